@@ -105,6 +105,43 @@ var (
 		},
 	)
 
+	sandwichStateMemberCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sandwich_state_member_cache_hits_total",
+			Help: "Sandwich State Guild Member Cache Hits",
+		},
+	)
+
+	sandwichStateMemberCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sandwich_state_member_cache_misses_total",
+			Help: "Sandwich State Guild Member Cache Misses",
+		},
+	)
+
+	sandwichStateMemberCacheEvictions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sandwich_state_member_cache_evictions_total",
+			Help: "Sandwich State Guild Member Cache Evictions",
+		},
+	)
+
+	sandwichGuildJoinRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sandwich_guild_join_rate",
+			Help: "Sandwich Guild Member Joins Within Window",
+		},
+		[]string{"guild_id", "window"},
+	)
+
+	sandwichGuildLeaveRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sandwich_guild_leave_rate",
+			Help: "Sandwich Guild Member Leaves Within Window",
+		},
+		[]string{"guild_id", "window"},
+	)
+
 	grpcCacheRequests = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "sandwich_grpc_requests_total",
@@ -125,4 +162,36 @@ var (
 			Help: "Sandwich GRPC Cache Misses",
 		},
 	)
+
+	sandwichSpoolDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sandwich_spool_depth",
+			Help: "Sandwich Spooled Payloads",
+		},
+		[]string{"manager"},
+	)
+
+	sandwichSpoolAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sandwich_spool_age_seconds",
+			Help: "Sandwich Oldest Spooled Payload Age",
+		},
+		[]string{"manager"},
+	)
+
+	sandwichSpoolDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sandwich_spool_dropped_total",
+			Help: "Sandwich Spooled Payloads Dropped When Full",
+		},
+		[]string{"manager"},
+	)
+
+	sandwichProducerRouteDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sandwich_producer_route_dropped_total",
+			Help: "Sandwich Events Dropped When A Producer Queue Is Full",
+		},
+		[]string{"manager", "producer"},
+	)
 )
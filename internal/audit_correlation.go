@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+// AuditCorrelationConfiguration configures how audit log entries are matched to events.
+type AuditCorrelationConfiguration struct {
+	// Number of seconds audit log entries and events are kept to be matched. 0 disables correlation.
+	Window int32 `json:"window" yaml:"window"`
+}
+
+type auditCorrelationKey struct {
+	guildID    discord.GuildID
+	targetID   discord.Snowflake
+	actionType discord.AuditLogActionType
+}
+
+type auditCorrelationEntry struct {
+	entry      discord.AuditLogEntry
+	receivedAt int64
+}
+
+type auditCorrelationEvent struct {
+	eventType  string
+	receivedAt int64
+}
+
+// AuditCorrelator keeps recent audit log entries and events so they can be matched when
+// either arrives first.
+type AuditCorrelator struct {
+	entries Cache[auditCorrelationKey, auditCorrelationEntry]
+	events  Cache[auditCorrelationKey, auditCorrelationEvent]
+}
+
+func NewAuditCorrelator() *AuditCorrelator {
+	return &AuditCorrelator{
+		entries: NewCache[auditCorrelationKey, auditCorrelationEntry](10),
+		events:  NewCache[auditCorrelationKey, auditCorrelationEvent](10),
+	}
+}
+
+// MatchEvent returns a recent audit log entry for the event. If there is none, the event is kept so
+// an audit log entry arriving later can be matched to it.
+func (ac *AuditCorrelator) MatchEvent(key auditCorrelationKey, eventType string, now int64, window int64) (entry *discord.AuditLogEntry) {
+	if correlationEntry, ok := ac.entries.Load(key); ok && now-correlationEntry.receivedAt <= window {
+		ac.entries.Delete(key)
+
+		return &correlationEntry.entry
+	}
+
+	ac.events.Store(key, auditCorrelationEvent{
+		eventType:  eventType,
+		receivedAt: now,
+	})
+
+	return nil
+}
+
+// MatchEntry returns the type of a recent event for the audit log entry. If there is none, the entry is
+// kept so an event arriving later can be matched to it.
+func (ac *AuditCorrelator) MatchEntry(key auditCorrelationKey, entry discord.AuditLogEntry, now int64, window int64) (eventType string, ok bool) {
+	if correlationEvent, ok := ac.events.Load(key); ok && now-correlationEvent.receivedAt <= window {
+		ac.events.Delete(key)
+
+		return correlationEvent.eventType, true
+	}
+
+	ac.entries.Store(key, auditCorrelationEntry{
+		entry:      entry,
+		receivedAt: now,
+	})
+
+	return "", false
+}
+
+// Expire removes entries and events received before the cutoff.
+func (ac *AuditCorrelator) Expire(cutoff int64) {
+	expiredEntries := make([]auditCorrelationKey, 0)
+
+	ac.entries.Range(func(key auditCorrelationKey, correlationEntry auditCorrelationEntry) bool {
+		if correlationEntry.receivedAt < cutoff {
+			expiredEntries = append(expiredEntries, key)
+		}
+
+		return false
+	})
+
+	for _, key := range expiredEntries {
+		ac.entries.Delete(key)
+	}
+
+	expiredEvents := make([]auditCorrelationKey, 0)
+
+	ac.events.Range(func(key auditCorrelationKey, correlationEvent auditCorrelationEvent) bool {
+		if correlationEvent.receivedAt < cutoff {
+			expiredEvents = append(expiredEvents, key)
+		}
+
+		return false
+	})
+
+	for _, key := range expiredEvents {
+		ac.events.Delete(key)
+	}
+}
+
+// auditCorrelationWindow returns the number of seconds to keep audit log entries and events for.
+func (mg *Manager) auditCorrelationWindow() int64 {
+	mg.configurationMu.RLock()
+	defer mg.configurationMu.RUnlock()
+
+	return int64(mg.Configuration.Events.AuditCorrelation.Window)
+}
+
+// CorrelateAuditEvent returns the audit log entry responsible for an event, if it has already been received.
+func (mg *Manager) CorrelateAuditEvent(eventType string, guildID discord.GuildID, actionType discord.AuditLogActionType, targetID discord.Snowflake) *discord.AuditLogEntry {
+	window := mg.auditCorrelationWindow()
+	if window <= 0 {
+		return nil
+	}
+
+	return mg.auditCorrelator.MatchEvent(auditCorrelationKey{
+		guildID:    guildID,
+		targetID:   targetID,
+		actionType: actionType,
+	}, eventType, time.Now().Unix(), window)
+}
+
+// CorrelateAuditEntry publishes SANDWICH_AUDIT_CORRELATED if an audit log entry is responsible for an
+// event that has already been received.
+func (mg *Manager) CorrelateAuditEntry(guildID discord.GuildID, entry discord.AuditLogEntry) {
+	window := mg.auditCorrelationWindow()
+	if window <= 0 || entry.TargetID == nil {
+		return
+	}
+
+	eventType, ok := mg.auditCorrelator.MatchEntry(auditCorrelationKey{
+		guildID:    guildID,
+		targetID:   *entry.TargetID,
+		actionType: entry.ActionType,
+	}, entry, time.Now().Unix(), window)
+	if !ok {
+		return
+	}
+
+	auditEntry, err := sandwichjson.Marshal(entry)
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to marshal audit log entry")
+
+		return
+	}
+
+	payload, err := sandwichjson.Marshal(sandwich_structs.AuditCorrelated{
+		EventType:  eventType,
+		GuildID:    int64(guildID),
+		TargetID:   int64(*entry.TargetID),
+		AuditEntry: auditEntry,
+	})
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to marshal audit correlation")
+
+		return
+	}
+
+	err = mg.PublishEvent(context.Background(), sandwich_structs.SandwichEventAuditCorrelated, json.RawMessage(payload))
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to publish audit correlation")
+	}
+}
+
+// ejectAuditCorrelations removes audit log entries and events that can no longer be matched.
+func (sg *Sandwich) ejectAuditCorrelations() {
+	now := time.Now().Unix()
+
+	sg.Managers.Range(func(_ string, mg *Manager) bool {
+		mg.auditCorrelator.Expire(now - mg.auditCorrelationWindow())
+
+		return false
+	})
+}
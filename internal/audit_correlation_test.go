@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+func TestAuditCorrelator(t *testing.T) {
+	correlator := NewAuditCorrelator()
+
+	now := int64(1_700_000_000)
+	reason := "spam"
+
+	key := auditCorrelationKey{
+		guildID:    1,
+		targetID:   2,
+		actionType: discord.AuditLogActionMemberBanAdd,
+	}
+
+	// Entry before the event.
+	if _, ok := correlator.MatchEntry(key, discord.AuditLogEntry{Reason: reason}, now, 10); ok {
+		t.Fatal("Expected no event to match the entry")
+	}
+
+	entry := correlator.MatchEvent(key, "GUILD_BAN_ADD", now+5, 10)
+	if entry == nil || entry.Reason != reason {
+		t.Fatalf("Expected the buffered entry to match the event, got %v", entry)
+	}
+
+	// Event before the entry.
+	if entry := correlator.MatchEvent(key, "GUILD_BAN_ADD", now+20, 10); entry != nil {
+		t.Fatal("Expected the matched entry to be removed")
+	}
+
+	eventType, ok := correlator.MatchEntry(key, discord.AuditLogEntry{Reason: reason}, now+25, 10)
+	if !ok || eventType != "GUILD_BAN_ADD" {
+		t.Fatalf("Expected the pending event to match the entry, got %q", eventType)
+	}
+
+	// Entries outside of the window do not match.
+	correlator.MatchEntry(key, discord.AuditLogEntry{Reason: reason}, now+30, 10)
+
+	if entry := correlator.MatchEvent(key, "GUILD_BAN_ADD", now+50, 10); entry != nil {
+		t.Fatal("Expected the entry outside of the window not to match")
+	}
+
+	correlator.Expire(now + 100)
+
+	if correlator.entries.Count() != 0 || correlator.events.Count() != 0 {
+		t.Error("Expected expired entries and events to be removed")
+	}
+}
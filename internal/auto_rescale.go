@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultAutoRescaleInterval = 3600
+
+	// Interval managers are checked for whether their gateway should be polled.
+	autoRescaleCheckInterval = time.Minute
+)
+
+// AutoRescaleConfiguration configures rescaling a manager when Discord recommends more shards.
+// Only applies to auto sharded managers that run every shard.
+type AutoRescaleConfiguration struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Seconds between polls of the recommended shard count. Defaults to 3600.
+	Interval int32 `json:"interval" yaml:"interval"`
+	// Logs and notifies the rescale that would happen without scaling.
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+}
+
+// autoRescaleShardCount returns the shard count to rescale to. The shard count is rounded up to a
+// multiple of max concurrency, so every identify bucket has the same number of shards. When Discord
+// has closed a shard as sharding is required, the shard count grows even if the recommendation has not.
+func autoRescaleShardCount(current int32, recommended int32, maxConcurrency int32, shardingRequired bool) (shardCount int32, ok bool) {
+	shardCount = recommended
+
+	if shardingRequired {
+		shardCount = max(shardCount, current+1)
+	}
+
+	if maxConcurrency > 1 && shardCount%maxConcurrency != 0 {
+		shardCount += maxConcurrency - shardCount%maxConcurrency
+	}
+
+	return shardCount, shardCount > current
+}
+
+func (mg *Manager) autoRescaleConfiguration() (configuration AutoRescaleConfiguration, autoSharded bool, shardIDs string) {
+	mg.configurationMu.RLock()
+	defer mg.configurationMu.RUnlock()
+
+	return mg.Configuration.Sharding.AutoRescale, mg.Configuration.Sharding.AutoSharded, mg.Configuration.Sharding.ShardIDs
+}
+
+func (mg *Manager) autoRescaleInterval() time.Duration {
+	configuration, _, _ := mg.autoRescaleConfiguration()
+
+	if configuration.Interval > 0 {
+		return time.Duration(configuration.Interval) * time.Second
+	}
+
+	return DefaultAutoRescaleInterval * time.Second
+}
+
+// isRescaling returns if a rescale is waiting for its shard group or handing off to it.
+func (mg *Manager) isRescaling() bool {
+	mg.rescaleMu.RLock()
+	defer mg.rescaleMu.RUnlock()
+
+	return mg.rescale != nil &&
+		(mg.rescale.phase == RescalePhaseConnecting || mg.rescale.phase == RescalePhaseHandoff)
+}
+
+// autoRescale polls the recommended shard count and rescales the manager if it has grown.
+// shardingRequired is set when Discord closed a shard with close code 4011.
+func (mg *Manager) autoRescale(shardingRequired bool) {
+	configuration, autoSharded, shardIDs := mg.autoRescaleConfiguration()
+	if !configuration.Enabled || !autoSharded || mg.IsClosing {
+		return
+	}
+
+	if shardIDs != "" {
+		mg.Logger.Warn().Msg("Cannot automatically rescale a manager with configured shard ids")
+
+		return
+	}
+
+	// Every shard of a manager receives 4011 at once, so only one check runs at a time.
+	if !mg.autoRescaleMu.TryLock() {
+		return
+	}
+	defer mg.autoRescaleMu.Unlock()
+
+	if mg.isRescaling() {
+		return
+	}
+
+	mg.lastAutoRescale.Store(time.Now())
+
+	gateway, err := mg.GetGateway()
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to get gateway for auto rescale")
+
+		return
+	}
+
+	mg.gatewayMu.Lock()
+	mg.Gateway = gateway
+	mg.gatewayMu.Unlock()
+
+	current := mg.noShards
+
+	shardCount, ok := autoRescaleShardCount(current, gateway.Shards, gateway.SessionStartLimit.MaxConcurrency, shardingRequired)
+	if !ok {
+		mg.Logger.Debug().Int32("shards", current).Int32("recommended", gateway.Shards).Msg("No auto rescale required")
+
+		return
+	}
+
+	footer := "Manager: " + mg.Identifier.Load()
+	description := fmt.Sprintf(
+		"Shard count: `%d` -> `%d` - Recommended: `%d` - Max concurrency: `%d`",
+		current, shardCount, gateway.Shards, gateway.SessionStartLimit.MaxConcurrency,
+	)
+
+	if shardingRequired {
+		description += " - Sharding required"
+	}
+
+	if gateway.SessionStartLimit.Remaining < shardCount {
+		mg.Logger.Warn().
+			Int32("shards", shardCount).
+			Int32("remaining", gateway.SessionStartLimit.Remaining).
+			Msg("Not enough session starts remaining to auto rescale")
+
+		go mg.Sandwich.PublishSimpleWebhook(
+			"Postponed automatic rescale",
+			description+fmt.Sprintf(" - Remaining session starts: `%d`", gateway.SessionStartLimit.Remaining),
+			footer,
+			EmbedColourWarning,
+		)
+
+		return
+	}
+
+	if configuration.DryRun {
+		mg.Logger.Info().Int32("from", current).Int32("to", shardCount).Msg("Would automatically rescale (dry run)")
+
+		go mg.Sandwich.PublishSimpleWebhook("Automatic rescale (dry run)", description, footer, EmbedColourSandwich)
+
+		return
+	}
+
+	mg.Logger.Info().Int32("from", current).Int32("to", shardCount).Msg("Automatically rescaling")
+
+	go mg.Sandwich.PublishSimpleWebhook("Automatically rescaling", description, footer, EmbedColourSandwich)
+
+	newShardIDs := make([]int32, 0, shardCount)
+	for i := int32(0); i < shardCount; i++ {
+		newShardIDs = append(newShardIDs, i)
+	}
+
+	mg.noShards = shardCount
+
+	sg := mg.Scale(newShardIDs, shardCount)
+
+	ready, err := sg.Open()
+	if err != nil {
+		// Cleanup ShardGroups to remove failed ShardGroup.
+		mg.ShardGroups.Delete(sg.ID)
+		mg.noShards = current
+
+		mg.Logger.Error().Err(err).Msg("Failed to automatically rescale")
+
+		go mg.Sandwich.PublishSimpleWebhook("Failed to automatically rescale", "`"+err.Error()+"`", footer, EmbedColourDanger)
+
+		return
+	}
+
+	<-ready
+
+	go mg.Sandwich.PublishSimpleWebhook("Automatically rescaled", description, footer, EmbedColourSandwich)
+}
+
+// autoRescaler periodically checks if managers should be rescaled.
+func (sg *Sandwich) autoRescaler() {
+	t := time.NewTicker(autoRescaleCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-sg.ctx.Done():
+			return
+		case <-t.C:
+			sg.Managers.Range(func(key string, mg *Manager) bool {
+				if mg.AllReady() && time.Since(mg.lastAutoRescale.Load()) >= mg.autoRescaleInterval() {
+					go mg.autoRescale(false)
+				}
+
+				return false
+			})
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package internal
+
+import "testing"
+
+func TestAutoRescaleShardCount(t *testing.T) {
+	tests := []struct {
+		name             string
+		current          int32
+		recommended      int32
+		maxConcurrency   int32
+		shardingRequired bool
+		shardCount       int32
+		ok               bool
+	}{
+		{"unchanged", 16, 16, 16, false, 16, false},
+		{"fewer recommended", 32, 20, 16, false, 32, false},
+		{"grown", 2, 3, 1, false, 3, true},
+		{"rounded to concurrency bucket", 16, 18, 16, false, 32, true},
+		{"sharding required", 1, 1, 1, true, 2, true},
+		{"sharding required rounded", 16, 16, 16, true, 32, true},
+	}
+
+	for _, test := range tests {
+		shardCount, ok := autoRescaleShardCount(test.current, test.recommended, test.maxConcurrency, test.shardingRequired)
+		if shardCount != test.shardCount || ok != test.ok {
+			t.Errorf("%s: expected %d %v, got %d %v", test.name, test.shardCount, test.ok, shardCount, ok)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	gotils_strings "github.com/savsgio/gotils/strings"
+)
+
+type BackpressurePolicy string
+
+const (
+	// Shards pause reading from the gateway until the producer catches up.
+	BackpressurePolicyBlock BackpressurePolicy = "block"
+	// Low priority events are dropped until the producer catches up.
+	BackpressurePolicyDrop BackpressurePolicy = "drop"
+	// Events are written to the producer spool until the producer catches up.
+	BackpressurePolicySpool BackpressurePolicy = "spool"
+)
+
+const (
+	DefaultBackpressureHighWatermark = 0.8
+
+	// Interval the producer queue length is sampled at.
+	backpressureSampleInterval = 50 * time.Millisecond
+)
+
+// Event types dropped by the drop policy when none are configured.
+var defaultBackpressureLowPriorityEvents = []string{
+	discord.DiscordEventPresenceUpdate,
+	discord.DiscordEventTypingStart,
+}
+
+// BackpressureConfiguration configures how a manager reacts to a producer that cannot keep up.
+type BackpressureConfiguration struct {
+	// Policy applied while the producer queue is above the high watermark. Either "block", "drop"
+	// or "spool". Backpressure is disabled if empty. The spool policy requires the producer spool.
+	Policy BackpressurePolicy `json:"policy" yaml:"policy"`
+	// Fraction of the producer queue capacity backpressure starts at. Defaults to 0.8.
+	HighWatermark float64 `json:"high_watermark" yaml:"high_watermark"`
+	// Fraction of the producer queue capacity backpressure stops at. Defaults to half the high watermark.
+	LowWatermark float64 `json:"low_watermark" yaml:"low_watermark"`
+	// Event types dropped by the drop policy. Defaults to PRESENCE_UPDATE and TYPING_START.
+	LowPriorityEvents []string `json:"low_priority_events" yaml:"low_priority_events"`
+}
+
+// Watermarks returns the queue lengths backpressure starts and stops at for a producer queue capacity.
+func (bc BackpressureConfiguration) Watermarks(capacity int) (high int, low int) {
+	highFraction := bc.HighWatermark
+	if highFraction <= 0 || highFraction > 1 {
+		highFraction = DefaultBackpressureHighWatermark
+	}
+
+	lowFraction := bc.LowWatermark
+	if lowFraction <= 0 || lowFraction >= highFraction {
+		lowFraction = highFraction / 2
+	}
+
+	return max(int(float64(capacity)*highFraction), 1), int(float64(capacity) * lowFraction)
+}
+
+// IsLowPriority returns if an event type is dropped by the drop policy.
+func (bc BackpressureConfiguration) IsLowPriority(eventType string) bool {
+	if len(bc.LowPriorityEvents) == 0 {
+		return gotils_strings.Include(defaultBackpressureLowPriorityEvents, eventType)
+	}
+
+	return gotils_strings.Include(bc.LowPriorityEvents, eventType)
+}
+
+func (mg *Manager) backpressureConfiguration() BackpressureConfiguration {
+	mg.configurationMu.RLock()
+	defer mg.configurationMu.RUnlock()
+
+	return mg.Configuration.Backpressure
+}
+
+// producerQueueLength returns the number of payloads queued by the producer and how many it can queue.
+// Producers that do not queue payloads always return 0.
+func (mg *Manager) producerQueueLength() (length int, capacity int) {
+	if queue, ok := mg.ProducerClient.(MQQueueLength); ok {
+		return queue.QueueLength(), queue.QueueCapacity()
+	}
+
+	return 0, 0
+}
+
+// sampleBackpressure samples the producer queue and updates if the manager is backpressured. The
+// producer is backpressured once its queue crosses the high watermark, until it falls back to the
+// low watermark.
+func (mg *Manager) sampleBackpressure() {
+	configuration := mg.backpressureConfiguration()
+	if configuration.Policy == "" {
+		mg.backpressured.Store(false)
+
+		return
+	}
+
+	queueLength, capacity := mg.producerQueueLength()
+	mg.producerQueue.Store(int64(queueLength))
+	mg.producerQueueCapacity.Store(int64(capacity))
+
+	// Producers without a queue cannot be backpressured.
+	if capacity <= 0 {
+		mg.backpressured.Store(false)
+
+		return
+	}
+
+	high, low := configuration.Watermarks(capacity)
+
+	if queueLength >= high {
+		if !mg.backpressured.Swap(true) {
+			mg.Logger.Warn().Int("queue", queueLength).Str("policy", string(configuration.Policy)).Msg("Producer is backpressured")
+		}
+	} else if queueLength <= low {
+		if mg.backpressured.Swap(false) {
+			mg.Logger.Info().Int("queue", queueLength).Msg("Producer has caught up")
+		}
+	}
+}
+
+// isBackpressured returns if the producer queue was above its watermark when last sampled.
+func (mg *Manager) isBackpressured() bool {
+	return mg.backpressured.Load()
+}
+
+// backpressureSampler periodically samples the producer queues of managers.
+func (sg *Sandwich) backpressureSampler() {
+	t := time.NewTicker(backpressureSampleInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-sg.ctx.Done():
+			return
+		case <-t.C:
+			sg.Managers.Range(func(key string, mg *Manager) bool {
+				mg.sampleBackpressure()
+
+				return false
+			})
+		}
+	}
+}
+
+// applyBackpressure returns if a payload should be dropped or spooled instead of published.
+func (mg *Manager) applyBackpressure(packet *sandwich_structs.SandwichPayload) (drop bool, spool bool) {
+	if !mg.isBackpressured() {
+		return false, false
+	}
+
+	configuration := mg.backpressureConfiguration()
+
+	switch configuration.Policy {
+	case BackpressurePolicyDrop:
+		if configuration.IsLowPriority(packet.Type) {
+			mg.backpressureDropped.Inc()
+
+			return true, false
+		}
+	case BackpressurePolicySpool:
+		return false, mg.spool != nil
+	}
+
+	return false, false
+}
+
+// backpressureStatus returns the backpressure state for the status endpoint.
+func (mg *Manager) backpressureStatus() *sandwich_structs.StatusEndpointBackpressure {
+	configuration := mg.backpressureConfiguration()
+	if configuration.Policy == "" {
+		return nil
+	}
+
+	return &sandwich_structs.StatusEndpointBackpressure{
+		Policy:        string(configuration.Policy),
+		Active:        mg.isBackpressured(),
+		QueueLength:   int(mg.producerQueue.Load()),
+		QueueCapacity: int(mg.producerQueueCapacity.Load()),
+		Dropped:       mg.backpressureDropped.Load(),
+	}
+}
+
+// waitForBackpressure pauses reading from the gateway while the producer is backpressured under the
+// block policy. Shards pause for at most half of each heartbeat interval, so heartbeat acknowledgements
+// are still read in time.
+func (sh *Shard) waitForBackpressure(ctx context.Context) {
+	if sh.HeartbeatInterval <= 0 || sh.Manager.backpressureConfiguration().Policy != BackpressurePolicyBlock ||
+		!sh.Manager.isBackpressured() {
+		return
+	}
+
+	now := time.Now()
+
+	if now.Sub(sh.backpressureWindow) > sh.HeartbeatInterval {
+		sh.backpressureWindow = now
+		sh.backpressurePaused = 0
+	}
+
+	budget := sh.HeartbeatInterval/2 - sh.backpressurePaused
+	if budget <= 0 {
+		return
+	}
+
+	t := time.NewTicker(backpressureSampleInterval)
+	defer t.Stop()
+
+	defer func() {
+		sh.backpressurePaused += time.Since(now)
+	}()
+
+	for time.Since(now) < budget && sh.Manager.isBackpressured() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
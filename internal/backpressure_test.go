@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+)
+
+func TestBackpressureDrop(t *testing.T) {
+	producer := &testMQClient{capacity: 20}
+
+	mg := &Manager{
+		ProducerClient: producer,
+		Configuration: &ManagerConfiguration{
+			Backpressure: BackpressureConfiguration{
+				Policy:        BackpressurePolicyDrop,
+				HighWatermark: 0.5,
+			},
+		},
+	}
+
+	publish := func(eventType string) {
+		t.Helper()
+
+		if err := mg.publish(context.Background(), &structs.SandwichPayload{Type: eventType}, "sandwich"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publish(discord.DiscordEventPresenceUpdate)
+
+	producer.queue = 10
+	mg.sampleBackpressure()
+
+	publish(discord.DiscordEventPresenceUpdate)
+	publish(discord.DiscordEventMessageCreate)
+
+	// Backpressure continues until the queue falls to the low watermark.
+	producer.queue = 6
+	mg.sampleBackpressure()
+
+	publish(discord.DiscordEventTypingStart)
+
+	status := mg.backpressureStatus()
+	if !status.Active || status.Dropped != 2 || status.QueueLength != 6 {
+		t.Fatalf("unexpected backpressure status %+v", status)
+	}
+
+	producer.queue = 5
+	mg.sampleBackpressure()
+
+	publish(discord.DiscordEventTypingStart)
+
+	expected := []string{
+		"sandwich:" + discord.DiscordEventPresenceUpdate,
+		"sandwich:" + discord.DiscordEventMessageCreate,
+		"sandwich:" + discord.DiscordEventTypingStart,
+	}
+
+	if len(producer.published) != len(expected) {
+		t.Fatalf("expected %v to be published, got %v", expected, producer.published)
+	}
+
+	for i := range expected {
+		if producer.published[i] != expected[i] {
+			t.Fatalf("expected %v to be published, got %v", expected, producer.published)
+		}
+	}
+}
+
+func TestBackpressureWatermarks(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration BackpressureConfiguration
+		capacity      int
+		high          int
+		low           int
+	}{
+		{"defaults", BackpressureConfiguration{}, 16, 12, 6},
+		{"configured", BackpressureConfiguration{HighWatermark: 0.9, LowWatermark: 0.1}, 10000, 9000, 1000},
+		{"low above high", BackpressureConfiguration{HighWatermark: 0.5, LowWatermark: 0.6}, 100, 50, 25},
+		{"small queue", BackpressureConfiguration{}, 1, 1, 0},
+	}
+
+	for _, test := range tests {
+		high, low := test.configuration.Watermarks(test.capacity)
+		if high != test.high || low != test.low {
+			t.Errorf("%s: expected %d %d, got %d %d", test.name, test.high, test.low, high, low)
+		}
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"sync"
 
 	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
 )
@@ -25,19 +24,20 @@ type Client struct {
 	URLHost   string
 	URLScheme string
 	UserAgent string
-	mu        sync.Mutex
+
+	RateLimiter *RateLimiter
 }
 
 // NewClient makes a new client.
 func NewClient(baseURL url.URL, token string) *Client {
 	return &Client{
-		mu:         sync.Mutex{},
-		Token:      token,
-		HTTP:       http.DefaultClient,
-		APIVersion: "9",
-		URLHost:    baseURL.Host,
-		URLScheme:  baseURL.Scheme,
-		UserAgent:  "Sandwich/" + VERSION + " (github.com/WelcomerTeam/Sandwich-Daemon)",
+		Token:       token,
+		HTTP:        http.DefaultClient,
+		APIVersion:  "9",
+		URLHost:     baseURL.Host,
+		URLScheme:   baseURL.Scheme,
+		UserAgent:   "Sandwich/" + VERSION + " (github.com/WelcomerTeam/Sandwich-Daemon)",
+		RateLimiter: NewRateLimiter(),
 	}
 }
 
@@ -88,11 +88,9 @@ func (c *Client) FetchJSON(ctx context.Context, method string, url string, body
 	return status, nil
 }
 
-// HandleRequest makes a request to the Discord API.
+// HandleRequest makes a request to the Discord API. Requests wait for their ratelimit bucket
+// and, if retry is set, are retried when ratelimited.
 func (c *Client) HandleRequest(req *http.Request, retry bool) (*http.Response, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	// Add the /api and version prefix when we did not include one
 	if !strings.HasPrefix(req.URL.Path, "/api") {
 		req.URL.Path = "/api/v" + c.APIVersion + req.URL.Path
@@ -107,13 +105,45 @@ func (c *Client) HandleRequest(req *http.Request, retry bool) (*http.Response, e
 		req.Header.Set("Authorization", replaceIfEmpty(req.Header.Get("Authorization"), "Bot "+c.Token))
 	}
 
-	res, err := c.HTTP.Do(req)
-	if err != nil || res == nil {
-		return res, fmt.Errorf("failed to do HTTP request: %w", err)
+	route := RateLimitRoute(req.Method, req.URL.Path)
+
+	var res *http.Response
+
+	for attempt := 0; ; attempt++ {
+		bucket, err := c.RateLimiter.Acquire(req.Context(), route)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for ratelimit: %w", err)
+		}
+
+		res, err = c.HTTP.Do(req)
+		if err != nil || res == nil {
+			c.RateLimiter.Release(route, bucket, 0, nil)
+
+			return res, fmt.Errorf("failed to do HTTP request: %w", err)
+		}
+
+		c.RateLimiter.Release(route, bucket, res.StatusCode, res.Header)
+
+		if res.StatusCode != http.StatusTooManyRequests || !retry || attempt >= RateLimitMaxRetries {
+			break
+		}
+
+		if req.Body != nil {
+			if req.GetBody == nil {
+				break
+			}
+
+			req.Body, err = req.GetBody()
+			if err != nil {
+				break
+			}
+		}
+
+		res.Body.Close()
 	}
 
 	if res.StatusCode == http.StatusTooManyRequests {
-		return res, fmt.Errorf("failed to do HTTP request, got 429")
+		return res, ErrRateLimited
 	}
 
 	if res.StatusCode == http.StatusUnauthorized {
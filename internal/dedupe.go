@@ -33,14 +33,23 @@ func (sg *Sandwich) CheckDedupe(key string) bool {
 // CheckMemberDedupe returns if a dedupe is set. If true, event should be ignored.
 // Adds dedupe if not set.
 func (sg *Sandwich) CheckAndAddDedupe(key string) bool {
-	value, ok := sg.Dedupe.Load(key)
+	return sg.CheckAndAddDedupeExpiration(key, memberDedupeExpiration)
+}
 
-	has := time.Now().Unix() < value && value != 0
-	if !has || !ok {
-		sg.Dedupe.Store(key, time.Now().Add(memberDedupeExpiration).Unix())
-	}
-	// println("CheckAndAddDedupe", key, has)
-	return has && ok
+// CheckAndAddDedupeExpiration returns if a dedupe is set. If true, event should be ignored.
+// Adds dedupe expiring after expiration if not set.
+func (sg *Sandwich) CheckAndAddDedupeExpiration(key string, expiration time.Duration) bool {
+	var has bool
+
+	// Checked and set atomically, as shards of different shard groups may receive the same event.
+	sg.Dedupe.SetIf(key, func(value int64, ok bool) (int64, bool) {
+		now := time.Now()
+		has = ok && now.Unix() < value && value != 0
+
+		return now.Add(expiration).Unix(), !has
+	})
+
+	return has
 }
 
 // RemoveDedupe removes a dedupe.
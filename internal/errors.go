@@ -21,6 +21,7 @@ var (
 	ErrInvalidShard      = errors.New("invalid shard id specified")
 	ErrChunkTimeout      = errors.New("timed out on initial member chunks")
 	ErrMissingShards     = errors.New("shardGroup has no shards")
+	ErrGuildNotFound     = errors.New("guild is not on any shard")
 )
 
 var (
@@ -36,4 +37,37 @@ var (
 	ErrNoGatewayHandler  = errors.New("no registered handler for gateway event")
 	ErrNoDispatchHandler = errors.New("no registered handler for dispatch event")
 	ErrProducerMissing   = errors.New("no producer client found")
+	ErrProducerClosed    = errors.New("producer client is closed")
 )
+
+var (
+	ErrSnapshotInvalid     = errors.New("file is not a state snapshot")
+	ErrSnapshotVersion     = errors.New("unsupported state snapshot version")
+	ErrSnapshotValueLength = errors.New("state snapshot value exceeds maximum length")
+)
+
+var ErrInvalidStateBackend = errors.New("invalid state backend specified")
+
+var ErrStateUpdateConflict = errors.New("state was changed by another process while updating")
+
+var ErrUnknownCachePolicy = errors.New("unknown cache policy entries")
+
+var ErrUnexpectedStatus = errors.New("unexpected response status")
+
+var ErrRateLimited = errors.New("request was ratelimited")
+
+var ErrAMQPNotConnected = errors.New("amqp channel is not connected")
+
+var ErrInvalidProducerRoute = errors.New("invalid producer route")
+
+var ErrInvalidGatewayCompression = errors.New("invalid gateway compression")
+
+var ErrInvalidETF = errors.New("invalid etf")
+
+var ErrInvalidIdentifyCoordinatorBackend = errors.New("invalid identify coordinator backend")
+
+var ErrIdentifyCoordinatorDisabled = errors.New("identify coordinator is not enabled")
+
+var ErrIdentifyCoordinatorSecret = errors.New("identify coordinator requires a secret")
+
+var ErrProxySecret = errors.New("proxy requires a secret")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"runtime/debug"
 	"time"
 
@@ -84,9 +85,14 @@ func (sh *Shard) OnDispatch(ctx context.Context, msg discord.GatewayPayload, tra
 	cacheUsers := sh.Manager.Configuration.Caching.CacheUsers
 	cacheMembers := sh.Manager.Configuration.Caching.CacheMembers
 	storeMutuals := sh.Manager.Configuration.Caching.StoreMutuals
+	trackInvites := sh.Manager.Configuration.Caching.TrackInvites
 	disableTrace := sh.Manager.Configuration.DisableTrace
 	sh.Manager.configurationMu.RUnlock()
 
+	sh.Manager.cachePoliciesMu.RLock()
+	cachePolicies := sh.Manager.cachePolicies
+	sh.Manager.cachePoliciesMu.RUnlock()
+
 	if !disableTrace {
 		if trace == nil {
 			trace = make(map[string]discord.Int64)
@@ -101,6 +107,9 @@ func (sh *Shard) OnDispatch(ctx context.Context, msg discord.GatewayPayload, tra
 		CacheUsers:   cacheUsers,
 		CacheMembers: cacheMembers,
 		StoreMutuals: storeMutuals,
+		TrackInvites: trackInvites,
+
+		CachePolicies: cachePolicies,
 	}, msg, trace)
 
 	if err != nil {
@@ -126,14 +135,18 @@ func (sh *Shard) OnDispatch(ctx context.Context, msg discord.GatewayPayload, tra
 		return nil
 	}
 
-	sh.Manager.produceBlacklistMu.RLock()
-	contains = gotils_strings.Include(sh.Manager.produceBlacklist, msg.Type)
-	sh.Manager.produceBlacklistMu.RUnlock()
-
-	if contains {
+	if msg.Op == discord.GatewayOpDispatch && sh.Manager.isHandoffDuplicate(sh.ShardGroup.ID, msg) {
 		return nil
 	}
 
+	// Events are published as JSON, so data received with ETF is only transcoded once it is published.
+	if isETF(result.Data) {
+		result.Data, err = DecodeETF(result.Data)
+		if err != nil {
+			return fmt.Errorf("failed to transcode event: %w", err)
+		}
+	}
+
 	packet := &sandwich_structs.SandwichPayload{
 		Op:                      msg.Op,
 		Sequence:                msg.Sequence,
@@ -113,6 +113,13 @@ func OnResumed(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.
 
 	ctx.SetStatus(sandwich_structs.ShardStatusReady)
 
+	// Discord replays any events missed while resuming, so guilds of the shard
+	// are now up to date even if they were loaded from a snapshot.
+	ctx.Guilds.Range(func(guildID discord.GuildID, _ struct{}) bool {
+		ctx.Sandwich.State.ClearGuildStale(guildID)
+		return false
+	})
+
 	return EventDispatch{
 		Data:                    msg.Data,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{},
@@ -130,6 +137,11 @@ func OnGuildCreate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_stru
 	defer ctx.OnGuildDispatchEvent(msg.Type, guildCreatePayload.ID)
 
 	ctx.Sandwich.State.SetGuild(ctx, discord.Guild(guildCreatePayload))
+	ctx.Sandwich.State.ClearGuildStale(guildCreatePayload.ID)
+
+	if ctx.TrackInvites {
+		ctx.Manager.QueueSeedGuildInvites(guildCreatePayload.ID)
+	}
 
 	lazy, _ := ctx.Lazy.Load(guildCreatePayload.ID)
 	ctx.Lazy.Delete(guildCreatePayload.ID)
@@ -240,7 +252,7 @@ func OnChannelCreate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_st
 		ctx.Sandwich.State.SetGuildChannel(ctx, *channelCreatePayload.GuildID, discord.Channel(channelCreatePayload))
 	} else if channelCreatePayload.Type == discord.ChannelTypeDM || channelCreatePayload.Type == discord.ChannelTypeGroupDM {
 		if len(channelCreatePayload.Recipients) > 0 {
-			ctx.Sandwich.State.AddDMChannel(channelCreatePayload.Recipients[0].ID, discord.Channel(channelCreatePayload))
+			ctx.Sandwich.State.AddDMChannel(ctx, channelCreatePayload.Recipients[0].ID, discord.Channel(channelCreatePayload))
 		}
 	}
 
@@ -319,9 +331,20 @@ func OnChannelDelete(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_st
 		ctx.Sandwich.State.RemoveDMChannelByChannelID(channelDeletePayload.ID)
 	}
 
-	extra, err := makeExtra(map[string]interface{}{
+	ctx.Sandwich.State.RemoveChannelMessages(channelDeletePayload.ID)
+
+	extras := map[string]interface{}{
 		"before": beforeChannel,
-	})
+	}
+
+	if channelDeletePayload.GuildID != nil {
+		if auditEntry := ctx.Manager.CorrelateAuditEvent(
+			msg.Type, *channelDeletePayload.GuildID, discord.AuditLogActionChannelDelete, discord.Snowflake(channelDeletePayload.ID)); auditEntry != nil {
+			extras["audit_entry"] = auditEntry
+		}
+	}
+
+	extra, err := makeExtra(extras)
 	if err != nil {
 		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
 	}
@@ -365,9 +388,49 @@ func OnChannelPinsUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwic
 	}, true, nil
 }
 
-func OnThreadUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
-	defer ctx.OnDispatchEvent(msg.Type)
+func OnThreadCreate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
+	var threadCreatePayload discord.ThreadCreate
+
+	err = ctx.decodeContent(msg, &threadCreatePayload)
+	if err != nil {
+		return result, false, err
+	}
+
+	defer ctx.SafeOnGuildDispatchEvent(msg.Type, threadCreatePayload.GuildID)
+
+	var beforeThread *discord.Channel
+
+	// Only supports guilds anyways
+	if threadCreatePayload.GuildID != nil && !threadCreatePayload.GuildID.IsNil() {
+		// THREAD_CREATE is also sent when added to an existing private thread.
+		if beforeThreadV, ok := ctx.Sandwich.State.GetGuildChannel(*threadCreatePayload.GuildID, threadCreatePayload.ID); ok {
+			beforeThread = &beforeThreadV
+		}
+
+		ctx.Sandwich.State.SetGuildThread(ctx, *threadCreatePayload.GuildID, discord.Channel(threadCreatePayload))
+
+		if threadCreatePayload.ThreadMember != nil {
+			ctx.Sandwich.State.SetThreadMember(ctx, *threadCreatePayload.GuildID, *threadCreatePayload.ThreadMember)
+		}
+	}
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before": beforeThread,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
+	return EventDispatch{
+		Data:  msg.Data,
+		Extra: extra,
+		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
+			GuildID: threadCreatePayload.GuildID,
+		},
+	}, true, nil
+}
 
+func OnThreadUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
 	var threadUpdatePayload discord.ThreadUpdate
 
 	err = ctx.decodeContent(msg, &threadUpdatePayload)
@@ -375,16 +438,20 @@ func OnThreadUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_str
 		return result, false, err
 	}
 
-	var beforeChannel *discord.Channel
+	defer ctx.SafeOnGuildDispatchEvent(msg.Type, threadUpdatePayload.GuildID)
+
+	var beforeThread *discord.Channel
 
 	// Only supports guilds anyways
 	if threadUpdatePayload.GuildID != nil && !threadUpdatePayload.GuildID.IsNil() {
-		beforeChannelV, _ := ctx.Sandwich.State.GetGuildChannel(*threadUpdatePayload.GuildID, threadUpdatePayload.ID)
-		beforeChannel = &beforeChannelV
+		beforeThreadV, _ := ctx.Sandwich.State.GetGuildChannel(*threadUpdatePayload.GuildID, threadUpdatePayload.ID)
+		beforeThread = &beforeThreadV
+
+		ctx.Sandwich.State.SetGuildThread(ctx, *threadUpdatePayload.GuildID, discord.Channel(threadUpdatePayload))
 	}
 
 	extra, err := makeExtra(map[string]interface{}{
-		"before": beforeChannel,
+		"before": beforeThread,
 	})
 	if err != nil {
 		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
@@ -399,6 +466,229 @@ func OnThreadUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_str
 	}, true, nil
 }
 
+func OnThreadDelete(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
+	var threadDeletePayload discord.ThreadDelete
+
+	err = ctx.decodeContent(msg, &threadDeletePayload)
+	if err != nil {
+		return result, false, err
+	}
+
+	defer ctx.SafeOnGuildDispatchEvent(msg.Type, threadDeletePayload.GuildID)
+
+	var beforeThread *discord.Channel
+
+	// Only supports guilds anyways
+	if threadDeletePayload.GuildID != nil && !threadDeletePayload.GuildID.IsNil() {
+		beforeThreadV, _ := ctx.Sandwich.State.GetGuildChannel(*threadDeletePayload.GuildID, threadDeletePayload.ID)
+		beforeThread = &beforeThreadV
+
+		ctx.Sandwich.State.RemoveGuildThread(*threadDeletePayload.GuildID, threadDeletePayload.ID)
+	}
+
+	ctx.Sandwich.State.RemoveChannelMessages(threadDeletePayload.ID)
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before": beforeThread,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
+	return EventDispatch{
+		Data:  msg.Data,
+		Extra: extra,
+		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
+			GuildID: threadDeletePayload.GuildID,
+		},
+	}, true, nil
+}
+
+func OnThreadListSync(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
+	var threadListSyncPayload discord.ThreadListSync
+
+	err = ctx.decodeContent(msg, &threadListSyncPayload)
+	if err != nil {
+		return result, false, err
+	}
+
+	defer ctx.OnGuildDispatchEvent(msg.Type, threadListSyncPayload.GuildID)
+
+	// If no parent channels are given, the threads of the entire guild are being synced.
+	parentIDs := make(map[discord.ChannelID]bool, len(threadListSyncPayload.ChannelIDs))
+	for _, channelID := range threadListSyncPayload.ChannelIDs {
+		parentIDs[channelID] = true
+	}
+
+	syncedThreadIDs := make(map[discord.ChannelID]bool, len(threadListSyncPayload.Threads))
+	for _, thread := range threadListSyncPayload.Threads {
+		syncedThreadIDs[thread.ID] = true
+	}
+
+	beforeThreads := make([]discord.Channel, 0)
+
+	for _, thread := range ctx.Sandwich.State.GetAllGuildThreads(threadListSyncPayload.GuildID) {
+		if len(parentIDs) > 0 && (thread.ParentID == nil || !parentIDs[*thread.ParentID]) {
+			continue
+		}
+
+		beforeThreads = append(beforeThreads, thread)
+
+		// Threads that are no longer active are not included in the sync.
+		if !syncedThreadIDs[thread.ID] {
+			ctx.Sandwich.State.RemoveGuildThread(threadListSyncPayload.GuildID, thread.ID)
+		}
+	}
+
+	threadMembers := make(map[discord.ChannelID]discord.ThreadMember, len(threadListSyncPayload.Members))
+	for _, threadMember := range threadListSyncPayload.Members {
+		if threadMember.ID != nil {
+			threadMembers[*threadMember.ID] = threadMember
+		}
+	}
+
+	for _, thread := range threadListSyncPayload.Threads {
+		if threadMember, ok := threadMembers[thread.ID]; ok {
+			thread.ThreadMember = &threadMember
+
+			ctx.Sandwich.State.SetThreadMember(ctx, threadListSyncPayload.GuildID, threadMember)
+		}
+
+		ctx.Sandwich.State.SetGuildThread(ctx, threadListSyncPayload.GuildID, thread)
+	}
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before": beforeThreads,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
+	return EventDispatch{
+		Data:  msg.Data,
+		Extra: extra,
+		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
+			GuildID: &threadListSyncPayload.GuildID,
+		},
+	}, true, nil
+}
+
+func OnThreadMemberUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
+	var threadMemberUpdatePayload discord.ThreadMemberUpdate
+
+	err = ctx.decodeContent(msg, &threadMemberUpdatePayload)
+	if err != nil {
+		return result, false, err
+	}
+
+	defer ctx.SafeOnGuildDispatchEvent(msg.Type, threadMemberUpdatePayload.GuildID)
+
+	var beforeThreadMember *discord.ThreadMember
+
+	// THREAD_MEMBER_UPDATE is only sent for the bot, so user_id may be omitted.
+	if threadMemberUpdatePayload.UserID == nil {
+		threadMemberUpdatePayload.UserID = &ctx.Manager.User.ID
+	}
+
+	if threadMemberUpdatePayload.ID != nil && threadMemberUpdatePayload.GuildID != nil {
+		guildID := *threadMemberUpdatePayload.GuildID
+		threadMember := discord.ThreadMember(threadMemberUpdatePayload)
+
+		if beforeThreadMemberV, ok := ctx.Sandwich.State.GetThreadMember(*threadMember.ID, *threadMember.UserID); ok {
+			beforeThreadMember = &beforeThreadMemberV
+		}
+
+		ctx.Sandwich.State.SetThreadMember(ctx, guildID, threadMember)
+
+		ctx.Sandwich.State.UpdateGuildChannel(guildID, *threadMember.ID, func(thread discord.Channel) discord.Channel {
+			thread.ThreadMember = &threadMember
+			return thread
+		})
+	}
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before": beforeThreadMember,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
+	return EventDispatch{
+		Data:  msg.Data,
+		Extra: extra,
+		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
+			GuildID: threadMemberUpdatePayload.GuildID,
+		},
+	}, true, nil
+}
+
+func OnThreadMembersUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
+	var threadMembersUpdatePayload discord.ThreadMembersUpdate
+
+	err = ctx.decodeContent(msg, &threadMembersUpdatePayload)
+	if err != nil {
+		return result, false, err
+	}
+
+	defer ctx.OnGuildDispatchEvent(msg.Type, threadMembersUpdatePayload.GuildID)
+
+	guildID := threadMembersUpdatePayload.GuildID
+	threadID := threadMembersUpdatePayload.ID
+
+	var beforeThread *discord.Channel
+
+	if beforeThreadV, ok := ctx.Sandwich.State.GetGuildChannel(guildID, threadID); ok {
+		beforeThread = &beforeThreadV
+	}
+
+	removedThreadMembers := make([]discord.ThreadMember, 0, len(threadMembersUpdatePayload.RemovedMemberIDs))
+
+	for _, userID := range threadMembersUpdatePayload.RemovedMemberIDs {
+		if threadMember, ok := ctx.Sandwich.State.GetThreadMember(threadID, userID); ok {
+			removedThreadMembers = append(removedThreadMembers, threadMember)
+		}
+
+		ctx.Sandwich.State.RemoveThreadMember(threadID, userID)
+	}
+
+	for _, threadMember := range threadMembersUpdatePayload.AddedMembers {
+		if threadMember.ID == nil {
+			threadMember.ID = &threadID
+		}
+
+		ctx.Sandwich.State.SetThreadMember(ctx, guildID, threadMember)
+	}
+
+	ctx.Sandwich.State.UpdateGuildChannel(guildID, threadID, func(thread discord.Channel) discord.Channel {
+		thread.MemberCount = threadMembersUpdatePayload.MemberCount
+
+		// Clear the thread member of the bot if it was removed from the thread.
+		if thread.ThreadMember != nil && thread.ThreadMember.UserID != nil {
+			if _, ok := ctx.Sandwich.State.GetThreadMember(threadID, *thread.ThreadMember.UserID); !ok {
+				thread.ThreadMember = nil
+			}
+		}
+
+		return thread
+	})
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before":          beforeThread,
+		"removed_members": removedThreadMembers,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
+	return EventDispatch{
+		Data:  msg.Data,
+		Extra: extra,
+		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
+			GuildID: &threadMembersUpdatePayload.GuildID,
+		},
+	}, true, nil
+}
+
 func OnGuildAuditLogEntryCreate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_structs.SandwichTrace) (result EventDispatch, ok bool, err error) {
 	defer ctx.OnDispatchEvent(msg.Type)
 
@@ -409,6 +699,8 @@ func OnGuildAuditLogEntryCreate(ctx StateCtx, msg discord.GatewayPayload, trace
 		return result, false, err
 	}
 
+	ctx.Manager.CorrelateAuditEntry(threadMembersUpdatePayload.GuildID, threadMembersUpdatePayload.AuditLogEntry)
+
 	return EventDispatch{
 		Data: msg.Data,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
@@ -538,8 +830,15 @@ func OnGuildBanAdd(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_stru
 
 	defer ctx.OnGuildDispatchEvent(msg.Type, *guildBanAddPayload.GuildID)
 
+	extra, err := makeAuditExtra(ctx.Manager.CorrelateAuditEvent(
+		msg.Type, *guildBanAddPayload.GuildID, discord.AuditLogActionMemberBanAdd, discord.Snowflake(guildBanAddPayload.User.ID)))
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
 	return EventDispatch{
-		Data: msg.Data,
+		Data:  msg.Data,
+		Extra: extra,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
 			GuildID: guildBanAddPayload.GuildID,
 		},
@@ -666,7 +965,7 @@ func OnGuildMemberAdd(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_s
 	if !ctx.Sandwich.CheckAndAddDedupe(ddAddKey) {
 		ctx.Sandwich.RemoveDedupe(ddRemoveKey)
 
-		ctx.Sandwich.State.Guilds.Update(*guildMemberAddPayload.GuildID, func(guild discord.Guild) discord.Guild {
+		ctx.Sandwich.State.UpdateGuild(*guildMemberAddPayload.GuildID, func(guild discord.Guild) discord.Guild {
 			guild.MemberCount++
 			return guild
 		})
@@ -687,8 +986,28 @@ func OnGuildMemberAdd(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_s
 		ctx.Sandwich.State.AddUserMutualGuild(ctx, guildMemberAddPayload.User.ID, *guildMemberAddPayload.GuildID)
 	}
 
+	extras := ctx.Manager.OnGuildMemberJoin(*guildMemberAddPayload.GuildID, *guildMemberAddPayload.User)
+
+	if ctx.TrackInvites && !guildMemberAddPayload.User.Bot {
+		inviteUse, inviteErr := ctx.Manager.TrackGuildInviteUse(ctx.context, *guildMemberAddPayload.GuildID)
+		if inviteErr != nil {
+			ctx.Logger.Debug().Err(inviteErr).Int64("guild_id", int64(*guildMemberAddPayload.GuildID)).Msg("Failed to track invite use")
+		}
+
+		if inviteUse != nil {
+			extras["invite_code"] = inviteUse.Code
+			extras["inviter"] = inviteUse.Inviter
+		}
+	}
+
+	extra, err := makeExtra(extras)
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
 	return EventDispatch{
-		Data: msg.Data,
+		Data:  msg.Data,
+		Extra: extra,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
 			GuildID: guildMemberAddPayload.GuildID,
 		},
@@ -709,12 +1028,10 @@ func OnGuildMemberRemove(ctx StateCtx, msg discord.GatewayPayload, trace sandwic
 	if !ctx.Sandwich.CheckAndAddDedupe(ddRemoveKey) {
 		ctx.Sandwich.RemoveDedupe(ddAddKey)
 
-		guild, ok := ctx.Sandwich.State.Guilds.Load(guildMemberRemovePayload.GuildID)
-
-		if ok {
+		ctx.Sandwich.State.UpdateGuild(guildMemberRemovePayload.GuildID, func(guild discord.Guild) discord.Guild {
 			guild.MemberCount--
-			ctx.Sandwich.State.Guilds.SetIfPresent(guildMemberRemovePayload.GuildID, guild)
-		}
+			return guild
+		})
 	} else {
 		ctx.Logger.Info().
 			Int64("guild_id", int64(guildMemberRemovePayload.GuildID)).
@@ -731,9 +1048,15 @@ func OnGuildMemberRemove(ctx StateCtx, msg discord.GatewayPayload, trace sandwic
 	ctx.Sandwich.State.RemoveGuildMember(guildMemberRemovePayload.GuildID, guildMemberRemovePayload.User.ID)
 	ctx.Sandwich.State.RemoveUserMutualGuild(guildMemberRemovePayload.User.ID, guildMemberRemovePayload.GuildID)
 
-	extra, err := makeExtra(map[string]interface{}{
-		"before": guildMember,
-	})
+	extras := ctx.Manager.OnGuildMemberLeave(guildMemberRemovePayload.GuildID)
+	extras["before"] = guildMember
+
+	if auditEntry := ctx.Manager.CorrelateAuditEvent(
+		msg.Type, guildMemberRemovePayload.GuildID, discord.AuditLogActionMemberKick, discord.Snowflake(guildMemberRemovePayload.User.ID)); auditEntry != nil {
+		extras["audit_entry"] = auditEntry
+	}
+
+	extra, err := makeExtra(extras)
 	if err != nil {
 		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
 	}
@@ -761,6 +1084,7 @@ func OnGuildMemberUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwic
 		*guildMemberUpdatePayload.GuildID, guildMemberUpdatePayload.User.ID)
 
 	ctx.Sandwich.State.SetGuildMember(ctx, *guildMemberUpdatePayload.GuildID, discord.GuildMember(guildMemberUpdatePayload))
+	ctx.Sandwich.State.TouchGuildMember(*guildMemberUpdatePayload.GuildID, guildMemberUpdatePayload.User.ID)
 
 	extra, err := makeExtra(map[string]interface{}{
 		"before": beforeGuildMember,
@@ -788,7 +1112,7 @@ func OnGuildRoleCreate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_
 
 	defer ctx.OnGuildDispatchEvent(msg.Type, guildRoleCreatePayload.GuildID)
 
-	ctx.Sandwich.State.SetGuildRole(guildRoleCreatePayload.GuildID, guildRoleCreatePayload.Role)
+	ctx.Sandwich.State.SetGuildRole(ctx, guildRoleCreatePayload.GuildID, guildRoleCreatePayload.Role)
 
 	return EventDispatch{
 		Data: msg.Data,
@@ -811,7 +1135,7 @@ func OnGuildRoleUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_
 	beforeRole, _ := ctx.Sandwich.State.GetGuildRole(
 		guildRoleUpdatePayload.GuildID, guildRoleUpdatePayload.Role.ID)
 
-	ctx.Sandwich.State.SetGuildRole(guildRoleUpdatePayload.GuildID, guildRoleUpdatePayload.Role)
+	ctx.Sandwich.State.SetGuildRole(ctx, guildRoleUpdatePayload.GuildID, guildRoleUpdatePayload.Role)
 
 	extra, err := makeExtra(map[string]interface{}{
 		"before": beforeRole,
@@ -841,8 +1165,15 @@ func OnGuildRoleDelete(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_
 
 	ctx.Sandwich.State.RemoveGuildRole(guildRoleDeletePayload.GuildID, guildRoleDeletePayload.RoleID)
 
+	extra, err := makeAuditExtra(ctx.Manager.CorrelateAuditEvent(
+		msg.Type, guildRoleDeletePayload.GuildID, discord.AuditLogActionRoleDelete, discord.Snowflake(guildRoleDeletePayload.RoleID)))
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
 	return EventDispatch{
-		Data: msg.Data,
+		Data:  msg.Data,
+		Extra: extra,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
 			GuildID: &guildRoleDeletePayload.GuildID,
 		},
@@ -859,6 +1190,10 @@ func OnInviteCreate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_str
 
 	if inviteCreatePayload.GuildID != nil {
 		defer ctx.SafeOnGuildDispatchEvent(msg.Type, inviteCreatePayload.GuildID)
+
+		if ctx.TrackInvites {
+			ctx.Sandwich.State.SetGuildInvite(*inviteCreatePayload.GuildID, discord.Invite(inviteCreatePayload))
+		}
 	}
 
 	return EventDispatch{
@@ -879,6 +1214,10 @@ func OnInviteDelete(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_str
 
 	if inviteDeletePayload.GuildID != nil {
 		defer ctx.SafeOnGuildDispatchEvent(msg.Type, inviteDeletePayload.GuildID)
+
+		if ctx.TrackInvites {
+			ctx.Sandwich.State.RemoveGuildInvite(*inviteDeletePayload.GuildID, inviteDeletePayload.Code)
+		}
 	}
 
 	return EventDispatch{
@@ -899,6 +1238,24 @@ func OnMessageCreate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_st
 
 	defer ctx.SafeOnGuildDispatchEvent(msg.Type, messageCreatePayload.GuildID)
 
+	if messageCreatePayload.GuildID != nil && ctx.Sandwich.State.TrackMemberActivity.Load() {
+		guildID := *messageCreatePayload.GuildID
+
+		// Members may have been evicted, so cache them again from the message.
+		if messageCreatePayload.Member != nil {
+			if _, ok := ctx.Sandwich.State.Backend.GetGuildMember(guildID, messageCreatePayload.Author.ID); !ok {
+				guildMember := *messageCreatePayload.Member
+				guildMember.User = &messageCreatePayload.Author
+
+				ctx.Sandwich.State.SetGuildMember(ctx, guildID, guildMember)
+			}
+		}
+
+		ctx.Sandwich.State.TouchGuildMember(guildID, messageCreatePayload.Author.ID)
+	}
+
+	ctx.Sandwich.State.AddMessage(discord.Message(messageCreatePayload))
+
 	// If no guild id, we know its a dm event anyways
 	return EventDispatch{
 		Data: msg.Data,
@@ -919,9 +1276,28 @@ func OnMessageUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_st
 
 	defer ctx.SafeOnGuildDispatchEvent(msg.Type, messageUpdatePayload.GuildID)
 
+	var beforeMessage *discord.Message
+
+	// Partial updates without an author are not cached as they would replace the full message.
+	if messageUpdatePayload.Author.ID != 0 {
+		if beforeMessageV, ok := ctx.Sandwich.State.UpdateMessage(discord.Message(messageUpdatePayload)); ok {
+			beforeMessage = &beforeMessageV
+		}
+	} else if beforeMessageV, ok := ctx.Sandwich.State.GetMessage(messageUpdatePayload.ChannelID, messageUpdatePayload.ID); ok {
+		beforeMessage = &beforeMessageV
+	}
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before": beforeMessage,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
 	// If no guild id, we know its a dm event anyways
 	return EventDispatch{
-		Data: msg.Data,
+		Data:  msg.Data,
+		Extra: extra,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
 			GuildID: messageUpdatePayload.GuildID,
 			UserID:  &messageUpdatePayload.Author.ID,
@@ -939,9 +1315,23 @@ func OnMessageDelete(ctx StateCtx, msg discord.GatewayPayload, trace sandwich_st
 
 	defer ctx.SafeOnGuildDispatchEvent(msg.Type, messageDeletePayload.GuildID)
 
+	var beforeMessage *discord.Message
+
+	if beforeMessageV, ok := ctx.Sandwich.State.RemoveMessage(messageDeletePayload.ChannelID, messageDeletePayload.ID); ok {
+		beforeMessage = &beforeMessageV
+	}
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before": beforeMessage,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
 	// If no guild id, we know its a dm event anyways
 	return EventDispatch{
-		Data: msg.Data,
+		Data:  msg.Data,
+		Extra: extra,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
 			GuildID: messageDeletePayload.GuildID,
 		},
@@ -958,9 +1348,25 @@ func OnMessageDeleteBulk(ctx StateCtx, msg discord.GatewayPayload, trace sandwic
 
 	defer ctx.SafeOnGuildDispatchEvent(msg.Type, messageDeleteBulkPayload.GuildID)
 
+	beforeMessages := make([]discord.Message, 0)
+
+	for _, messageID := range messageDeleteBulkPayload.IDs {
+		if beforeMessage, ok := ctx.Sandwich.State.RemoveMessage(messageDeleteBulkPayload.ChannelID, messageID); ok {
+			beforeMessages = append(beforeMessages, beforeMessage)
+		}
+	}
+
+	extra, err := makeExtra(map[string]interface{}{
+		"before": beforeMessages,
+	})
+	if err != nil {
+		return result, ok, fmt.Errorf("failed to marshal extras: %w", err)
+	}
+
 	// If no guild id, we know its a dm event anyways
 	return EventDispatch{
-		Data: msg.Data,
+		Data:  msg.Data,
+		Extra: extra,
 		EventDispatchIdentifier: &sandwich_structs.EventDispatchIdentifier{
 			GuildID: messageDeleteBulkPayload.GuildID,
 		},
@@ -1126,6 +1532,7 @@ func OnVoiceStateUpdate(ctx StateCtx, msg discord.GatewayPayload, trace sandwich
 		ctx.Sandwich.State.RemoveVoiceState(ctx, guildID, voiceStateUpdatePayload.UserID)
 	} else {
 		ctx.Sandwich.State.UpdateVoiceState(ctx, discord.VoiceState(voiceStateUpdatePayload))
+		ctx.Sandwich.State.TouchGuildMember(guildID, voiceStateUpdatePayload.UserID)
 	}
 
 	extra, err := makeExtra(map[string]interface{}{
@@ -1233,7 +1640,12 @@ func init() {
 	registerDispatch(discord.DiscordEventChannelUpdate, OnChannelUpdate)
 	registerDispatch(discord.DiscordEventChannelDelete, OnChannelDelete)
 	registerDispatch(discord.DiscordEventChannelPinsUpdate, OnChannelPinsUpdate)
+	registerDispatch(discord.DiscordEventThreadCreate, OnThreadCreate)
 	registerDispatch(discord.DiscordEventThreadUpdate, OnThreadUpdate)
+	registerDispatch(discord.DiscordEventThreadDelete, OnThreadDelete)
+	registerDispatch(discord.DiscordEventThreadListSync, OnThreadListSync)
+	registerDispatch(discord.DiscordEventThreadMemberUpdate, OnThreadMemberUpdate)
+	registerDispatch(discord.DiscordEventThreadMembersUpdate, OnThreadMembersUpdate)
 	registerDispatch(discord.DiscordEventGuildCreate, OnGuildCreate)
 	registerDispatch(discord.DiscordEventGuildAuditLogEntryCreate, OnGuildAuditLogEntryCreate)
 	registerDispatch(discord.DiscordEventGuildUpdate, OnGuildUpdate)
@@ -9,7 +9,6 @@ import (
 
 	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
 	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
-	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
 	"nhooyr.io/websocket"
 )
 
@@ -78,7 +77,7 @@ func gatewayOpInvalidSession(ctx context.Context, sh *Shard, msg discord.Gateway
 
 	sh.Logger.Warn().Str("data", string(msg.Data)).Msg("Received invalid session")
 
-	err := sandwichjson.Unmarshal(msg.Data, &resumable)
+	err := sh.decodeContent(msg, &resumable)
 	if err != nil {
 		sh.Logger.Error().Err(err).Msg("Failed to unmarshal invalid session")
 		return err
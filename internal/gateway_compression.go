@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type GatewayCompression string
+
+const (
+	// Payloads are compressed individually when requested in identify.
+	GatewayCompressionPayload GatewayCompression = ""
+	// The connection is a single zlib stream, flushed at the end of every message.
+	GatewayCompressionZlibStream GatewayCompression = "zlib-stream"
+	// The connection is a single zstd stream, flushed at the end of every message.
+	GatewayCompressionZstdStream GatewayCompression = "zstd-stream"
+)
+
+// Suffix of a zlib sync flush, marking the end of a zlib-stream message.
+var zlibStreamSuffix = []byte{0x00, 0x00, 0xff, 0xff}
+
+// Size of the buffer decompressed data is read into.
+const streamDecompressorBufferSize = 32 * 1024
+
+var errStreamDecompressorClosed = errors.New("stream decompressor is closed")
+
+// IsValid returns if the compression is supported.
+func (gc GatewayCompression) IsValid() bool {
+	switch gc {
+	case GatewayCompressionPayload, GatewayCompressionZlibStream, GatewayCompressionZstdStream:
+		return true
+	default:
+		return false
+	}
+}
+
+// streamDecompressor decompresses a transport compressed gateway connection. Discord flushes the
+// stream at the end of every message, so each message can be decompressed as it is received while
+// the compression context is shared across the connection.
+//
+// The decompression reader runs in its own goroutine, reading compressed messages from the
+// decompressor. Once it asks for more input than has been received, all output of the current
+// message has been produced.
+type streamDecompressor struct {
+	compression GatewayCompression
+
+	input   chan []byte
+	drained chan void
+	done    chan void
+	closed  chan void
+
+	// Remainder of the current compressed message. Only used by the decompression reader.
+	pending []byte
+	started bool
+
+	// Decompressed output of the current message. Only written by the decompression reader
+	// between receiving input and signalling drained.
+	output bytes.Buffer
+
+	err error
+
+	// Compressed message waiting for the rest of its frames.
+	partial []byte
+}
+
+func newStreamDecompressor(compression GatewayCompression) (*streamDecompressor, error) {
+	var newReader func(r io.Reader) (io.ReadCloser, error)
+
+	switch compression {
+	case GatewayCompressionZlibStream:
+		newReader = zlib.NewReader
+	case GatewayCompressionZstdStream:
+		newReader = func(r io.Reader) (io.ReadCloser, error) {
+			// A single goroutine decodes blocks synchronously, so input is only read once all
+			// output of the previous block has been returned.
+			decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+
+			return decoder.IOReadCloser(), nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGatewayCompression, compression)
+	}
+
+	sd := &streamDecompressor{
+		compression: compression,
+		input:       make(chan []byte),
+		drained:     make(chan void),
+		done:        make(chan void),
+		closed:      make(chan void),
+	}
+
+	go sd.run(newReader)
+
+	return sd, nil
+}
+
+func (sd *streamDecompressor) run(newReader func(r io.Reader) (io.ReadCloser, error)) {
+	defer close(sd.done)
+
+	reader, err := newReader(sd)
+	if err != nil {
+		sd.err = fmt.Errorf("failed to create decompressor: %w", err)
+
+		return
+	}
+
+	defer reader.Close()
+
+	buf := make([]byte, streamDecompressorBufferSize)
+
+	for {
+		n, err := reader.Read(buf)
+		sd.output.Write(buf[:n])
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			sd.err = err
+
+			return
+		}
+	}
+}
+
+// Read passes compressed messages to the decompression reader, blocking until the next message
+// once the current one has been read.
+func (sd *streamDecompressor) Read(p []byte) (int, error) {
+	for len(sd.pending) == 0 {
+		if sd.started {
+			select {
+			case sd.drained <- void{}:
+			case <-sd.closed:
+				return 0, errStreamDecompressorClosed
+			}
+		}
+
+		sd.started = true
+
+		select {
+		case sd.pending = <-sd.input:
+		case <-sd.closed:
+			return 0, errStreamDecompressorClosed
+		}
+	}
+
+	n := copy(p, sd.pending)
+	sd.pending = sd.pending[n:]
+
+	return n, nil
+}
+
+// ReadByte allows the decompression reader to read without buffering past the current message.
+func (sd *streamDecompressor) ReadByte() (byte, error) {
+	var b [1]byte
+
+	_, err := sd.Read(b[:])
+
+	return b[0], err
+}
+
+// Decompress decompresses a message. Returns false if the message is incomplete and the
+// following message should be passed as well.
+func (sd *streamDecompressor) Decompress(data []byte) (decompressed []byte, complete bool, err error) {
+	if sd.compression == GatewayCompressionZlibStream {
+		if len(sd.partial) > 0 {
+			data = append(sd.partial, data...)
+		}
+
+		if !bytes.HasSuffix(data, zlibStreamSuffix) {
+			sd.partial = data
+
+			return nil, false, nil
+		}
+
+		sd.partial = nil
+	}
+
+	if len(data) == 0 {
+		return nil, true, nil
+	}
+
+	select {
+	case sd.input <- data:
+	case <-sd.done:
+		return nil, false, sd.err
+	}
+
+	select {
+	case <-sd.drained:
+	case <-sd.done:
+		return nil, false, sd.err
+	}
+
+	decompressed = bytes.Clone(sd.output.Bytes())
+	sd.output.Reset()
+
+	return decompressed, true, nil
+}
+
+// Close stops the decompression reader.
+func (sd *streamDecompressor) Close() {
+	select {
+	case <-sd.closed:
+	default:
+		close(sd.closed)
+	}
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/atomic"
+	"nhooyr.io/websocket"
+)
+
+type flushWriter interface {
+	Write(p []byte) (int, error)
+	Flush() error
+}
+
+func testStreamDecompressor(t *testing.T, compression GatewayCompression, newWriter func(buf *bytes.Buffer) flushWriter) {
+	t.Helper()
+
+	decompressor, err := newStreamDecompressor(compression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decompressor.Close()
+
+	var buf bytes.Buffer
+
+	writer := newWriter(&buf)
+
+	messages := []string{
+		`{"op":10,"d":{"heartbeat_interval":41250}}`,
+		`{"op":0,"t":"GUILD_CREATE","d":{"id":"1","name":"sandwich"}}`,
+		`{"op":0,"t":"GUILD_CREATE","d":{"id":"2","name":"sandwich"}}`,
+	}
+
+	for i, message := range messages {
+		if _, err := writer.Write([]byte(message)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		data := bytes.Clone(buf.Bytes())
+		buf.Reset()
+
+		// The last message is split across frames.
+		if i == len(messages)-1 && compression == GatewayCompressionZlibStream {
+			if _, complete, err := decompressor.Decompress(data[:len(data)/2]); err != nil || complete {
+				t.Fatalf("expected partial message to be incomplete, got %v %v", complete, err)
+			}
+
+			data = data[len(data)/2:]
+		}
+
+		decompressed, complete, err := decompressor.Decompress(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !complete || string(decompressed) != message {
+			t.Fatalf("expected %s, got %s", message, decompressed)
+		}
+	}
+}
+
+func TestStreamDecompressorZlib(t *testing.T) {
+	testStreamDecompressor(t, GatewayCompressionZlibStream, func(buf *bytes.Buffer) flushWriter {
+		return zlib.NewWriter(buf)
+	})
+}
+
+func TestStreamDecompressorZstd(t *testing.T) {
+	testStreamDecompressor(t, GatewayCompressionZstdStream, func(buf *bytes.Buffer) flushWriter {
+		writer, err := zstd.NewWriter(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return writer
+	})
+}
+
+func TestShardReadMessageSplitFrames(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer := zlib.NewWriter(&buf)
+
+	frames := make([][]byte, 0)
+
+	// Each message is split across several frames.
+	for _, message := range []string{`{"op":11}`, `{"op":0,"s":1,"t":"GUILD_CREATE","d":{"id":"1"}}`} {
+		if _, err := writer.Write([]byte(message)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		data := bytes.Clone(buf.Bytes())
+		buf.Reset()
+
+		for i := 0; i < len(data); i += 5 {
+			frames = append(frames, data[i:min(i+5, len(data))])
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+
+		for _, frame := range frames {
+			if err := conn.Write(r.Context(), websocket.MessageBinary, frame); err != nil {
+				return
+			}
+		}
+
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+
+	decompressor, err := newStreamDecompressor(GatewayCompressionZlibStream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decompressor.Close()
+
+	shard := &Shard{
+		ctx:          ctx,
+		Sandwich:     &Sandwich{},
+		Manager:      &Manager{Identifier: atomic.NewString("sandwich")},
+		wsConn:       conn,
+		decompressor: decompressor,
+		encoding:     GatewayEncodingJSON,
+	}
+
+	for _, expected := range []discord.GatewayOp{discord.GatewayOpHeartbeatACK, discord.GatewayOpDispatch} {
+		payload, err := shard.readMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if payload.Op != expected {
+			t.Errorf("expected op %d, got %d", expected, payload.Op)
+		}
+	}
+}
@@ -0,0 +1,884 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+type GatewayEncoding string
+
+const (
+	GatewayEncodingJSON GatewayEncoding = "json"
+	// Erlang External Term Format. Snowflakes are sent as integers rather than strings.
+	GatewayEncodingETF GatewayEncoding = "etf"
+)
+
+// IsValid returns if the encoding is supported. An empty encoding uses JSON.
+func (ge GatewayEncoding) IsValid() bool {
+	switch ge {
+	case "", GatewayEncodingJSON, GatewayEncodingETF:
+		return true
+	default:
+		return false
+	}
+}
+
+// External term format tags.
+const (
+	etfVersion = 131
+
+	etfNewFloat      = 70
+	etfSmallInteger  = 97
+	etfInteger       = 98
+	etfFloat         = 99
+	etfAtom          = 100
+	etfSmallTuple    = 104
+	etfLargeTuple    = 105
+	etfNil           = 106
+	etfString        = 107
+	etfList          = 108
+	etfBinary        = 109
+	etfSmallBig      = 110
+	etfLargeBig      = 111
+	etfSmallAtom     = 115
+	etfMap           = 116
+	etfAtomUTF8      = 118
+	etfSmallAtomUTF8 = 119
+)
+
+var errETFUnexpectedEnd = errors.New("unexpected end of term")
+
+// isETF returns if the data is an encoded term.
+func isETF(data []byte) bool {
+	return len(data) > 0 && data[0] == etfVersion
+}
+
+// etfDecoder decodes encoded terms, either into values or transcoded to JSON.
+type etfDecoder struct {
+	data []byte
+	pos  int
+}
+
+// DecodeETFGatewayPayload decodes an ETF gateway payload. The event data is kept as an encoded term, so
+// it is decoded straight into the dispatch structs by decodeContent and only transcoded to JSON if it
+// is published.
+func DecodeETFGatewayPayload(data []byte) (payload discord.GatewayPayload, err error) {
+	d := &etfDecoder{data: data}
+
+	err = d.readVersion()
+	if err != nil {
+		return payload, err
+	}
+
+	tag, err := d.readByte()
+	if err != nil {
+		return payload, err
+	}
+
+	if tag != etfMap {
+		return payload, fmt.Errorf("%w: expected map, got tag %d", ErrInvalidETF, tag)
+	}
+
+	arity, err := d.readUint32()
+	if err != nil {
+		return payload, err
+	}
+
+	var key bytes.Buffer
+
+	for i := range arity {
+		key.Reset()
+
+		err = d.readKey(&key)
+		if err != nil {
+			return payload, err
+		}
+
+		switch key.String() {
+		case "op":
+			err = d.decodeValue(reflect.ValueOf(&payload.Op).Elem())
+		case "s":
+			err = d.decodeValue(reflect.ValueOf(&payload.Sequence).Elem())
+		case "t":
+			err = d.decodeValue(reflect.ValueOf(&payload.Type).Elem())
+		case "d":
+			start := d.pos
+
+			// The end of the data only needs to be found if other keys follow it.
+			if i < arity-1 {
+				err = d.skipTerm()
+			} else {
+				d.pos = len(d.data)
+			}
+
+			if err == nil {
+				payload.Data = append([]byte{etfVersion}, d.data[start:d.pos]...)
+			}
+		default:
+			err = d.skipTerm()
+		}
+
+		if err != nil {
+			return payload, fmt.Errorf("%w: invalid %s: %w", ErrInvalidETF, key.String(), err)
+		}
+	}
+
+	return payload, nil
+}
+
+// DecodeETF transcodes an encoded term to JSON.
+func DecodeETF(data []byte) ([]byte, error) {
+	d := &etfDecoder{data: data}
+
+	err := d.readVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	err = d.readTerm(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *etfDecoder) readVersion() error {
+	version, err := d.readByte()
+	if err != nil {
+		return err
+	}
+
+	if version != etfVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidETF, version)
+	}
+
+	return nil
+}
+
+func (d *etfDecoder) readByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidETF, errETFUnexpectedEnd)
+	}
+
+	b := d.data[d.pos]
+	d.pos++
+
+	return b, nil
+}
+
+func (d *etfDecoder) readBytes(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidETF, errETFUnexpectedEnd)
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+func (d *etfDecoder) readUint16() (uint16, error) {
+	b, err := d.readBytes(2)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (d *etfDecoder) readUint32() (uint32, error) {
+	b, err := d.readBytes(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// readKey writes a map key as a plain string. JSON objects only allow string keys, so keys that are
+// not atoms or binaries use their JSON representation.
+func (d *etfDecoder) readKey(buf *bytes.Buffer) error {
+	if key, ok := d.readPlainKey(); ok {
+		buf.Write(key)
+
+		return nil
+	}
+
+	var key bytes.Buffer
+
+	err := d.readTerm(&key)
+	if err != nil {
+		return err
+	}
+
+	if key.Len() > 0 && key.Bytes()[0] == '"' {
+		var s string
+
+		err = json.Unmarshal(key.Bytes(), &s)
+		if err != nil {
+			return fmt.Errorf("%w: invalid map key: %w", ErrInvalidETF, err)
+		}
+
+		buf.WriteString(s)
+	} else {
+		buf.Write(key.Bytes())
+	}
+
+	return nil
+}
+
+// readPlainKey reads a binary or UTF-8 atom key without copying it, which covers every key Discord
+// sends. Returns false without reading anything if the key needs to be transcoded.
+func (d *etfDecoder) readPlainKey() (key []byte, ok bool) {
+	pos := d.pos
+
+	tag, err := d.readByte()
+	if err != nil {
+		return nil, false
+	}
+
+	var length int
+
+	switch tag {
+	case etfBinary:
+		n, err := d.readUint32()
+		if err != nil {
+			d.pos = pos
+
+			return nil, false
+		}
+
+		length = int(n)
+	case etfSmallAtomUTF8:
+		n, err := d.readByte()
+		if err != nil {
+			d.pos = pos
+
+			return nil, false
+		}
+
+		length = int(n)
+	default:
+		d.pos = pos
+
+		return nil, false
+	}
+
+	key, err = d.readBytes(length)
+	if err != nil || !utf8.Valid(key) || isETFSpecialAtom(tag, key) {
+		d.pos = pos
+
+		return nil, false
+	}
+
+	return key, true
+}
+
+// isETFSpecialAtom returns if an atom maps to a JSON literal rather than a string.
+func isETFSpecialAtom(tag byte, b []byte) bool {
+	if tag == etfBinary {
+		return false
+	}
+
+	switch string(b) {
+	case "nil", "null", "true", "false":
+		return true
+	default:
+		return false
+	}
+}
+
+// skipTerm reads the next term without decoding it.
+func (d *etfDecoder) skipTerm() error {
+	tag, err := d.readByte()
+	if err != nil {
+		return err
+	}
+
+	var length int
+
+	switch tag {
+	case etfSmallInteger:
+		length = 1
+	case etfInteger:
+		length = 4
+	case etfNewFloat:
+		length = 8
+	case etfFloat:
+		length = 31
+	case etfNil:
+	case etfSmallAtom, etfSmallAtomUTF8:
+		n, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		length = int(n)
+	case etfAtom, etfAtomUTF8, etfString:
+		n, err := d.readUint16()
+		if err != nil {
+			return err
+		}
+
+		length = int(n)
+	case etfBinary:
+		n, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		length = int(n)
+	case etfSmallBig:
+		n, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		length = int(n) + 1
+	case etfLargeBig:
+		n, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		length = int(n) + 1
+	case etfSmallTuple:
+		n, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		return d.skipTerms(int(n))
+	case etfLargeTuple, etfMap:
+		n, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		if tag == etfMap {
+			return d.skipTerms(int(n) * 2)
+		}
+
+		return d.skipTerms(int(n))
+	case etfList:
+		n, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		// Lists are followed by their tail.
+		return d.skipTerms(int(n) + 1)
+	default:
+		return fmt.Errorf("%w: unsupported tag %d", ErrInvalidETF, tag)
+	}
+
+	_, err = d.readBytes(length)
+
+	return err
+}
+
+func (d *etfDecoder) skipTerms(n int) error {
+	for range n {
+		err := d.skipTerm()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readTerm transcodes the next term to JSON.
+func (d *etfDecoder) readTerm(buf *bytes.Buffer) error {
+	tag, err := d.readByte()
+	if err != nil {
+		return err
+	}
+
+	switch tag {
+	case etfSmallInteger:
+		b, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(strconv.Itoa(int(b)))
+	case etfInteger:
+		i, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(strconv.Itoa(int(int32(i))))
+	case etfNewFloat:
+		b, err := d.readBytes(8)
+		if err != nil {
+			return err
+		}
+
+		return writeJSONFloat(buf, math.Float64frombits(binary.BigEndian.Uint64(b)))
+	case etfFloat:
+		b, err := d.readBytes(31)
+		if err != nil {
+			return err
+		}
+
+		f, err := strconv.ParseFloat(string(bytes.TrimRight(b, "\x00")), 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid float: %w", ErrInvalidETF, err)
+		}
+
+		return writeJSONFloat(buf, f)
+	case etfAtom, etfSmallAtom, etfAtomUTF8, etfSmallAtomUTF8:
+		return d.readAtom(buf, tag)
+	case etfSmallTuple:
+		arity, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		return d.readArray(buf, int(arity))
+	case etfLargeTuple:
+		arity, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		return d.readArray(buf, int(arity))
+	case etfNil:
+		buf.WriteString("[]")
+	case etfString:
+		// Lists of small integers are sent as strings.
+		length, err := d.readUint16()
+		if err != nil {
+			return err
+		}
+
+		b, err := d.readBytes(int(length))
+		if err != nil {
+			return err
+		}
+
+		buf.WriteByte('[')
+
+		for i, c := range b {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			buf.WriteString(strconv.Itoa(int(c)))
+		}
+
+		buf.WriteByte(']')
+	case etfList:
+		length, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		err = d.readArray(buf, int(length))
+		if err != nil {
+			return err
+		}
+
+		// Proper lists end with an empty list.
+		tail, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		if tail != etfNil {
+			return fmt.Errorf("%w: improper lists are not supported", ErrInvalidETF)
+		}
+	case etfBinary:
+		length, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		b, err := d.readBytes(int(length))
+		if err != nil {
+			return err
+		}
+
+		writeJSONString(buf, b)
+	case etfSmallBig:
+		n, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		return d.readBig(buf, int(n))
+	case etfLargeBig:
+		n, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		return d.readBig(buf, int(n))
+	case etfMap:
+		arity, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		var key bytes.Buffer
+
+		buf.WriteByte('{')
+
+		for i := range int(arity) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			key.Reset()
+
+			err = d.readKey(&key)
+			if err != nil {
+				return err
+			}
+
+			writeJSONString(buf, key.Bytes())
+			buf.WriteByte(':')
+
+			err = d.readTerm(buf)
+			if err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("%w: unsupported tag %d", ErrInvalidETF, tag)
+	}
+
+	return nil
+}
+
+func (d *etfDecoder) readArray(buf *bytes.Buffer, length int) error {
+	buf.WriteByte('[')
+
+	for i := range length {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		err := d.readTerm(buf)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf.WriteByte(']')
+
+	return nil
+}
+
+// readAtom writes an atom. The nil, true and false atoms map to their JSON values and any other atom
+// is written as a string.
+func (d *etfDecoder) readAtom(buf *bytes.Buffer, tag byte) error {
+	var length int
+
+	if tag == etfSmallAtom || tag == etfSmallAtomUTF8 {
+		n, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		length = int(n)
+	} else {
+		n, err := d.readUint16()
+		if err != nil {
+			return err
+		}
+
+		length = int(n)
+	}
+
+	b, err := d.readBytes(length)
+	if err != nil {
+		return err
+	}
+
+	switch string(b) {
+	case "nil", "null":
+		buf.WriteString("null")
+	case "true":
+		buf.WriteString("true")
+	case "false":
+		buf.WriteString("false")
+	default:
+		if tag == etfAtom || tag == etfSmallAtom {
+			// Latin-1 atoms.
+			s := make([]byte, 0, len(b))
+			for _, c := range b {
+				s = utf8.AppendRune(s, rune(c))
+			}
+
+			b = s
+		}
+
+		writeJSONString(buf, b)
+	}
+
+	return nil
+}
+
+// readBig writes a big integer. Integers that cannot be represented exactly as a float, which includes
+// every snowflake, are written as strings so they keep their precision like they do in JSON.
+func (d *etfDecoder) readBig(buf *bytes.Buffer, n int) error {
+	sign, err := d.readByte()
+	if err != nil {
+		return err
+	}
+
+	digits, err := d.readBytes(n)
+	if err != nil {
+		return err
+	}
+
+	if n <= 8 {
+		var value uint64
+
+		for i := n - 1; i >= 0; i-- {
+			value = value<<8 | uint64(digits[i])
+		}
+
+		quoted := value > discord.MaxInt64
+		if quoted {
+			buf.WriteByte('"')
+		}
+
+		if sign != 0 && value != 0 {
+			buf.WriteByte('-')
+		}
+
+		buf.WriteString(strconv.FormatUint(value, 10))
+
+		if quoted {
+			buf.WriteByte('"')
+		}
+	} else {
+		// Digits are little endian.
+		be := slices.Clone(digits)
+		slices.Reverse(be)
+
+		value := new(big.Int).SetBytes(be)
+		if sign != 0 {
+			value.Neg(value)
+		}
+
+		buf.WriteByte('"')
+		buf.WriteString(value.String())
+		buf.WriteByte('"')
+	}
+
+	return nil
+}
+
+func writeJSONFloat(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("%w: unsupported float %v", ErrInvalidETF, f)
+	}
+
+	buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes a quoted JSON string. Invalid UTF-8 is replaced with U+FFFD.
+func writeJSONString(buf *bytes.Buffer, s []byte) {
+	buf.WriteByte('"')
+
+	start := 0
+
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+
+				continue
+			}
+
+			buf.Write(s[start:i])
+
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xf])
+			}
+
+			i++
+			start = i
+
+			continue
+		}
+
+		r, size := utf8.DecodeRune(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.Write(s[start:i])
+			buf.WriteString("\ufffd")
+
+			i += size
+			start = i
+
+			continue
+		}
+
+		i += size
+	}
+
+	buf.Write(s[start:])
+	buf.WriteByte('"')
+}
+
+// EncodeETF transcodes JSON to an encoded term. Objects are encoded as maps with binary keys,
+// strings as binaries and null as the nil atom.
+func EncodeETF(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	buf := []byte{etfVersion}
+
+	return appendETF(buf, value)
+}
+
+func appendETF(buf []byte, value interface{}) ([]byte, error) {
+	var err error
+
+	switch v := value.(type) {
+	case nil:
+		buf = appendETFAtom(buf, "nil")
+	case bool:
+		buf = appendETFAtom(buf, strconv.FormatBool(v))
+	case json.Number:
+		return appendETFNumber(buf, v)
+	case string:
+		buf = appendETFBinary(buf, v)
+	case []interface{}:
+		if len(v) == 0 {
+			return append(buf, etfNil), nil
+		}
+
+		buf = append(buf, etfList)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(v)))
+
+		for _, item := range v {
+			buf, err = appendETF(buf, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		buf = append(buf, etfNil)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		buf = append(buf, etfMap)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(v)))
+
+		for _, key := range keys {
+			buf = appendETFBinary(buf, key)
+
+			buf, err = appendETF(buf, v[key])
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %T", ErrInvalidETF, value)
+	}
+
+	return buf, nil
+}
+
+func appendETFAtom(buf []byte, atom string) []byte {
+	buf = append(buf, etfSmallAtomUTF8, byte(len(atom)))
+
+	return append(buf, atom...)
+}
+
+func appendETFBinary(buf []byte, s string) []byte {
+	buf = append(buf, etfBinary)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(s)))
+
+	return append(buf, s...)
+}
+
+func appendETFNumber(buf []byte, n json.Number) ([]byte, error) {
+	i, err := n.Int64()
+	if err != nil {
+		f, err := n.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %s", ErrInvalidETF, n)
+		}
+
+		buf = append(buf, etfNewFloat)
+
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(f)), nil
+	}
+
+	switch {
+	case i >= 0 && i <= math.MaxUint8:
+		return append(buf, etfSmallInteger, byte(i)), nil
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf = append(buf, etfInteger)
+
+		return binary.BigEndian.AppendUint32(buf, uint32(int32(i))), nil
+	}
+
+	var sign byte
+
+	u := uint64(i)
+	if i < 0 {
+		sign = 1
+		u = uint64(-i)
+	}
+
+	var digits []byte
+	for ; u > 0; u >>= 8 {
+		digits = append(digits, byte(u))
+	}
+
+	buf = append(buf, etfSmallBig, byte(len(digits)), sign)
+
+	return append(buf, digits...), nil
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+func TestDecodeETFGatewayPayload(t *testing.T) {
+	// {op: 0, s: 42, t: "GUILD_CREATE", d: %{"id" => 830107410349211648, "name" => "sandwich", "unavailable" => false}}
+	data := []byte{
+		etfVersion, etfMap, 0, 0, 0, 4,
+		etfSmallAtomUTF8, 2, 'o', 'p', etfSmallInteger, 0,
+		etfSmallAtomUTF8, 1, 's', etfSmallInteger, 42,
+		etfSmallAtomUTF8, 1, 't', etfSmallAtomUTF8, 12, 'G', 'U', 'I', 'L', 'D', '_', 'C', 'R', 'E', 'A', 'T', 'E',
+		etfSmallAtomUTF8, 1, 'd', etfMap, 0, 0, 0, 3,
+		etfBinary, 0, 0, 0, 2, 'i', 'd', etfSmallBig, 8, 0, 0x00, 0xc0, 0x48, 0xa7, 0x4a, 0x22, 0x85, 0x0b,
+		etfBinary, 0, 0, 0, 4, 'n', 'a', 'm', 'e', etfBinary, 0, 0, 0, 8, 's', 'a', 'n', 'd', 'w', 'i', 'c', 'h',
+		etfBinary, 0, 0, 0, 11, 'u', 'n', 'a', 'v', 'a', 'i', 'l', 'a', 'b', 'l', 'e', etfSmallAtomUTF8, 5, 'f', 'a', 'l', 's', 'e',
+	}
+
+	payload, err := DecodeETFGatewayPayload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Op != discord.GatewayOpDispatch || payload.Sequence != 42 || payload.Type != "GUILD_CREATE" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+
+	var guild discord.Guild
+
+	err = UnmarshalETF(payload.Data, &guild)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if guild.ID != 830107410349211648 || guild.Name != "sandwich" || guild.Unavailable {
+		t.Fatalf("unexpected guild %+v", guild)
+	}
+
+	// Event data is transcoded to JSON when it is published.
+	transcoded, err := DecodeETF(payload.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guild = discord.Guild{}
+
+	err = json.Unmarshal(transcoded, &guild)
+	if err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", transcoded, err)
+	}
+
+	if guild.ID != 830107410349211648 || guild.Name != "sandwich" {
+		t.Fatalf("unexpected guild %d %s from %s", guild.ID, guild.Name, transcoded)
+	}
+}
+
+func TestETFRoundTrip(t *testing.T) {
+	message := `{"d":{"float":1.5,"id":"830107410349211648","large":830107410349211648,"list":[1,-300,"a\"b\n"],"null":null,"ok":true,"empty":[]},"op":2}`
+
+	encoded, err := EncodeETF([]byte(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeETF(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys are sorted when encoding and integers that cannot be represented exactly as a float decode
+	// as strings.
+	expected := `{"d":{"empty":[],"float":1.5,"id":"830107410349211648","large":"830107410349211648","list":[1,-300,"a\"b\n"],"null":null,"ok":true},"op":2}`
+
+	if string(decoded) != expected {
+		t.Fatalf("expected %s, got %s", expected, decoded)
+	}
+}
+
+func TestDecodeETFPresenceUpdate(t *testing.T) {
+	// Discord sends snowflakes and activity timestamps as integers when using ETF.
+	encoded, err := EncodeETF([]byte(`{"op":0,"s":7,"t":"PRESENCE_UPDATE","d":{` +
+		`"user":{"id":143090142360371200},"guild_id":830107410349211648,"status":"online","client_status":{"desktop":"online"},` +
+		`"activities":[{"name":"Sandwich","type":0,"created_at":1700000000000,"application_id":383226320970055681,"timestamps":{"start":1699999990000,"end":1700000090000}}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := DecodeETFGatewayPayload(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var presence discord.PresenceUpdate
+
+	err = UnmarshalETF(payload.Data, &presence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if presence.User.ID != 143090142360371200 || presence.GuildID != 830107410349211648 || len(presence.Activities) != 1 {
+		t.Fatalf("unexpected presence %+v", presence)
+	}
+
+	activity := presence.Activities[0]
+
+	if activity.CreatedAt == nil || *activity.CreatedAt != 1700000000000 ||
+		activity.ApplicationID == nil || *activity.ApplicationID != 383226320970055681 ||
+		activity.Timestamps == nil || activity.Timestamps.Start != 1699999990000 || activity.Timestamps.End != 1700000090000 {
+		t.Fatalf("unexpected activity %+v", activity)
+	}
+}
+
+func TestUnmarshalETFMatchesJSON(t *testing.T) {
+	// Snowflakes are integers, as Discord sends them when using ETF.
+	message := []byte(`{"id":830107410349211648,"name":"sandwich","owner_id":143090142360371200,"unknown":{"a":[1,2]},` +
+		`"icon":null,"permissions":"8","member_count":2,"large":false,"features":["COMMUNITY"],` +
+		`"roles":[{"id":830107410349211648,"name":"@everyone","permissions":"1071698660929","color":0,"tags":{"bot_id":143090142360371200}}],` +
+		`"channels":[{"id":830107410349211649,"type":0,"name":"general","permission_overwrites":[{"id":830107410349211648,"type":0,"allow":"0","deny":"2048"}]}],` +
+		`"members":[{"user":{"id":143090142360371200,"username":"sandwich","bot":true},"roles":[830107410349211648],"joined_at":"2021-04-11T00:00:00.000000+00:00","nick":null}],` +
+		`"emojis":[],"stickers":[]}`)
+
+	encoded, err := EncodeETF(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fromETF, fromJSON discord.Guild
+
+	err = UnmarshalETF(encoded, &fromETF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sandwichjson.Unmarshal(message, &fromJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromETF, fromJSON) {
+		t.Fatalf("expected %+v, got %+v", fromJSON, fromETF)
+	}
+}
@@ -0,0 +1,692 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+var (
+	etfRawMessageType  = reflect.TypeOf(json.RawMessage{})
+	etfUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+var (
+	// Fields of structs by their JSON name, keyed by struct type.
+	etfStructFieldsCache sync.Map
+
+	// If values of a type are decoded by transcoding to JSON, keyed by type.
+	etfDecodesAsJSONCache sync.Map
+)
+
+type etfStructFields struct {
+	byName map[string][]int
+
+	// Fields by their lowercase JSON name, as JSON matches keys case insensitively.
+	byFoldedName map[string][]int
+}
+
+// UnmarshalETF decodes an encoded term into a value the same way it would be unmarshalled from JSON.
+// Struct fields are matched by their json tags and integers are set directly, so snowflakes sent as
+// integers do not pass through JSON. Values of interfaces and of types implementing json.Unmarshaler,
+// other than integers, are transcoded to JSON and unmarshalled.
+func UnmarshalETF(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: expected non-nil pointer, got %T", ErrInvalidETF, v)
+	}
+
+	d := &etfDecoder{data: data}
+
+	err := d.readVersion()
+	if err != nil {
+		return err
+	}
+
+	return d.decodeValue(rv.Elem())
+}
+
+func (d *etfDecoder) peekByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidETF, errETFUnexpectedEnd)
+	}
+
+	return d.data[d.pos], nil
+}
+
+// peekAtom returns the next atom without reading it. Returns false if the next term is not an atom.
+func (d *etfDecoder) peekAtom() (atom []byte, ok bool) {
+	pos := d.pos
+
+	defer func() {
+		d.pos = pos
+	}()
+
+	tag, err := d.readByte()
+	if err != nil {
+		return nil, false
+	}
+
+	var length int
+
+	switch tag {
+	case etfSmallAtom, etfSmallAtomUTF8:
+		n, err := d.readByte()
+		if err != nil {
+			return nil, false
+		}
+
+		length = int(n)
+	case etfAtom, etfAtomUTF8:
+		n, err := d.readUint16()
+		if err != nil {
+			return nil, false
+		}
+
+		length = int(n)
+	default:
+		return nil, false
+	}
+
+	b, err := d.readBytes(length)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+// decodeValue decodes the next term into a value.
+func (d *etfDecoder) decodeValue(rv reflect.Value) error {
+	if atom, ok := d.peekAtom(); ok && (string(atom) == "nil" || string(atom) == "null") {
+		err := d.skipTerm()
+		if err != nil {
+			return err
+		}
+
+		// Like JSON, null only changes values that can be nil.
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			rv.SetZero()
+		}
+
+		return nil
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		return d.decodeValue(rv.Elem())
+	}
+
+	if d.decodesAsJSON(rv) {
+		return d.decodeJSON(rv)
+	}
+
+	tag, err := d.peekByte()
+	if err != nil {
+		return err
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := d.readInteger()
+		if err != nil {
+			return err
+		}
+
+		if rv.OverflowInt(i) {
+			return fmt.Errorf("%w: %d overflows %s", ErrInvalidETF, i, rv.Type())
+		}
+
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := d.readInteger()
+		if err != nil {
+			return err
+		}
+
+		if i < 0 || rv.OverflowUint(uint64(i)) {
+			return fmt.Errorf("%w: %d overflows %s", ErrInvalidETF, i, rv.Type())
+		}
+
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := d.readFloat()
+		if err != nil {
+			return err
+		}
+
+		rv.SetFloat(f)
+	case reflect.Bool:
+		atom, ok := d.peekAtom()
+		if !ok || (string(atom) != "true" && string(atom) != "false") {
+			return d.typeError(tag, rv)
+		}
+
+		rv.SetBool(string(atom) == "true")
+
+		return d.skipTerm()
+	case reflect.String:
+		s, err := d.readString()
+		if err != nil {
+			return err
+		}
+
+		rv.SetString(s)
+	case reflect.Slice:
+		return d.decodeSlice(rv, tag)
+	case reflect.Map:
+		return d.decodeMap(rv, tag)
+	case reflect.Struct:
+		return d.decodeStruct(rv, tag)
+	default:
+		return d.typeError(tag, rv)
+	}
+
+	return nil
+}
+
+// decodesAsJSON returns if a value is decoded by transcoding the term to JSON.
+func (d *etfDecoder) decodesAsJSON(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Interface, reflect.Array:
+		return true
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return true
+		}
+	case reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Integer types only implement json.Unmarshaler to accept quoted integers.
+		return false
+	}
+
+	t := rv.Type()
+
+	if decodesAsJSON, ok := etfDecodesAsJSONCache.Load(t); ok {
+		return decodesAsJSON.(bool)
+	}
+
+	decodesAsJSON := t == etfRawMessageType || reflect.PointerTo(t).Implements(etfUnmarshalerType)
+	etfDecodesAsJSONCache.Store(t, decodesAsJSON)
+
+	return decodesAsJSON
+}
+
+func (d *etfDecoder) decodeJSON(rv reflect.Value) error {
+	var buf bytes.Buffer
+
+	err := d.readTerm(&buf)
+	if err != nil {
+		return err
+	}
+
+	if rv.Type() == etfRawMessageType {
+		rv.SetBytes(buf.Bytes())
+
+		return nil
+	}
+
+	target := rv
+	if !rv.CanAddr() {
+		target = reflect.New(rv.Type()).Elem()
+	}
+
+	err = sandwichjson.Unmarshal(buf.Bytes(), target.Addr().Interface())
+	if err != nil {
+		return fmt.Errorf("%w: failed to unmarshal %s: %w", ErrInvalidETF, rv.Type(), err)
+	}
+
+	if target != rv {
+		rv.Set(target)
+	}
+
+	return nil
+}
+
+func (d *etfDecoder) decodeSlice(rv reflect.Value, tag byte) error {
+	var (
+		length int
+		proper bool
+	)
+
+	switch tag {
+	case etfNil:
+		d.pos++
+
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+
+		return nil
+	case etfList:
+		d.pos++
+
+		n, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		length, proper = int(n), true
+	case etfSmallTuple:
+		d.pos++
+
+		n, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		length = int(n)
+	case etfLargeTuple:
+		d.pos++
+
+		n, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+
+		length = int(n)
+	case etfString:
+		// Lists of small integers are sent as strings, which are rare enough to go through JSON.
+		return d.decodeJSON(rv)
+	default:
+		return d.typeError(tag, rv)
+	}
+
+	// Every element takes at least one byte, so the length cannot exceed the remaining data.
+	if length > len(d.data)-d.pos {
+		return fmt.Errorf("%w: %w", ErrInvalidETF, errETFUnexpectedEnd)
+	}
+
+	slice := reflect.MakeSlice(rv.Type(), length, length)
+
+	for i := range length {
+		err := d.decodeValue(slice.Index(i))
+		if err != nil {
+			return err
+		}
+	}
+
+	if proper {
+		tail, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		if tail != etfNil {
+			return fmt.Errorf("%w: improper lists are not supported", ErrInvalidETF)
+		}
+	}
+
+	rv.Set(slice)
+
+	return nil
+}
+
+func (d *etfDecoder) decodeMap(rv reflect.Value, tag byte) error {
+	if tag != etfMap {
+		return d.typeError(tag, rv)
+	}
+
+	d.pos++
+
+	arity, err := d.readUint32()
+	if err != nil {
+		return err
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+
+	var buf bytes.Buffer
+
+	for range arity {
+		key, err := d.readMapKey(&buf)
+		if err != nil {
+			return err
+		}
+
+		value := reflect.New(rv.Type().Elem()).Elem()
+
+		err = d.decodeValue(value)
+		if err != nil {
+			return err
+		}
+
+		rv.SetMapIndex(reflect.ValueOf(string(key)).Convert(rv.Type().Key()), value)
+	}
+
+	return nil
+}
+
+func (d *etfDecoder) decodeStruct(rv reflect.Value, tag byte) error {
+	if tag != etfMap {
+		return d.typeError(tag, rv)
+	}
+
+	d.pos++
+
+	arity, err := d.readUint32()
+	if err != nil {
+		return err
+	}
+
+	fields := cachedETFStructFields(rv.Type())
+
+	var buf bytes.Buffer
+
+	for range arity {
+		key, err := d.readMapKey(&buf)
+		if err != nil {
+			return err
+		}
+
+		index, ok := fields.byName[string(key)]
+		if !ok {
+			index, ok = fields.byFoldedName[strings.ToLower(string(key))]
+		}
+
+		var field reflect.Value
+		if ok {
+			field = etfFieldByIndex(rv, index)
+		}
+
+		if !field.IsValid() {
+			err = d.skipTerm()
+		} else {
+			err = d.decodeValue(field)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readMapKey reads a map key, using buf if the key has to be transcoded. The key is only valid until
+// the next read.
+func (d *etfDecoder) readMapKey(buf *bytes.Buffer) ([]byte, error) {
+	if key, ok := d.readPlainKey(); ok {
+		return key, nil
+	}
+
+	buf.Reset()
+
+	err := d.readKey(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// readInteger reads an integer. Integers sent as binaries are parsed, as they are in JSON strings.
+func (d *etfDecoder) readInteger() (int64, error) {
+	tag, err := d.readByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch tag {
+	case etfSmallInteger:
+		b, err := d.readByte()
+
+		return int64(b), err
+	case etfInteger:
+		i, err := d.readUint32()
+
+		return int64(int32(i)), err
+	case etfSmallBig, etfLargeBig:
+		var n int
+
+		if tag == etfSmallBig {
+			b, err := d.readByte()
+			if err != nil {
+				return 0, err
+			}
+
+			n = int(b)
+		} else {
+			b, err := d.readUint32()
+			if err != nil {
+				return 0, err
+			}
+
+			n = int(b)
+		}
+
+		sign, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+
+		digits, err := d.readBytes(n)
+		if err != nil {
+			return 0, err
+		}
+
+		var value uint64
+
+		for i := n - 1; i >= 0; i-- {
+			if value > math.MaxUint64>>8 {
+				return 0, fmt.Errorf("%w: integer overflows int64", ErrInvalidETF)
+			}
+
+			value = value<<8 | uint64(digits[i])
+		}
+
+		if sign == 0 && value <= math.MaxInt64 {
+			return int64(value), nil
+		}
+
+		if sign != 0 && value <= math.MaxInt64+1 {
+			return -int64(value-1) - 1, nil
+		}
+
+		return 0, fmt.Errorf("%w: integer overflows int64", ErrInvalidETF)
+	case etfBinary:
+		length, err := d.readUint32()
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := d.readBytes(int(length))
+		if err != nil {
+			return 0, err
+		}
+
+		i, err := strconv.ParseInt(string(b), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid integer: %w", ErrInvalidETF, err)
+		}
+
+		return i, nil
+	default:
+		return 0, fmt.Errorf("%w: expected integer, got tag %d", ErrInvalidETF, tag)
+	}
+}
+
+func (d *etfDecoder) readFloat() (float64, error) {
+	tag, err := d.peekByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch tag {
+	case etfNewFloat:
+		d.pos++
+
+		b, err := d.readBytes(8)
+		if err != nil {
+			return 0, err
+		}
+
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case etfFloat:
+		d.pos++
+
+		b, err := d.readBytes(31)
+		if err != nil {
+			return 0, err
+		}
+
+		f, err := strconv.ParseFloat(string(bytes.TrimRight(b, "\x00")), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid float: %w", ErrInvalidETF, err)
+		}
+
+		return f, nil
+	default:
+		i, err := d.readInteger()
+
+		return float64(i), err
+	}
+}
+
+// readString reads a binary or atom as a string. Integers are formatted, as snowflakes are sent as
+// integers rather than strings.
+func (d *etfDecoder) readString() (string, error) {
+	tag, err := d.peekByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch tag {
+	case etfBinary:
+		d.pos++
+
+		length, err := d.readUint32()
+		if err != nil {
+			return "", err
+		}
+
+		b, err := d.readBytes(int(length))
+		if err != nil {
+			return "", err
+		}
+
+		return string(b), nil
+	case etfSmallInteger, etfInteger, etfSmallBig, etfLargeBig:
+		i, err := d.readInteger()
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(i, 10), nil
+	case etfAtom, etfSmallAtom, etfAtomUTF8, etfSmallAtomUTF8:
+		var buf bytes.Buffer
+
+		err = d.readTerm(&buf)
+		if err != nil {
+			return "", err
+		}
+
+		var s string
+
+		err = json.Unmarshal(buf.Bytes(), &s)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid atom: %w", ErrInvalidETF, err)
+		}
+
+		return s, nil
+	default:
+		return "", fmt.Errorf("%w: expected string, got tag %d", ErrInvalidETF, tag)
+	}
+}
+
+func (d *etfDecoder) typeError(tag byte, rv reflect.Value) error {
+	return fmt.Errorf("%w: cannot decode tag %d into %s", ErrInvalidETF, tag, rv.Type())
+}
+
+// cachedETFStructFields returns the fields of a struct by their JSON name, following the rules of
+// encoding/json for tags and embedded structs.
+func cachedETFStructFields(t reflect.Type) *etfStructFields {
+	if fields, ok := etfStructFieldsCache.Load(t); ok {
+		return fields.(*etfStructFields)
+	}
+
+	fields := &etfStructFields{
+		byName:       make(map[string][]int),
+		byFoldedName: make(map[string][]int),
+	}
+
+	for _, field := range reflect.VisibleFields(t) {
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		// Untagged embedded structs have their fields promoted.
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		// Shallower fields take precedence.
+		if existing, ok := fields.byName[name]; !ok || len(field.Index) < len(existing) {
+			fields.byName[name] = field.Index
+		}
+
+		folded := strings.ToLower(name)
+		if existing, ok := fields.byFoldedName[folded]; !ok || len(field.Index) < len(existing) {
+			fields.byFoldedName[folded] = field.Index
+		}
+	}
+
+	actual, _ := etfStructFieldsCache.LoadOrStore(t, fields)
+
+	return actual.(*etfStructFields)
+}
+
+// etfFieldByIndex returns a field of a struct, allocating embedded struct pointers. Returns an invalid
+// value if the field cannot be set.
+func etfFieldByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}
+				}
+
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(x)
+	}
+
+	if !rv.CanSet() {
+		return reflect.Value{}
+	}
+
+	return rv
+}
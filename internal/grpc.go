@@ -0,0 +1,613 @@
+package internal
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/protobuf"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Minimum number of letters in a query word before typos are allowed.
+const queryTypoMinLength = 4
+
+// routeSandwichServer implements the Sandwich gRPC service against SandwichState and managers.
+type routeSandwichServer struct {
+	protobuf.UnimplementedSandwichServer
+
+	sg *Sandwich
+}
+
+// NewGRPCServer creates a gRPC server with the Sandwich service registered. If a gRPC token is
+// configured, requests must send it as the authorization metadata.
+func (sg *Sandwich) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	if sg.Options.GRPCToken != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(sg.grpcUnaryAuthInterceptor),
+			grpc.ChainStreamInterceptor(sg.grpcStreamAuthInterceptor),
+		)
+	} else {
+		sg.Logger.Warn().Msg("gRPC token is not set, gRPC requests are unauthenticated")
+	}
+
+	server := grpc.NewServer(opts...)
+
+	protobuf.RegisterSandwichServer(server, &routeSandwichServer{sg: sg})
+
+	return server
+}
+
+// grpcAuthenticate returns an Unauthenticated error if the authorization metadata is not the gRPC token.
+func (sg *Sandwich) grpcAuthenticate(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	authorization := md.Get("authorization")
+	if len(authorization) == 0 || subtle.ConstantTimeCompare([]byte(authorization[0]), []byte(sg.Options.GRPCToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return nil
+}
+
+func (sg *Sandwich) grpcUnaryAuthInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := sg.grpcAuthenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (sg *Sandwich) grpcStreamAuthInterceptor(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := sg.grpcAuthenticate(stream.Context()); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+func (sg *Sandwich) setupGRPC() error {
+	network := sg.Options.GRPCNetwork
+	if network == "" {
+		network = "tcp"
+	}
+
+	sg.Logger.Info().Msgf("Serving gRPC at %s", sg.Options.GRPCHost)
+
+	listener, err := net.Listen(network, sg.Options.GRPCHost)
+	if err != nil {
+		sg.Logger.Error().Str("host", sg.Options.GRPCHost).Err(err).Msg("Failed to bind to gRPC host")
+
+		return fmt.Errorf("failed to bind to gRPC host: %w", err)
+	}
+
+	err = sg.grpcServer.Serve(listener)
+	if err != nil {
+		sg.Logger.Error().Str("host", sg.Options.GRPCHost).Err(err).Msg("Failed to serve gRPC server")
+
+		return fmt.Errorf("failed to serve gRPC server: %w", err)
+	}
+
+	return nil
+}
+
+func newBaseResponse(err error) *protobuf.BaseResponse {
+	if err != nil {
+		return &protobuf.BaseResponse{
+			Version: VERSION,
+			Error:   err.Error(),
+		}
+	}
+
+	return &protobuf.BaseResponse{
+		Version: VERSION,
+		Ok:      true,
+	}
+}
+
+// matchesQuery returns if the ID equals the query or any of the names match it. A name matches if it
+// contains the query or every word of the query matches a word of the name, in any order. Words
+// match if the query word is within the name word, or one typo from its start. Case is ignored.
+func matchesQuery(query string, id int64, names ...string) bool {
+	if query == strconv.FormatInt(id, 10) {
+		return true
+	}
+
+	query = strings.ToLower(query)
+	queryWords := splitQueryWords(query)
+
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
+		name = strings.ToLower(name)
+
+		if strings.Contains(name, query) || matchesWords(queryWords, splitQueryWords(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitQueryWords splits a string into words of letters and numbers.
+func splitQueryWords(s string) [][]rune {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	words := make([][]rune, len(fields))
+	for i, field := range fields {
+		words[i] = []rune(field)
+	}
+
+	return words
+}
+
+// matchesWords returns if every query word matches a name word.
+func matchesWords(queryWords, nameWords [][]rune) bool {
+	if len(queryWords) == 0 {
+		return false
+	}
+
+	for _, queryWord := range queryWords {
+		if !slices.ContainsFunc(nameWords, func(nameWord []rune) bool {
+			return matchesWord(queryWord, nameWord)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesWord returns if the query word is within the name word or one edit from the start of it.
+// Typos are only allowed in query words of at least queryTypoMinLength letters.
+func matchesWord(queryWord, nameWord []rune) bool {
+	if strings.Contains(string(nameWord), string(queryWord)) {
+		return true
+	}
+
+	if len(queryWord) < queryTypoMinLength {
+		return false
+	}
+
+	// The start of the name word may be a letter shorter or longer than the query word.
+	for length := len(queryWord) - 1; length <= len(queryWord)+1; length++ {
+		if length <= len(nameWord) && withinOneEdit(queryWord, nameWord[:length]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withinOneEdit returns if a can be changed into b by inserting, removing or replacing at most one letter.
+func withinOneEdit(a, b []rune) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	if len(b)-len(a) > 1 {
+		return false
+	}
+
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+
+	if len(a) == len(b) {
+		// Replace the first differing letter.
+		return i == len(a) || slices.Equal(a[i+1:], b[i+1:])
+	}
+
+	// Insert the first differing letter into the shorter word.
+	return slices.Equal(a[i:], b[i+1:])
+}
+
+// marshalValues encodes values keyed by ID as JSON.
+func marshalValues[T any](values map[int64]T) (out map[int64][]byte, err error) {
+	out = make(map[int64][]byte, len(values))
+
+	for id, value := range values {
+		out[id], err = sandwichjson.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %d: %w", id, err)
+		}
+	}
+
+	return out, nil
+}
+
+// onGRPCLookup counts a lookup by ID as a cache hit or miss.
+func onGRPCLookup(ok bool) {
+	if ok {
+		grpcCacheHits.Inc()
+	} else {
+		grpcCacheMisses.Inc()
+	}
+}
+
+func (s *routeSandwichServer) FetchConsumerConfiguration(ctx context.Context, req *protobuf.FetchConsumerConfigurationRequest) (*protobuf.FetchConsumerConfigurationResponse, error) {
+	grpcCacheRequests.Inc()
+
+	configuration := sandwich_structs.SandwichConsumerConfiguration{
+		Version:     VERSION,
+		Identifiers: make(map[string]sandwich_structs.ManagerConsumerConfiguration),
+	}
+
+	s.sg.Managers.Range(func(key string, mg *Manager) bool {
+		mg.configurationMu.RLock()
+		producerIdentifier := mg.Configuration.ProducerIdentifier
+		token := mg.Configuration.Token
+		mg.configurationMu.RUnlock()
+
+		if req.Identifier != "" && req.Identifier != producerIdentifier {
+			return false
+		}
+
+		mg.userMu.RLock()
+		user := mg.User
+		mg.userMu.RUnlock()
+
+		configuration.Identifiers[key] = sandwich_structs.ManagerConsumerConfiguration{
+			Token: token,
+			User:  user,
+			ID:    discord.Snowflake(user.ID),
+		}
+
+		return false
+	})
+
+	file, err := sandwichjson.Marshal(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal consumer configuration: %w", err)
+	}
+
+	return &protobuf.FetchConsumerConfigurationResponse{
+		File: file,
+	}, nil
+}
+
+func (s *routeSandwichServer) FetchGuild(ctx context.Context, req *protobuf.FetchGuildRequest) (*protobuf.GuildsResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guilds := make(map[int64]discord.Guild)
+
+	for _, guildID := range req.GuildIds {
+		guild, ok := s.sg.State.GetGuild(discord.GuildID(guildID))
+		onGRPCLookup(ok)
+
+		if ok {
+			guilds[guildID] = guild
+		}
+	}
+
+	if req.Query != "" {
+		for _, guildID := range s.sg.State.GetAllGuildIDs() {
+			guild, ok := s.sg.State.Backend.GetGuild(guildID)
+			if !ok || !matchesQuery(req.Query, int64(guildID), guild.Name) {
+				continue
+			}
+
+			if guild, ok = s.sg.State.GetGuild(guildID); ok {
+				guilds[int64(guildID)] = guild
+			}
+		}
+	}
+
+	return guildsResponse(guilds)
+}
+
+func guildsResponse(guilds map[int64]discord.Guild) (*protobuf.GuildsResponse, error) {
+	guildsJSON, err := marshalValues(guilds)
+	if err != nil {
+		return nil, err
+	}
+
+	guildIDs := make([]int64, 0, len(guilds))
+	for guildID := range guilds {
+		guildIDs = append(guildIDs, guildID)
+	}
+
+	return &protobuf.GuildsResponse{
+		BaseResponse: newBaseResponse(nil),
+		Guilds:       guildsJSON,
+		GuildIds:     guildIDs,
+	}, nil
+}
+
+func (s *routeSandwichServer) FetchGuildChannels(ctx context.Context, req *protobuf.FetchGuildChannelsRequest) (*protobuf.ChannelsResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guildID := discord.GuildID(req.GuildId)
+	channels := make(map[int64]discord.Channel)
+
+	for _, channelID := range req.ChannelIds {
+		channel, ok := s.sg.State.GetGuildChannel(guildID, discord.ChannelID(channelID))
+		onGRPCLookup(ok)
+
+		if ok {
+			channels[channelID] = channel
+		}
+	}
+
+	if req.Query != "" || len(req.ChannelIds) == 0 {
+		guildChannels, _ := s.sg.State.GetAllGuildChannels(guildID)
+
+		for _, channel := range guildChannels {
+			if req.Query == "" || matchesQuery(req.Query, int64(channel.ID), channel.Name) {
+				channels[int64(channel.ID)] = channel
+			}
+		}
+	}
+
+	channelsJSON, err := marshalValues(channels)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protobuf.ChannelsResponse{
+		BaseResponse:  newBaseResponse(nil),
+		GuildChannels: channelsJSON,
+	}, nil
+}
+
+func (s *routeSandwichServer) FetchGuildEmojis(ctx context.Context, req *protobuf.FetchGuildEmojisRequest) (*protobuf.EmojisResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guildID := discord.GuildID(req.GuildId)
+	emojis := make(map[int64]discord.Emoji)
+
+	for _, emojiID := range req.EmojiIds {
+		emoji, ok := s.sg.State.GetGuildEmoji(guildID, discord.EmojiID(emojiID))
+		onGRPCLookup(ok)
+
+		if ok {
+			emojis[emojiID] = emoji
+		}
+	}
+
+	if req.Query != "" || len(req.EmojiIds) == 0 {
+		guildEmojis, _ := s.sg.State.GetAllGuildEmojis(guildID)
+
+		for _, emoji := range guildEmojis {
+			if req.Query == "" || matchesQuery(req.Query, int64(emoji.ID), emoji.Name) {
+				emojis[int64(emoji.ID)] = emoji
+			}
+		}
+	}
+
+	emojisJSON, err := marshalValues(emojis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protobuf.EmojisResponse{
+		BaseResponse: newBaseResponse(nil),
+		GuildEmojis:  emojisJSON,
+	}, nil
+}
+
+func (s *routeSandwichServer) FetchGuildMembers(ctx context.Context, req *protobuf.FetchGuildMembersRequest) (*protobuf.GuildMembersResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guildID := discord.GuildID(req.GuildId)
+	guildMembers := make(map[int64]discord.GuildMember)
+
+	for _, userID := range req.UserIds {
+		guildMember, ok := s.sg.State.GetGuildMember(guildID, discord.UserID(userID))
+		onGRPCLookup(ok)
+
+		if ok {
+			guildMembers[userID] = guildMember
+		}
+	}
+
+	// Members are not listed without a query, as guilds can have many members.
+	if req.Query != "" {
+		allGuildMembers, _ := s.sg.State.GetAllGuildMembers(guildID)
+
+		for _, guildMember := range allGuildMembers {
+			if guildMember.User == nil {
+				continue
+			}
+
+			user := guildMember.User
+
+			if matchesQuery(req.Query, int64(user.ID), user.Username, user.GlobalName, guildMember.Nick, user.Username+"#"+user.Discriminator) {
+				guildMembers[int64(user.ID)] = guildMember
+			}
+		}
+	}
+
+	guildMembersJSON, err := marshalValues(guildMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protobuf.GuildMembersResponse{
+		BaseResponse: newBaseResponse(nil),
+		GuildMembers: guildMembersJSON,
+	}, nil
+}
+
+func (s *routeSandwichServer) FetchGuildRoles(ctx context.Context, req *protobuf.FetchGuildRolesRequest) (*protobuf.GuildRolesResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guildID := discord.GuildID(req.GuildId)
+	roles := make(map[int64]discord.Role)
+
+	for _, roleID := range req.RoleIds {
+		role, ok := s.sg.State.GetGuildRole(guildID, discord.RoleID(roleID))
+		onGRPCLookup(ok)
+
+		if ok {
+			roles[roleID] = role
+		}
+	}
+
+	if req.Query != "" || len(req.RoleIds) == 0 {
+		guildRoles, _ := s.sg.State.GetAllGuildRoles(guildID)
+
+		for _, role := range guildRoles {
+			if req.Query == "" || matchesQuery(req.Query, int64(role.ID), role.Name) {
+				roles[int64(role.ID)] = role
+			}
+		}
+	}
+
+	rolesJSON, err := marshalValues(roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protobuf.GuildRolesResponse{
+		BaseResponse: newBaseResponse(nil),
+		GuildRoles:   rolesJSON,
+	}, nil
+}
+
+func (s *routeSandwichServer) FetchMutualGuilds(ctx context.Context, req *protobuf.FetchMutualGuildsRequest) (*protobuf.GuildsResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guildIDs, ok := s.sg.State.GetUserMutualGuilds(discord.UserID(req.UserId))
+	onGRPCLookup(ok)
+
+	if !req.Expand {
+		ids := make([]int64, 0, len(guildIDs))
+		for _, guildID := range guildIDs {
+			ids = append(ids, int64(guildID))
+		}
+
+		return &protobuf.GuildsResponse{
+			BaseResponse: newBaseResponse(nil),
+			GuildIds:     ids,
+		}, nil
+	}
+
+	guilds := make(map[int64]discord.Guild, len(guildIDs))
+
+	for _, guildID := range guildIDs {
+		if guild, ok := s.sg.State.GetGuild(guildID); ok {
+			guilds[int64(guildID)] = guild
+		}
+	}
+
+	return guildsResponse(guilds)
+}
+
+func (s *routeSandwichServer) RequestGuildChunk(ctx context.Context, req *protobuf.RequestGuildChunkRequest) (*protobuf.BaseResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guildID := discord.GuildID(req.GuildId)
+
+	var shard *Shard
+
+	s.sg.Managers.Range(func(_ string, mg *Manager) bool {
+		shard = mg.shardOfGuild(guildID)
+
+		return shard != nil
+	})
+
+	if shard == nil {
+		return newBaseResponse(ErrGuildNotFound), nil
+	}
+
+	_, err := shard.ChunkGuild(guildID, true, nil)
+	if err != nil {
+		return newBaseResponse(err), nil
+	}
+
+	return newBaseResponse(nil), nil
+}
+
+func (s *routeSandwichServer) SendWebsocketMessage(ctx context.Context, req *protobuf.SendWebsocketMessageRequest) (*protobuf.BaseResponse, error) {
+	grpcCacheRequests.Inc()
+
+	mg, ok := s.sg.Managers.Load(req.Manager)
+	if !ok {
+		return newBaseResponse(ErrInvalidManager), nil
+	}
+
+	shardGroup, ok := mg.ShardGroups.Load(req.ShardGroup)
+	if !ok {
+		return newBaseResponse(ErrInvalidShardGroup), nil
+	}
+
+	shard, ok := shardGroup.Shards.Load(req.Shard)
+	if !ok {
+		return newBaseResponse(ErrInvalidShard), nil
+	}
+
+	err := shard.SendEvent(ctx, discord.GatewayOp(req.GatewayOpCode), json.RawMessage(req.Data))
+	if err != nil {
+		return newBaseResponse(err), nil
+	}
+
+	return newBaseResponse(nil), nil
+}
+
+func (s *routeSandwichServer) WhereIsGuild(ctx context.Context, req *protobuf.WhereIsGuildRequest) (*protobuf.WhereIsGuildResponse, error) {
+	grpcCacheRequests.Inc()
+
+	guildID := discord.GuildID(req.GuildId)
+	locations := make([]*protobuf.WhereIsGuildLocation, 0)
+
+	s.sg.Managers.Range(func(key string, mg *Manager) bool {
+		shard := mg.shardOfGuild(guildID)
+		if shard == nil {
+			return false
+		}
+
+		location := &protobuf.WhereIsGuildLocation{
+			Manager:    key,
+			ShardGroup: shard.ShardGroup.ID,
+			ShardId:    shard.ShardID,
+		}
+
+		if guildMember, ok := s.sg.State.GetGuildMember(guildID, discord.UserID(mg.UserID.Load())); ok {
+			location.GuildMember, _ = sandwichjson.Marshal(guildMember)
+		}
+
+		locations = append(locations, location)
+
+		return false
+	})
+
+	return &protobuf.WhereIsGuildResponse{
+		BaseResponse: newBaseResponse(nil),
+		Locations:    locations,
+	}, nil
+}
+
+// shardOfGuild returns the shard of the manager that has received a guild.
+func (mg *Manager) shardOfGuild(guildID discord.GuildID) (shard *Shard) {
+	mg.ShardGroups.Range(func(_ int32, shardGroup *ShardGroup) bool {
+		shardGroup.Shards.Range(func(_ int32, sh *Shard) bool {
+			if sh.Guilds.Has(guildID) {
+				shard = sh
+			}
+
+			return shard != nil
+		})
+
+		return shard != nil
+	})
+
+	return shard
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/protobuf"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCServer(t *testing.T) {
+	sg, mg := newProxyTestSandwich(t, "http://127.0.0.1")
+
+	ctx := NewFakeCtx(mg)
+
+	sg.State.Backend.SetGuild(discord.Guild{ID: 1, Name: "Welcomer Support"})
+	sg.State.SetGuildRole(ctx, 1, discord.Role{ID: 1, Name: "@everyone"})
+	sg.State.SetGuildRole(ctx, 1, discord.Role{ID: 2, Name: "Moderator"})
+	sg.State.SetGuildMember(ctx, 1, discord.GuildMember{User: &discord.User{ID: 3, Username: "alice"}, Nick: "Ally"})
+	sg.State.SetGuildMember(ctx, 1, discord.GuildMember{User: &discord.User{ID: 4, Username: "bob"}})
+
+	shard := &Shard{ShardID: 5, Guilds: NewCache[discord.GuildID, struct{}](0)}
+	shard.Guilds.Store(1, struct{}{})
+
+	shardGroup := &ShardGroup{ID: 2, Shards: NewCache[int32, *Shard](0)}
+	shardGroup.Shards.Store(5, shard)
+	shard.ShardGroup = shardGroup
+
+	mg.ShardGroups.Store(2, shardGroup)
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := sg.NewGRPCServer()
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := protobuf.NewSandwichClient(conn)
+
+	guilds, err := client.FetchGuild(context.Background(), &protobuf.FetchGuildRequest{Query: "support"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var guild discord.Guild
+	if err := sandwichjson.Unmarshal(guilds.Guilds[1], &guild); err != nil || guild.Name != "Welcomer Support" || len(guild.Roles) != 2 {
+		t.Errorf("Unexpected guild %+v: %v", guild, err)
+	}
+
+	roles, err := client.FetchGuildRoles(context.Background(), &protobuf.FetchGuildRolesRequest{GuildId: 1, Query: "MOD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roles.GuildRoles) != 1 || roles.GuildRoles[2] == nil {
+		t.Errorf("Expected only the moderator role to match, got %v", roles.GuildRoles)
+	}
+
+	members, err := client.FetchGuildMembers(context.Background(), &protobuf.FetchGuildMembersRequest{GuildId: 1, Query: "ally", UserIds: []int64{4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(members.GuildMembers) != 2 || members.GuildMembers[3] == nil || members.GuildMembers[4] == nil {
+		t.Errorf("Expected members matched by nick and ID, got %v", members.GuildMembers)
+	}
+
+	locations, err := client.WhereIsGuild(context.Background(), &protobuf.WhereIsGuildRequest{GuildId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(locations.Locations) != 1 || locations.Locations[0].Manager != "test" ||
+		locations.Locations[0].ShardGroup != 2 || locations.Locations[0].ShardId != 5 {
+		t.Errorf("Unexpected locations %v", locations.Locations)
+	}
+
+	response, err := client.SendWebsocketMessage(context.Background(), &protobuf.SendWebsocketMessageRequest{Manager: "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Ok || response.Error != ErrInvalidManager.Error() {
+		t.Errorf("Expected a missing manager error, got %v", response)
+	}
+}
+
+func TestGRPCServerAuthentication(t *testing.T) {
+	sg, _ := newProxyTestSandwich(t, "http://127.0.0.1")
+	sg.Options.GRPCToken = "secret"
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := sg.NewGRPCServer()
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := protobuf.NewSandwichClient(conn)
+
+	for name, ctx := range map[string]context.Context{
+		"missing token": context.Background(),
+		"invalid token": metadata.AppendToOutgoingContext(context.Background(), "authorization", "invalid"),
+	} {
+		_, err = client.FetchConsumerConfiguration(ctx, &protobuf.FetchConsumerConfigurationRequest{})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: expected unauthenticated, got %v", name, err)
+		}
+	}
+
+	_, err = client.FetchConsumerConfiguration(
+		metadata.AppendToOutgoingContext(context.Background(), "authorization", "secret"),
+		&protobuf.FetchConsumerConfigurationRequest{},
+	)
+	if err != nil {
+		t.Errorf("Expected request with token to succeed, got %v", err)
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		name     string
+		expected bool
+	}{
+		{"support", "Welcomer Support", true},
+		{"support welcomer", "Welcomer Support", true},
+		{"suport", "Welcomer Support", true},
+		{"welcomr supprt", "Welcomer Support", true},
+		{"wlcomr", "Welcomer Support", false},
+		{"mod", "Moderator", true},
+		{"mdo", "Moderator", false},
+		{"user#0001", "user#0001", true},
+		{"server", "Welcomer Support", false},
+		{"1", "Welcomer Support", true},
+	}
+
+	for _, test := range tests {
+		if matched := matchesQuery(test.query, 1, test.name); matched != test.expected {
+			t.Errorf("matchesQuery(%q, %q) = %t, expected %t", test.query, test.name, matched, test.expected)
+		}
+	}
+}
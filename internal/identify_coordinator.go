@@ -0,0 +1,356 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// Session starts allowed per window if the limit of the token is not known.
+	DefaultIdentifySessionStartLimit = 1000
+
+	identifySessionStartWindow = 24 * time.Hour
+)
+
+// IdentifyCoordinatorConfiguration configures coordinating identifies between Sandwich instances that
+// share a token. The coordinator serves the identify URL protocol at /api/identify, so other instances
+// can use it as their identify URL. Shards of this instance identify through the coordinator as well.
+type IdentifyCoordinatorConfiguration struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Required. Identify requests must send the secret as their Authorization header.
+	Secret string `json:"secret" yaml:"secret"`
+	// Backend identify leases are stored in. Either "memory" or "redis". Defaults to "memory".
+	// With redis, every instance using the same redis can coordinate without an identify URL.
+	// The coordinator is only created on start.
+	Backend string                  `json:"backend" yaml:"backend"`
+	Redis   RedisStateConfiguration `json:"redis" yaml:"redis"`
+}
+
+// identifyLeaser grants leases to identify. Shards of a token identify at most once per bucket
+// every 5 seconds, where the bucket is the shard id modulo max concurrency, and only start as many
+// sessions as the daily session start limit allows.
+type identifyLeaser interface {
+	// Acquire returns 0 if the shard can identify, otherwise how long to wait before retrying.
+	Acquire(ctx context.Context, payload sandwich_structs.IdentifyPayload) (wait time.Duration, err error)
+}
+
+// IdentifyCoordinator coordinates identifies of shards sharing a token.
+type IdentifyCoordinator struct {
+	Logger zerolog.Logger
+
+	leaser identifyLeaser
+}
+
+// NewIdentifyCoordinator creates the identify coordinator of the configured backend.
+func NewIdentifyCoordinator(ctx context.Context, logger zerolog.Logger, configuration IdentifyCoordinatorConfiguration) (*IdentifyCoordinator, error) {
+	var leaser identifyLeaser
+
+	switch configuration.Backend {
+	case "", "memory":
+		leaser = newMemoryIdentifyLeaser()
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     configuration.Redis.Address,
+			Username: configuration.Redis.Username,
+			Password: configuration.Redis.Password,
+			DB:       configuration.Redis.DB,
+		})
+
+		err := client.Ping(ctx).Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed to ping redis: %w", err)
+		}
+
+		leaser = newRedisIdentifyLeaser(client, configuration.Redis.Prefix)
+	default:
+		return nil, ErrInvalidIdentifyCoordinatorBackend
+	}
+
+	return &IdentifyCoordinator{
+		Logger: logger,
+		leaser: leaser,
+	}, nil
+}
+
+// Acquire returns 0 if the shard can identify, otherwise how long to wait before retrying.
+func (ic *IdentifyCoordinator) Acquire(ctx context.Context, payload sandwich_structs.IdentifyPayload) (time.Duration, error) {
+	if payload.TokenHash == "" {
+		hash, err := quickHash(sha256.New(), payload.Token)
+		if err != nil {
+			return 0, err
+		}
+
+		payload.TokenHash = hash
+	}
+
+	if payload.MaxConcurrency < 1 {
+		payload.MaxConcurrency = 1
+	}
+
+	return ic.leaser.Acquire(ctx, payload)
+}
+
+// Wait blocks until the shard can identify.
+func (ic *IdentifyCoordinator) Wait(ctx context.Context, payload sandwich_structs.IdentifyPayload) error {
+	for {
+		wait, err := ic.Acquire(ctx, payload)
+		if err != nil {
+			ic.Logger.Warn().Err(err).Msg("Failed to acquire identify lease")
+
+			wait = IdentifyRetry
+		}
+
+		if wait <= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// identifySessionStartLimit returns the session start limit of the token, the number of sessions
+// already started and when the limit resets.
+func identifySessionStartLimit(payload sandwich_structs.IdentifyPayload) (total int32, used int32, resetAfter time.Duration) {
+	limit := payload.SessionStartLimit
+	if limit == nil || limit.Total <= 0 {
+		return DefaultIdentifySessionStartLimit, 0, identifySessionStartWindow
+	}
+
+	resetAfter = time.Duration(limit.ResetAfter) * time.Millisecond
+	if resetAfter <= 0 {
+		resetAfter = identifySessionStartWindow
+	}
+
+	return limit.Total, max(limit.Total-limit.Remaining, 0), resetAfter
+}
+
+func identifyBucket(payload sandwich_structs.IdentifyPayload) int32 {
+	return payload.ShardID % payload.MaxConcurrency
+}
+
+type memoryIdentifySessions struct {
+	total   int32
+	used    int32
+	resetAt time.Time
+}
+
+// memoryIdentifyLeaser stores identify leases in memory, coordinating the shards of this instance
+// and instances using it as their identify URL.
+type memoryIdentifyLeaser struct {
+	mu sync.Mutex
+
+	// Time each bucket can next identify, keyed by token hash and bucket.
+	buckets  map[string]time.Time
+	sessions map[string]*memoryIdentifySessions
+}
+
+func newMemoryIdentifyLeaser() *memoryIdentifyLeaser {
+	return &memoryIdentifyLeaser{
+		buckets:  make(map[string]time.Time),
+		sessions: make(map[string]*memoryIdentifySessions),
+	}
+}
+
+func (ml *memoryIdentifyLeaser) Acquire(_ context.Context, payload sandwich_structs.IdentifyPayload) (time.Duration, error) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	now := time.Now()
+
+	sessions, ok := ml.sessions[payload.TokenHash]
+	if !ok || !now.Before(sessions.resetAt) {
+		total, used, resetAfter := identifySessionStartLimit(payload)
+
+		sessions = &memoryIdentifySessions{
+			total:   total,
+			used:    used,
+			resetAt: now.Add(resetAfter),
+		}
+
+		ml.sessions[payload.TokenHash] = sessions
+	}
+
+	if sessions.used >= sessions.total {
+		return sessions.resetAt.Sub(now), nil
+	}
+
+	bucketKey := payload.TokenHash + ":" + strconv.Itoa(int(identifyBucket(payload)))
+
+	if next := ml.buckets[bucketKey]; now.Before(next) {
+		return next.Sub(now), nil
+	}
+
+	ml.buckets[bucketKey] = now.Add(IdentifyRateLimit)
+	sessions.used++
+
+	return 0, nil
+}
+
+// Checks the session start limit and bucket of a shard and takes a lease if both allow it.
+// Returns 0 if a lease was taken, otherwise milliseconds to wait.
+//
+// KEYS[1] session starts, KEYS[2] bucket lease
+// ARGV[1] session start limit, ARGV[2] session starts used, ARGV[3] milliseconds until reset,
+// ARGV[4] milliseconds of the bucket lease.
+var redisIdentifyAcquireScript = redis.NewScript(`
+local used = redis.call('GET', KEYS[1])
+if not used then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	used = ARGV[2]
+end
+
+if tonumber(used) >= tonumber(ARGV[1]) then
+	return math.max(redis.call('PTTL', KEYS[1]), 1)
+end
+
+local wait = redis.call('PTTL', KEYS[2])
+if wait > 0 then
+	return wait
+end
+
+redis.call('SET', KEYS[2], 1, 'PX', ARGV[4])
+redis.call('INCR', KEYS[1])
+
+return 0
+`)
+
+// redisIdentifyLeaser stores identify leases in redis, coordinating every instance using the same redis.
+//
+//	{prefix}:identify:{token hash}:sessions          session starts in the current window
+//	{prefix}:identify:{token hash}:bucket:{bucket}   lease of a bucket, expiring after 5 seconds
+type redisIdentifyLeaser struct {
+	client *redis.Client
+	prefix string
+}
+
+func newRedisIdentifyLeaser(client *redis.Client, prefix string) *redisIdentifyLeaser {
+	if prefix == "" {
+		prefix = DefaultRedisStatePrefix
+	}
+
+	return &redisIdentifyLeaser{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (rl *redisIdentifyLeaser) Acquire(ctx context.Context, payload sandwich_structs.IdentifyPayload) (time.Duration, error) {
+	total, used, resetAfter := identifySessionStartLimit(payload)
+
+	key := rl.prefix + ":identify:" + payload.TokenHash
+
+	wait, err := redisIdentifyAcquireScript.Run(
+		ctx, rl.client,
+		[]string{key + ":sessions", key + ":bucket:" + strconv.Itoa(int(identifyBucket(payload)))},
+		total, used, resetAfter.Milliseconds(), IdentifyRateLimit.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire identify lease: %w", err)
+	}
+
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+func (sg *Sandwich) identifyCoordinatorConfiguration() IdentifyCoordinatorConfiguration {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	return sg.Configuration.Identify.Coordinator
+}
+
+// setupIdentifyCoordinator creates the identify coordinator if it is enabled.
+func (sg *Sandwich) setupIdentifyCoordinator() {
+	configuration := sg.identifyCoordinatorConfiguration()
+	if !configuration.Enabled {
+		return
+	}
+
+	var coordinator *IdentifyCoordinator
+
+	err := ErrIdentifyCoordinatorSecret
+	if configuration.Secret != "" {
+		coordinator, err = NewIdentifyCoordinator(sg.ctx, sg.Logger, configuration)
+	}
+
+	if err != nil {
+		sg.Logger.Error().Err(err).Msg("Failed to create identify coordinator")
+
+		go sg.PublishSimpleWebhook("Failed to create identify coordinator", "`"+err.Error()+"`", "", EmbedColourDanger)
+
+		return
+	}
+
+	sg.identifyCoordinator = coordinator
+
+	sg.Logger.Info().Str("backend", configuration.Backend).Msg("Using identify coordinator")
+}
+
+// writeIdentifyRetry writes an identify response telling the client to retry later. Clients of the
+// identify URL protocol retry immediately if no wait is given.
+func writeIdentifyRetry(ctx *fasthttp.RequestCtx, statusCode int, message string) {
+	writeResponse(ctx, statusCode, sandwich_structs.IdentifyResponse{
+		Message: message,
+		Wait:    int32(IdentifyRetry.Milliseconds()),
+	})
+}
+
+// /api/identify: Serves the identify URL protocol for other Sandwich instances.
+func (sg *Sandwich) IdentifyEndpoint(ctx *fasthttp.RequestCtx) {
+	if sg.identifyCoordinator == nil {
+		writeIdentifyRetry(ctx, fasthttp.StatusNotFound, ErrIdentifyCoordinatorDisabled.Error())
+
+		return
+	}
+
+	secret := sg.identifyCoordinatorConfiguration().Secret
+	if subtle.ConstantTimeCompare(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization), []byte(secret)) != 1 {
+		writeIdentifyRetry(ctx, fasthttp.StatusUnauthorized, "Unauthorized")
+
+		return
+	}
+
+	var payload sandwich_structs.IdentifyPayload
+
+	err := sandwichjson.Unmarshal(ctx.PostBody(), &payload)
+	if err != nil || (payload.Token == "" && payload.TokenHash == "") {
+		writeIdentifyRetry(ctx, fasthttp.StatusBadRequest, "Invalid identify payload")
+
+		return
+	}
+
+	wait, err := sg.identifyCoordinator.Acquire(ctx, payload)
+	if err != nil {
+		sg.Logger.Warn().Err(err).Msg("Failed to acquire identify lease")
+
+		writeIdentifyRetry(ctx, fasthttp.StatusOK, err.Error())
+
+		return
+	}
+
+	if wait > 0 {
+		writeResponse(ctx, fasthttp.StatusOK, sandwich_structs.IdentifyResponse{
+			Wait: int32(min(max(wait.Milliseconds(), 1), int64(identifySessionStartWindow.Milliseconds()))),
+		})
+
+		return
+	}
+
+	writeResponse(ctx, fasthttp.StatusOK, sandwich_structs.IdentifyResponse{
+		Success: true,
+	})
+}
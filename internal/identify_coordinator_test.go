@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+)
+
+func testIdentifyCoordinator(t *testing.T, coordinator *IdentifyCoordinator) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	payload := func(shardID int32) structs.IdentifyPayload {
+		return structs.IdentifyPayload{
+			Token:          "token",
+			ShardID:        shardID,
+			ShardCount:     32,
+			MaxConcurrency: 16,
+			SessionStartLimit: &structs.IdentifySessionStartLimit{
+				Total:      1000,
+				Remaining:  2,
+				ResetAfter: 60000,
+			},
+		}
+	}
+
+	acquire := func(shardID int32) time.Duration {
+		t.Helper()
+
+		wait, err := coordinator.Acquire(ctx, payload(shardID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return wait
+	}
+
+	if wait := acquire(0); wait != 0 {
+		t.Fatalf("expected shard 0 to identify, got wait %s", wait)
+	}
+
+	// Shard 16 shares the bucket of shard 0.
+	if wait := acquire(16); wait <= 0 || wait > IdentifyRateLimit {
+		t.Fatalf("expected shard 16 to wait for its bucket, got wait %s", wait)
+	}
+
+	if wait := acquire(1); wait != 0 {
+		t.Fatalf("expected shard 1 to identify, got wait %s", wait)
+	}
+
+	// Both remaining session starts have been used.
+	if wait := acquire(2); wait <= IdentifyRateLimit || wait > time.Minute {
+		t.Fatalf("expected shard 2 to wait for the session start limit to reset, got wait %s", wait)
+	}
+}
+
+func TestIdentifyCoordinatorMemory(t *testing.T) {
+	coordinator, err := NewIdentifyCoordinator(context.Background(), zerolog.Nop(), IdentifyCoordinatorConfiguration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testIdentifyCoordinator(t, coordinator)
+}
+
+func TestIdentifyCoordinatorRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	coordinator, err := NewIdentifyCoordinator(context.Background(), zerolog.Nop(), IdentifyCoordinatorConfiguration{
+		Backend: "redis",
+		Redis:   RedisStateConfiguration{Address: server.Addr()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testIdentifyCoordinator(t, coordinator)
+}
+
+func TestIdentifyEndpoint(t *testing.T) {
+	sg, _ := newProxyTestSandwich(t, "http://127.0.0.1")
+
+	identify := func(authorization string) (int, structs.IdentifyResponse) {
+		t.Helper()
+
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Init(&fasthttp.Request{}, nil, nil)
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.Header.Set(fasthttp.HeaderAuthorization, authorization)
+		ctx.Request.SetBodyString(`{"token":"token","shard_id":0,"shard_count":1,"max_concurrency":1}`)
+
+		sg.IdentifyEndpoint(ctx)
+
+		var response structs.IdentifyResponse
+		if err := sandwichjson.Unmarshal(ctx.Response.Body(), &response); err != nil {
+			t.Fatal(err)
+		}
+
+		return ctx.Response.StatusCode(), response
+	}
+
+	// Clients retry without waiting if no wait is given.
+	if status, response := identify("secret"); status != fasthttp.StatusNotFound || response.Wait <= 0 {
+		t.Fatalf("expected disabled coordinator to return 404 with a wait, got %d %+v", status, response)
+	}
+
+	sg.Configuration.Identify.Coordinator = IdentifyCoordinatorConfiguration{Enabled: true}
+	sg.setupIdentifyCoordinator()
+
+	if sg.identifyCoordinator != nil {
+		t.Fatal("expected coordinator without a secret not to be created")
+	}
+
+	sg.Configuration.Identify.Coordinator.Secret = "secret"
+	sg.setupIdentifyCoordinator()
+
+	if status, response := identify("invalid"); status != fasthttp.StatusUnauthorized || response.Wait <= 0 {
+		t.Fatalf("expected invalid secret to return 401 with a wait, got %d %+v", status, response)
+	}
+
+	if status, response := identify("secret"); status != fasthttp.StatusOK || !response.Success {
+		t.Fatalf("expected identify to succeed, got %d %+v", status, response)
+	}
+}
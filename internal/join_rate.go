@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+// Number of seconds member joins and leaves are kept for. This is the largest window reported.
+const memberRateHistory = 600
+
+// Windows that join and leave rates are reported for, in seconds.
+var memberRateWindows = []struct {
+	Name    string
+	Seconds int64
+}{
+	{"10s", 10},
+	{"1m", 60},
+	{"10m", 600},
+}
+
+// JoinSpikeConfiguration configures when SANDWICH_JOIN_SPIKE is published.
+type JoinSpikeConfiguration struct {
+	// Number of joins within the window that is considered a spike. 0 disables join spikes.
+	Threshold int32 `json:"threshold" yaml:"threshold"`
+	// Number of seconds joins are counted over, up to 600. Defaults to 10.
+	Window int32 `json:"window" yaml:"window"`
+	// Minimum number of seconds between join spikes of a guild. Defaults to the window.
+	Cooldown int32 `json:"cooldown" yaml:"cooldown"`
+}
+
+// WindowSeconds returns the number of seconds joins are counted over.
+func (jc JoinSpikeConfiguration) WindowSeconds() int64 {
+	if jc.Window <= 0 {
+		return 10
+	}
+
+	return min(int64(jc.Window), memberRateHistory)
+}
+
+// CooldownSeconds returns the minimum number of seconds between join spikes.
+func (jc JoinSpikeConfiguration) CooldownSeconds() int64 {
+	if jc.Cooldown <= 0 {
+		return jc.WindowSeconds()
+	}
+
+	return int64(jc.Cooldown)
+}
+
+// GuildMemberRate counts the member joins and leaves of a guild for each second of the history.
+type GuildMemberRate struct {
+	seconds [memberRateHistory]int64
+	joins   [memberRateHistory]int32
+	leaves  [memberRateHistory]int32
+
+	lastSpike int64
+
+	mu sync.Mutex
+}
+
+// Add counts a join or leave at the unix time now.
+func (gr *GuildMemberRate) Add(now int64, join bool) {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	i := now % memberRateHistory
+
+	if gr.seconds[i] != now {
+		gr.seconds[i] = now
+		gr.joins[i] = 0
+		gr.leaves[i] = 0
+	}
+
+	if join {
+		gr.joins[i]++
+	} else {
+		gr.leaves[i]++
+	}
+}
+
+// Count returns the joins and leaves in the last window seconds.
+func (gr *GuildMemberRate) Count(now int64, window int64) (joins int32, leaves int32) {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	for i := range gr.seconds {
+		if gr.seconds[i] > now-window && gr.seconds[i] <= now {
+			joins += gr.joins[i]
+			leaves += gr.leaves[i]
+		}
+	}
+
+	return joins, leaves
+}
+
+// Rates returns the joins and leaves for each reported window.
+func (gr *GuildMemberRate) Rates(now int64) (joinRate map[string]int32, leaveRate map[string]int32) {
+	joinRate = make(map[string]int32, len(memberRateWindows))
+	leaveRate = make(map[string]int32, len(memberRateWindows))
+
+	for _, window := range memberRateWindows {
+		joinRate[window.Name], leaveRate[window.Name] = gr.Count(now, window.Seconds)
+	}
+
+	return joinRate, leaveRate
+}
+
+// LastActive returns the last unix time a join or leave was counted.
+func (gr *GuildMemberRate) LastActive() (lastActive int64) {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	for _, second := range gr.seconds {
+		lastActive = max(lastActive, second)
+	}
+
+	return lastActive
+}
+
+// checkSpike returns if the joins within the window cross the threshold and marks the spike if so.
+func (gr *GuildMemberRate) checkSpike(now int64, configuration JoinSpikeConfiguration) (joins int32, spike bool) {
+	joins, _ = gr.Count(now, configuration.WindowSeconds())
+
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	if joins < configuration.Threshold || now-gr.lastSpike < configuration.CooldownSeconds() {
+		return joins, false
+	}
+
+	gr.lastSpike = now
+
+	return joins, true
+}
+
+// AddMemberRate counts a member join or leave for a guild and returns the guild member rate.
+func (sg *Sandwich) AddMemberRate(guildID discord.GuildID, join bool) *GuildMemberRate {
+	memberRate, ok := sg.MemberRates.Load(guildID)
+	if !ok {
+		sg.MemberRates.SetIfAbsent(guildID, &GuildMemberRate{})
+		memberRate, _ = sg.MemberRates.Load(guildID)
+	}
+
+	memberRate.Add(time.Now().Unix(), join)
+
+	return memberRate
+}
+
+// OnGuildMemberJoin counts a member join and returns the extras for GUILD_MEMBER_ADD.
+// Publishes SANDWICH_JOIN_SPIKE if the join spike threshold of the manager is crossed.
+func (mg *Manager) OnGuildMemberJoin(guildID discord.GuildID, user discord.User) map[string]interface{} {
+	now := time.Now()
+	memberRate := mg.Sandwich.AddMemberRate(guildID, true)
+
+	joinRate, _ := memberRate.Rates(now.Unix())
+
+	mg.configurationMu.RLock()
+	joinSpike := mg.Configuration.Events.JoinSpike
+	mg.configurationMu.RUnlock()
+
+	if joinSpike.Threshold > 0 {
+		if joins, spike := memberRate.checkSpike(now.Unix(), joinSpike); spike {
+			go mg.publishJoinSpike(guildID, joins, joinSpike, joinRate)
+		}
+	}
+
+	return map[string]interface{}{
+		"join_rate":   joinRate,
+		"account_age": int64(now.Sub(discord.Snowflake(user.ID).Time()).Seconds()),
+	}
+}
+
+// OnGuildMemberLeave counts a member leave and returns the extras for GUILD_MEMBER_REMOVE.
+func (mg *Manager) OnGuildMemberLeave(guildID discord.GuildID) map[string]interface{} {
+	memberRate := mg.Sandwich.AddMemberRate(guildID, false)
+
+	_, leaveRate := memberRate.Rates(time.Now().Unix())
+
+	return map[string]interface{}{
+		"leave_rate": leaveRate,
+	}
+}
+
+func (mg *Manager) publishJoinSpike(guildID discord.GuildID, joins int32, configuration JoinSpikeConfiguration, joinRate map[string]int32) {
+	payload, err := sandwichjson.Marshal(sandwich_structs.JoinSpike{
+		GuildID:   int64(guildID),
+		Joins:     joins,
+		Threshold: configuration.Threshold,
+		Window:    int32(configuration.WindowSeconds()),
+		JoinRate:  joinRate,
+	})
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to marshal join spike")
+
+		return
+	}
+
+	mg.Logger.Warn().Int64("guild_id", int64(guildID)).Int32("joins", joins).Msg("Guild join spike")
+
+	err = mg.PublishEvent(context.Background(), sandwich_structs.SandwichEventJoinSpike, json.RawMessage(payload))
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to publish join spike")
+	}
+}
+
+// gatherMemberRates updates the member rate gauges and removes guilds without any recent joins or leaves.
+func (sg *Sandwich) gatherMemberRates() {
+	now := time.Now().Unix()
+
+	inactiveGuilds := make([]discord.GuildID, 0)
+
+	sg.MemberRates.Range(func(guildID discord.GuildID, memberRate *GuildMemberRate) bool {
+		guildIDLabel := strconv.FormatInt(int64(guildID), 10)
+
+		if now-memberRate.LastActive() > memberRateHistory {
+			inactiveGuilds = append(inactiveGuilds, guildID)
+
+			sandwichGuildJoinRate.DeletePartialMatch(map[string]string{"guild_id": guildIDLabel})
+			sandwichGuildLeaveRate.DeletePartialMatch(map[string]string{"guild_id": guildIDLabel})
+
+			return false
+		}
+
+		joinRate, leaveRate := memberRate.Rates(now)
+
+		for window, joins := range joinRate {
+			sandwichGuildJoinRate.WithLabelValues(guildIDLabel, window).Set(float64(joins))
+		}
+
+		for window, leaves := range leaveRate {
+			sandwichGuildLeaveRate.WithLabelValues(guildIDLabel, window).Set(float64(leaves))
+		}
+
+		return false
+	})
+
+	for _, guildID := range inactiveGuilds {
+		sg.MemberRates.Delete(guildID)
+	}
+}
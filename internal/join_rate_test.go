@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestGuildMemberRate(t *testing.T) {
+	memberRate := &GuildMemberRate{}
+
+	now := int64(1_700_000_000)
+
+	memberRate.Add(now-120, true)
+	memberRate.Add(now-30, true)
+	memberRate.Add(now-5, false)
+	memberRate.Add(now, true)
+	memberRate.Add(now, true)
+
+	joinRate, leaveRate := memberRate.Rates(now)
+
+	if joinRate["10s"] != 2 || joinRate["1m"] != 3 || joinRate["10m"] != 4 {
+		t.Errorf("Unexpected join rate %v", joinRate)
+	}
+
+	if leaveRate["10s"] != 1 || leaveRate["10m"] != 1 {
+		t.Errorf("Unexpected leave rate %v", leaveRate)
+	}
+
+	// Buckets older than the history are reused.
+	memberRate.Add(now+memberRateHistory, true)
+
+	if joins, _ := memberRate.Count(now+memberRateHistory, memberRateHistory); joins != 1 {
+		t.Errorf("Expected expired buckets to be replaced, got %d joins", joins)
+	}
+}
+
+func TestGuildMemberRateSpike(t *testing.T) {
+	memberRate := &GuildMemberRate{}
+	configuration := JoinSpikeConfiguration{Threshold: 3, Window: 10, Cooldown: 60}
+
+	now := int64(1_700_000_000)
+
+	for i := 0; i < 2; i++ {
+		memberRate.Add(now, true)
+	}
+
+	if _, spike := memberRate.checkSpike(now, configuration); spike {
+		t.Errorf("Expected no spike below threshold")
+	}
+
+	memberRate.Add(now, true)
+
+	if joins, spike := memberRate.checkSpike(now, configuration); !spike || joins != 3 {
+		t.Errorf("Expected spike with 3 joins, got %d", joins)
+	}
+
+	memberRate.Add(now+1, true)
+
+	if _, spike := memberRate.checkSpike(now+1, configuration); spike {
+		t.Errorf("Expected no spike during cooldown")
+	}
+}
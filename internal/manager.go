@@ -66,6 +66,8 @@ type Manager struct {
 
 	produceBlacklist []string
 
+	cachePolicies *StateCachePolicies
+
 	Gateway discord.GatewayBotResponse `json:"gateway" yaml:"gateway"`
 
 	User discord.User `json:"user"`
@@ -80,6 +82,35 @@ type Manager struct {
 
 	produceBlacklistMu sync.RWMutex
 
+	cachePoliciesMu sync.RWMutex
+
+	auditCorrelator *AuditCorrelator
+
+	inviteTracker *InviteTracker
+
+	spool *Spool
+
+	// Number of dropped spooled payloads already counted in metrics.
+	spoolDropped int64
+
+	producerRoutes   []*producerRoute
+	producerRoutesMu sync.RWMutex
+
+	backpressured         atomic.Bool
+	backpressureDropped   atomic.Int64
+	producerQueue         atomic.Int64
+	producerQueueCapacity atomic.Int64
+
+	rescale   *rescaleState
+	rescaleMu sync.RWMutex
+
+	// Set while events are produced by more than one shard group.
+	handoffDedupe atomic.Bool
+	handoffEvents *csmap.CsMap[string, handoffEvent]
+
+	autoRescaleMu   sync.Mutex
+	lastAutoRescale atomic.Time
+
 	metadataMu sync.RWMutex
 
 	clientMu sync.Mutex
@@ -93,6 +124,11 @@ type ManagerConfiguration struct {
 		ShardIDs    string `json:"shard_ids" yaml:"shard_ids"`
 		ShardCount  int32  `json:"shard_count" yaml:"shard_count"`
 		AutoSharded bool   `json:"auto_sharded" yaml:"auto_sharded"`
+		// Seconds a new shard group and the shard groups it replaces both produce events, with events
+		// received by both deduplicated. Defaults to 10.
+		HandoffWindow int32 `json:"handoff_window" yaml:"handoff_window"`
+
+		AutoRescale AutoRescaleConfiguration `json:"auto_rescale" yaml:"auto_rescale"`
 	} `json:"sharding" yaml:"sharding"`
 	// Unique name that will be referenced internally
 	Identifier string `json:"identifier" yaml:"identifier"`
@@ -106,8 +142,18 @@ type ManagerConfiguration struct {
 	Events struct {
 		EventBlacklist   []string `json:"event_blacklist" yaml:"event_blacklist"`
 		ProduceBlacklist []string `json:"produce_blacklist" yaml:"produce_blacklist"`
+
+		JoinSpike JoinSpikeConfiguration `json:"join_spike" yaml:"join_spike"`
+
+		AuditCorrelation AuditCorrelationConfiguration `json:"audit_correlation" yaml:"audit_correlation"`
 	} `json:"events" yaml:"events"`
 
+	Backpressure BackpressureConfiguration `json:"backpressure" yaml:"backpressure"`
+
+	// Producers events are routed to in addition to the sandwich producer, each receiving
+	// the events that match its filter. The produce blacklist applies to every producer.
+	Producers []ProducerRouteConfiguration `json:"producers" yaml:"producers"`
+
 	Messaging struct {
 		ClientName      string `json:"client_name" yaml:"client_name"`
 		ChannelName     string `json:"channel_name" yaml:"channel_name"`
@@ -119,13 +165,24 @@ type ManagerConfiguration struct {
 		DefaultPresence      discord.UpdateStatus `json:"default_presence" yaml:"default_presence"`
 		Intents              int32                `json:"intents" yaml:"intents"`
 		ChunkGuildsOnStartup bool                 `json:"chunk_guilds_on_startup" yaml:"chunk_guilds_on_startup"`
+		// Transport compression of gateway connections. Either "zlib-stream" or "zstd-stream".
+		// Payloads are compressed individually if empty.
+		Compression GatewayCompression `json:"compression" yaml:"compression"`
+		// Encoding of gateway connections. Either "json" or "etf". Events are still published as JSON.
+		Encoding GatewayEncoding `json:"encoding" yaml:"encoding"`
 	} `json:"bot" yaml:"bot"`
 
 	Caching struct {
 		CacheUsers   bool `json:"cache_users" yaml:"cache_users"`
 		CacheMembers bool `json:"cache_members" yaml:"cache_members"`
 		StoreMutuals bool `json:"store_mutuals" yaml:"store_mutuals"`
-		// TODO: Flexible caching
+		// Tracks the invites of guilds to find the invite a member joined with.
+		// Requires the Manage Guild permission and fetches invites on every member join.
+		TrackInvites bool `json:"track_invites" yaml:"track_invites"`
+
+		Policy CachePolicy `json:"policy" yaml:"policy"`
+		// Cache policies for specific guilds, replacing the default policy.
+		Guilds map[discord.GuildID]CachePolicy `json:"guilds" yaml:"guilds"`
 	} `json:"caching" yaml:"caching"`
 
 	AutoStart    bool `json:"auto_start" yaml:"auto_start"`
@@ -167,7 +224,11 @@ func (sg *Sandwich) NewManager(configuration *ManagerConfiguration) (mg *Manager
 			csmap.WithSize[int32, *ShardGroup](0),
 		),
 
-		Client: NewClient(baseURL, configuration.Token),
+		handoffEvents: csmap.Create(
+			csmap.WithSize[string, handoffEvent](0),
+		),
+
+		Client: NewClient(sg.BaseURL(), configuration.Token),
 
 		UserID: &atomic.Int64{},
 
@@ -182,6 +243,10 @@ func (sg *Sandwich) NewManager(configuration *ManagerConfiguration) (mg *Manager
 		produceBlacklistMu: sync.RWMutex{},
 		produceBlacklist:   configuration.Events.ProduceBlacklist,
 
+		auditCorrelator: NewAuditCorrelator(),
+
+		inviteTracker: NewInviteTracker(),
+
 		metadataMu: sync.RWMutex{},
 		metadata: &sandwich_structs.SandwichMetadata{
 			Version:     VERSION,
@@ -192,6 +257,8 @@ func (sg *Sandwich) NewManager(configuration *ManagerConfiguration) (mg *Manager
 
 	mg.ctx, mg.cancel = context.WithCancel(sg.ctx)
 
+	mg.SetCachePolicies(configuration.Caching.Policy, configuration.Caching.Guilds)
+
 	return mg
 }
 
@@ -244,6 +311,20 @@ func (mg *Manager) Initialize(forceRestartProducers bool) error {
 		mg.ProducerClient = producerClient
 	}
 
+	err = mg.setupProducerRoutes(clientName, forceRestartProducers)
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to connect producers")
+
+		return fmt.Errorf("failed to connect producers: %w", err)
+	}
+
+	err = mg.setupSpool()
+	if err != nil {
+		mg.Logger.Error().Err(err).Msg("Failed to create spool")
+
+		return fmt.Errorf("failed to create spool: %w", err)
+	}
+
 	mg.gatewayMu.Lock()
 	mg.Gateway = gateway
 	mg.gatewayMu.Unlock()
@@ -275,6 +356,9 @@ func (mg *Manager) Open() error {
 
 	<-ready
 
+	// The shard count was just retrieved, so the next auto rescale check waits for the interval.
+	mg.lastAutoRescale.Store(time.Now())
+
 	return nil
 }
 
@@ -325,6 +409,19 @@ func (mg *Manager) AllReady() bool {
 	return isReady
 }
 
+// HasGuild returns whether a shard group of the manager has received the guild.
+func (mg *Manager) HasGuild(guildID discord.GuildID) bool {
+	var hasGuild bool
+
+	mg.ShardGroups.Range(func(shardGroupID int32, sg *ShardGroup) bool {
+		hasGuild = sg.Guilds.Has(guildID)
+
+		return hasGuild
+	})
+
+	return hasGuild
+}
+
 // ConsumerShardCount returns the number of shards from a consumer view
 //
 // If virtual shards is disabled, this will return the actual shard count.
@@ -378,7 +475,7 @@ func (mg *Manager) PublishEvent(ctx context.Context, eventType string, eventData
 	channelName := mg.Configuration.Messaging.ChannelName
 	mg.configurationMu.RUnlock()
 
-	err := mg.ProducerClient.Publish(
+	err := mg.publish(
 		ctx,
 		&sandwich_structs.SandwichPayload{
 			Type:     eventType,
@@ -406,6 +503,11 @@ func (mg *Manager) WaitForIdentify(shardID int32, shardCount int32) error {
 
 	mg.gatewayMu.RLock()
 	maxConcurrency := mg.Gateway.SessionStartLimit.MaxConcurrency
+	sessionStartLimit := sandwich_structs.IdentifySessionStartLimit{
+		Total:      mg.Gateway.SessionStartLimit.Total,
+		Remaining:  mg.Gateway.SessionStartLimit.Remaining,
+		ResetAfter: mg.Gateway.SessionStartLimit.ResetAfter,
+	}
 	mg.gatewayMu.RUnlock()
 
 	hash, err := quickHash(sha256.New(), token)
@@ -413,7 +515,21 @@ func (mg *Manager) WaitForIdentify(shardID int32, shardCount int32) error {
 		return err
 	}
 
-	if identifyURL == "" {
+	identifyPayload := sandwich_structs.IdentifyPayload{
+		ShardID:           shardID,
+		ShardCount:        shardCount,
+		Token:             token,
+		TokenHash:         hash,
+		MaxConcurrency:    maxConcurrency,
+		SessionStartLimit: &sessionStartLimit,
+	}
+
+	if identifyURL == "" && mg.Sandwich.identifyCoordinator != nil {
+		err = mg.Sandwich.identifyCoordinator.Wait(mg.ctx, identifyPayload)
+		if err != nil {
+			return fmt.Errorf("failed to wait for identify coordinator: %w", err)
+		}
+	} else if identifyURL == "" {
 		identifyBucketName := fmt.Sprintf(
 			"identify:%s:%d",
 			hash,
@@ -438,14 +554,6 @@ func (mg *Manager) WaitForIdentify(shardID int32, shardCount int32) error {
 			return fmt.Errorf("failed to create valid identify URL: %w", err)
 		}
 
-		identifyPayload := sandwich_structs.IdentifyPayload{
-			ShardID:        shardID,
-			ShardCount:     shardCount,
-			Token:          token,
-			TokenHash:      hash,
-			MaxConcurrency: maxConcurrency,
-		}
-
 		identifyPayloadBytes, err := sandwichjson.Marshal(identifyPayload)
 		if err != nil {
 			return fmt.Errorf("failed to encode identify payload: %w", err)
@@ -509,6 +617,10 @@ func (mg *Manager) Close() {
 		sg.Close()
 		return false
 	})
+
+	if mg.Sandwich.sessionsEnabled() {
+		mg.Sandwich.saveSessions()
+	}
 }
 
 // getInitialShardCount returns the initial shard count and ids to use.
@@ -37,6 +37,16 @@ type MQClient interface {
 	Close()
 }
 
+// MQQueueLength is implemented by producers that queue payloads before sending them,
+// allowing backpressure to be applied to shards.
+type MQQueueLength interface {
+	// QueueLength returns the number of payloads waiting to be sent to the slowest consumer.
+	QueueLength() int
+	// QueueCapacity returns the number of payloads that can wait for a consumer before they
+	// are dropped or the consumer is disconnected.
+	QueueCapacity() int
+}
+
 func NewMQClient(mqType string) (MQClient, error) {
 	switch mqType {
 	case "jetstream":
@@ -45,8 +55,16 @@ func NewMQClient(mqType string) (MQClient, error) {
 		return &KafkaMQClient{}, nil
 	case "redis":
 		return &RedisMQClient{}, nil
+	case "redis_streams":
+		return &RedisMQClient{Streams: true}, nil
 	case "websocket":
 		return &WebsocketClient{}, nil
+	case "grpc":
+		return &GRPCMQClient{}, nil
+	case "http":
+		return &HTTPMQClient{}, nil
+	case "amqp":
+		return &AMQPMQClient{}, nil
 	default:
 		return nil, fmt.Errorf("%s is not a valid MQClient", mqType)
 	}
@@ -75,7 +93,7 @@ func (sh *Shard) PublishEvent(ctx context.Context, packet *sandwich_structs.Sand
 		return fmt.Errorf("publishEvent RoutePayloadToConsumer: %w", err)
 	}
 
-	err = sh.Manager.ProducerClient.Publish(
+	err = sh.Manager.publish(
 		ctx,
 		packet,
 		channelName,
@@ -0,0 +1,247 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestAMQPRoutingKey(t *testing.T) {
+	guildID := discord.GuildID(123)
+
+	packet := &structs.SandwichPayload{
+		Metadata:                &structs.SandwichMetadata{Identifier: "bot"},
+		Type:                    "MESSAGE_CREATE",
+		EventDispatchIdentifier: &structs.EventDispatchIdentifier{GuildID: &guildID},
+	}
+
+	if key := AMQPRoutingKey("{identifier}.{guild_id}.{event_type}", packet); key != "bot.123.MESSAGE_CREATE" {
+		t.Fatalf("unexpected routing key %q", key)
+	}
+
+	packet.EventDispatchIdentifier.GloballyRouted = true
+
+	if key := AMQPRoutingKey("{guild_id}.{event_type}", packet); key != "global.MESSAGE_CREATE" {
+		t.Fatalf("unexpected routing key %q", key)
+	}
+}
+
+// fakeAMQPBroker records payloads published on its channels, which are confirmed by the test.
+type fakeAMQPBroker struct {
+	channels []*fakeAMQPChannel
+
+	mu sync.Mutex
+}
+
+func (broker *fakeAMQPBroker) dial() (io.Closer, amqpChannel, error) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	channel := &fakeAMQPChannel{}
+	broker.channels = append(broker.channels, channel)
+
+	return channel, channel, nil
+}
+
+// channel returns the channel opened at an index once it has been opened.
+func (broker *fakeAMQPBroker) channel(t *testing.T, index int) *fakeAMQPChannel {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		broker.mu.Lock()
+
+		var channel *fakeAMQPChannel
+		if len(broker.channels) > index {
+			channel = broker.channels[index]
+		}
+
+		broker.mu.Unlock()
+
+		if channel != nil {
+			return channel
+		}
+	}
+
+	t.Fatalf("expected channel %d to be opened", index)
+
+	return nil
+}
+
+type fakeAMQPChannel struct {
+	published []string
+	confirm   chan amqp.Confirmation
+	closed    chan *amqp.Error
+
+	mu sync.Mutex
+}
+
+func (channel *fakeAMQPChannel) PublishWithContext(_ context.Context, _, _ string, _, _ bool, msg amqp.Publishing) error {
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+
+	if channel.closed == nil {
+		return amqp.ErrClosed
+	}
+
+	channel.published = append(channel.published, msg.Type)
+
+	return nil
+}
+
+func (channel *fakeAMQPChannel) GetNextPublishSeqNo() uint64 {
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+
+	return uint64(len(channel.published) + 1)
+}
+
+func (channel *fakeAMQPChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	channel.confirm = confirm
+
+	return confirm
+}
+
+func (channel *fakeAMQPChannel) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	channel.closed = receiver
+
+	return receiver
+}
+
+// Close closes the channel as the broker would, closing the confirm and close notifications.
+func (channel *fakeAMQPChannel) Close() error {
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+
+	if channel.closed != nil {
+		close(channel.confirm)
+		channel.closed <- amqp.ErrClosed
+		close(channel.closed)
+		channel.closed = nil
+	}
+
+	return nil
+}
+
+func (channel *fakeAMQPChannel) Published() []string {
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+
+	return append([]string(nil), channel.published...)
+}
+
+func waitAMQPQueueLength(t *testing.T, mq *AMQPMQClient, expected int) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); mq.QueueLength() != expected && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if length := mq.QueueLength(); length != expected {
+		t.Fatalf("expected %d payloads waiting for a confirm, got %d", expected, length)
+	}
+}
+
+func TestAMQPMQClientConfirms(t *testing.T) {
+	broker := &fakeAMQPBroker{}
+	mq := &AMQPMQClient{dial: broker.dial}
+
+	err := mq.Connect(context.Background(), nil, "", map[string]interface{}{"Address": "amqp://fake", "MaxPendingConfirms": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mq.Close()
+
+	for _, eventType := range []string{"A", "B"} {
+		if err := mq.Publish(context.Background(), &structs.SandwichPayload{Type: eventType}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Publish blocks once the maximum number of payloads are waiting for a confirm.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := mq.Publish(ctx, &structs.SandwichPayload{Type: "C"}, ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected publish to wait for a confirm, got %v", err)
+	}
+
+	if length, capacity := mq.QueueLength(), mq.QueueCapacity(); length != 2 || capacity != 2 {
+		t.Fatalf("expected 2 of 2 payloads waiting for a confirm, got %d of %d", length, capacity)
+	}
+
+	channel := broker.channel(t, 0)
+
+	channel.confirm <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+
+	waitAMQPQueueLength(t, mq, 1)
+
+	// Rejected payloads are published again.
+	channel.confirm <- amqp.Confirmation{DeliveryTag: 2, Ack: false}
+
+	for deadline := time.Now().Add(5 * time.Second); len(channel.Published()) < 3 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if published := channel.Published(); len(published) != 3 || published[2] != "B" {
+		t.Fatalf("expected the rejected payload to be published again, got %v", published)
+	}
+
+	if length := mq.QueueLength(); length != 1 {
+		t.Fatalf("expected the rejected payload to wait for a confirm, got %d", length)
+	}
+
+	channel.confirm <- amqp.Confirmation{DeliveryTag: 3, Ack: true}
+
+	waitAMQPQueueLength(t, mq, 0)
+}
+
+func TestAMQPMQClientReconnect(t *testing.T) {
+	broker := &fakeAMQPBroker{}
+	mq := &AMQPMQClient{dial: broker.dial}
+
+	err := mq.Connect(context.Background(), nil, "", map[string]interface{}{"Address": "amqp://fake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mq.Close()
+
+	for _, eventType := range []string{"A", "B", "C"} {
+		if err := mq.Publish(context.Background(), &structs.SandwichPayload{Type: eventType}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := broker.channel(t, 0)
+	first.confirm <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+
+	waitAMQPQueueLength(t, mq, 2)
+
+	first.Close()
+
+	// Payloads that were not confirmed are published in order once the channel is reopened.
+	second := broker.channel(t, 1)
+
+	for deadline := time.Now().Add(5 * time.Second); len(second.Published()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if published := second.Published(); len(published) != 2 || published[0] != "A" || published[1] != "C" {
+		t.Fatalf("expected unconfirmed payloads to be published again, got %v", published)
+	}
+
+	if mq.IsClosed() {
+		t.Fatal("expected the producer to be reconnected")
+	}
+
+	second.confirm <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	second.confirm <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+
+	waitAMQPQueueLength(t, mq, 0)
+}
@@ -0,0 +1,328 @@
+package internal
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	MQClients = append(MQClients, "grpc")
+}
+
+const (
+	// Default number of payloads that can be queued for a subscriber before it is disconnected.
+	grpcSubscriberBufferSize = 10000
+
+	// Time subscribers have to finish sending when closing before their streams are cancelled.
+	grpcStopTimeout = 5 * time.Second
+)
+
+// GRPCMQClient serves events to consumers over a server-streaming gRPC call.
+type GRPCMQClient struct {
+	protobuf.UnimplementedProducerServer
+
+	server   *grpc.Server
+	serverMu sync.Mutex
+
+	subscribers map[*grpcSubscriber]struct{}
+
+	expectedToken string
+
+	bufferSize int
+
+	subscribersMu sync.RWMutex
+}
+
+// grpcSubscriber is a consumer subscribed to events. Payloads are queued and sent by the stream
+// of the subscriber, so a slow consumer does not block publishing.
+type grpcSubscriber struct {
+	shardRange *protobuf.ShardRange
+	eventTypes map[string]bool
+
+	payloads chan *protobuf.SandwichPayload
+
+	// Closed with the reason the subscriber was disconnected.
+	closed    chan error
+	closeOnce sync.Once
+}
+
+func (mq *GRPCMQClient) String() string {
+	return "grpc"
+}
+
+func (mq *GRPCMQClient) Channel() string {
+	return "grpc"
+}
+
+// Supported options:
+//
+// address (string): the address to listen on
+// expectedToken (string): the token consumers must send as the authorization metadata, if set
+// bufferSize (int): the number of payloads that can be queued for a consumer, defaults to 10000
+func (mq *GRPCMQClient) Connect(ctx context.Context, manager *Manager, clientName string, args map[string]interface{}) error {
+	var ok bool
+
+	var address string
+
+	if address, ok = GetEntry(args, "Address").(string); !ok {
+		return errors.New("grpcMQ connect: string type assertion failed for Address")
+	}
+
+	mq.expectedToken, _ = GetEntry(args, "ExpectedToken").(string)
+
+	mq.bufferSize = GetEntryInt(args, "BufferSize", grpcSubscriberBufferSize)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("grpcMQ listen: %w", err)
+	}
+
+	mq.Serve(listener)
+
+	return nil
+}
+
+// Serve starts serving subscribers on a listener.
+func (mq *GRPCMQClient) Serve(listener net.Listener) {
+	if mq.bufferSize <= 0 {
+		mq.bufferSize = grpcSubscriberBufferSize
+	}
+
+	mq.subscribersMu.Lock()
+	mq.subscribers = make(map[*grpcSubscriber]struct{})
+	mq.subscribersMu.Unlock()
+
+	server := grpc.NewServer()
+	protobuf.RegisterProducerServer(server, mq)
+
+	mq.serverMu.Lock()
+	mq.server = server
+	mq.serverMu.Unlock()
+
+	go server.Serve(listener)
+}
+
+// Subscribe streams payloads to a consumer until it disconnects or cannot keep up.
+func (mq *GRPCMQClient) Subscribe(req *protobuf.SubscribeRequest, stream protobuf.Producer_SubscribeServer) error {
+	if mq.expectedToken != "" {
+		md, _ := metadata.FromIncomingContext(stream.Context())
+
+		if authorization := md.Get("authorization"); len(authorization) == 0 ||
+			subtle.ConstantTimeCompare([]byte(authorization[0]), []byte(mq.expectedToken)) != 1 {
+			return status.Error(codes.Unauthenticated, "invalid token")
+		}
+	}
+
+	subscriber := &grpcSubscriber{
+		shardRange: req.ShardRange,
+		eventTypes: make(map[string]bool, len(req.EventTypes)),
+		payloads:   make(chan *protobuf.SandwichPayload, mq.bufferSize),
+		closed:     make(chan error, 1),
+	}
+
+	for _, eventType := range req.EventTypes {
+		subscriber.eventTypes[eventType] = true
+	}
+
+	mq.subscribersMu.Lock()
+	mq.subscribers[subscriber] = struct{}{}
+	mq.subscribersMu.Unlock()
+
+	defer func() {
+		mq.subscribersMu.Lock()
+		delete(mq.subscribers, subscriber)
+		mq.subscribersMu.Unlock()
+	}()
+
+	for {
+		select {
+		case payload := <-subscriber.payloads:
+			// Send blocks while the flow control window of the consumer is full.
+			if err := stream.Send(payload); err != nil {
+				return err
+			}
+		case err := <-subscriber.closed:
+			return err
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// wants returns if the subscriber should receive a payload.
+func (s *grpcSubscriber) wants(packet *structs.SandwichPayload) bool {
+	if len(s.eventTypes) > 0 && !s.eventTypes[packet.Type] {
+		return false
+	}
+
+	if s.shardRange == nil || packet.Metadata == nil ||
+		(packet.EventDispatchIdentifier != nil && packet.EventDispatchIdentifier.GloballyRouted) {
+		return true
+	}
+
+	shardID := packet.Metadata.Shard[1]
+
+	return shardID >= s.shardRange.Start && shardID <= s.shardRange.End
+}
+
+func (s *grpcSubscriber) close(err error) {
+	s.closeOnce.Do(func() {
+		s.closed <- err
+	})
+}
+
+func (mq *GRPCMQClient) Publish(ctx context.Context, packet *structs.SandwichPayload, channelName string) error {
+	var payload *protobuf.SandwichPayload
+
+	mq.subscribersMu.RLock()
+	defer mq.subscribersMu.RUnlock()
+
+	for subscriber := range mq.subscribers {
+		if !subscriber.wants(packet) {
+			continue
+		}
+
+		if payload == nil {
+			payload = GRPCPayload(packet)
+		}
+
+		select {
+		case subscriber.payloads <- payload:
+		default:
+			subscriber.close(status.Error(codes.ResourceExhausted, "consumer is too slow"))
+		}
+	}
+
+	return nil
+}
+
+// GRPCPayload converts a SandwichPayload to its protobuf representation.
+func GRPCPayload(packet *structs.SandwichPayload) *protobuf.SandwichPayload {
+	payload := &protobuf.SandwichPayload{
+		Type:     packet.Type,
+		Data:     packet.Data,
+		Sequence: packet.Sequence,
+		Op:       int32(packet.Op),
+	}
+
+	if packet.Metadata != nil {
+		payload.Metadata = &protobuf.SandwichMetadata{
+			Version:       packet.Metadata.Version,
+			Identifier:    packet.Metadata.Identifier,
+			Application:   packet.Metadata.Application,
+			ApplicationId: int64(packet.Metadata.ApplicationID),
+			ShardGroupId:  packet.Metadata.Shard[0],
+			ShardId:       packet.Metadata.Shard[1],
+			ShardCount:    packet.Metadata.Shard[2],
+		}
+	}
+
+	if len(packet.Trace) > 0 {
+		payload.Trace = make(map[string]int64, len(packet.Trace))
+
+		for key, value := range packet.Trace {
+			payload.Trace[key] = int64(value)
+		}
+	}
+
+	if len(packet.Extra) > 0 {
+		payload.Extra = make(map[string][]byte, len(packet.Extra))
+
+		for key, value := range packet.Extra {
+			payload.Extra[key] = value
+		}
+	}
+
+	if packet.EventDispatchIdentifier != nil {
+		payload.GloballyRouted = packet.EventDispatchIdentifier.GloballyRouted
+	}
+
+	return payload
+}
+
+// QueueLength returns the most payloads queued for a subscriber.
+func (mq *GRPCMQClient) QueueLength() (length int) {
+	mq.subscribersMu.RLock()
+	defer mq.subscribersMu.RUnlock()
+
+	for subscriber := range mq.subscribers {
+		length = max(length, len(subscriber.payloads))
+	}
+
+	return length
+}
+
+// QueueCapacity returns the number of payloads that can be queued for a subscriber.
+func (mq *GRPCMQClient) QueueCapacity() int {
+	return mq.bufferSize
+}
+
+func (mq *GRPCMQClient) IsClosed() bool {
+	mq.serverMu.Lock()
+	defer mq.serverMu.Unlock()
+
+	return mq.server == nil
+}
+
+func (mq *GRPCMQClient) CloseShard(shardID int32, reason MQCloseShardReason) {
+	if reason == MQCloseShardReasonGateway {
+		return // No-op if the reason is a gateway reconnect
+	}
+
+	mq.subscribersMu.RLock()
+	defer mq.subscribersMu.RUnlock()
+
+	for subscriber := range mq.subscribers {
+		if subscriber.shardRange == nil || (shardID >= subscriber.shardRange.Start && shardID <= subscriber.shardRange.End) {
+			subscriber.close(status.Error(codes.Unavailable, "shard closed"))
+		}
+	}
+}
+
+// Close disconnects subscribers and stops the server. Subscribers blocked sending to a consumer
+// that is not reading are cancelled after grpcStopTimeout.
+func (mq *GRPCMQClient) Close() {
+	mq.serverMu.Lock()
+	server := mq.server
+	mq.server = nil
+	mq.serverMu.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	mq.subscribersMu.RLock()
+	for subscriber := range mq.subscribers {
+		subscriber.close(status.Error(codes.Unavailable, "connection closed"))
+	}
+	mq.subscribersMu.RUnlock()
+
+	stopped := make(chan void)
+
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(grpcStopTimeout):
+		server.Stop()
+	}
+}
+
+func (mq *GRPCMQClient) StopSession(sessionID string) {
+	// No-op
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCMQClient(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+
+	mq := &GRPCMQClient{expectedToken: "token"}
+	mq.Serve(listener)
+	defer mq.Close()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := protobuf.NewProducerClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Consumers without the expected token are rejected.
+	stream, err := client.Subscribe(ctx, &protobuf.SubscribeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected unauthenticated, got %v", err)
+	}
+
+	stream, err = client.Subscribe(metadata.AppendToOutgoingContext(ctx, "authorization", "token"), &protobuf.SubscribeRequest{
+		ShardRange: &protobuf.ShardRange{Start: 0, End: 1},
+		EventTypes: []string{"MESSAGE_CREATE"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		mq.subscribersMu.RLock()
+		subscribers := len(mq.subscribers)
+		mq.subscribersMu.RUnlock()
+
+		if subscribers > 0 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	publish := func(eventType string, shardID int32) {
+		err := mq.Publish(ctx, &structs.SandwichPayload{
+			Metadata: &structs.SandwichMetadata{Identifier: "test", Shard: [3]int32{0, shardID, 4}},
+			Trace:    structs.SandwichTrace{"publish": discord.Int64(1)},
+			Type:     eventType,
+			Data:     []byte(`{"id":"1"}`),
+			Op:       discord.GatewayOpDispatch,
+		}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publish("MESSAGE_CREATE", 2)
+	publish("GUILD_CREATE", 1)
+	publish("MESSAGE_CREATE", 1)
+
+	payload, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Type != "MESSAGE_CREATE" || payload.Metadata.GetShardId() != 1 || payload.Metadata.GetShardCount() != 4 ||
+		payload.Trace["publish"] != 1 || string(payload.Data) != `{"id":"1"}` {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+
+	// Closing disconnects subscribers and can be repeated.
+	mq.Close()
+	mq.Close()
+
+	if !mq.IsClosed() {
+		t.Fatal("Expected client to be closed")
+	}
+
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected unavailable, got %v", err)
+	}
+}
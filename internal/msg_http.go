@@ -0,0 +1,532 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	MQClients = append(MQClients, "http")
+}
+
+const (
+	// Headers sent with each request. The signature is the hex HMAC-SHA256 of "{timestamp}.{body}".
+	HTTPSignatureHeader = "X-Sandwich-Signature"
+	HTTPTimestampHeader = "X-Sandwich-Timestamp"
+
+	httpDefaultBatchSize     = 1
+	httpDefaultBatchInterval = 100 * time.Millisecond
+	httpDefaultConcurrency   = 4
+	httpDefaultMaxRetries    = 5
+	httpDefaultRetryBackoff  = 500 * time.Millisecond
+	httpMaxRetryBackoff      = 30 * time.Second
+	httpDefaultQueueInterval = 5 * time.Second
+
+	// Number of batches that can wait for a worker before being queued on disk.
+	httpPendingBatches = 1000
+)
+
+// HTTPMQClient POSTs payloads to consumer URLs. Payloads are sent as a JSON object, or as a
+// JSON array when batching. Batches that cannot be delivered are queued on disk and retried.
+type HTTPMQClient struct {
+	Logger zerolog.Logger
+
+	HTTP *http.Client
+
+	ctx    context.Context
+	cancel func()
+
+	batch   [][]byte
+	batches chan [][]byte
+
+	urls   []string
+	secret []byte
+
+	// Directory batches are queued in while the endpoint is down. Disabled if empty.
+	queueDirectory string
+
+	batchSize     int
+	batchInterval time.Duration
+	concurrency   int
+	maxRetries    int
+	retryBackoff  time.Duration
+	queueInterval time.Duration
+
+	// Last sequence a batch was queued with, queued batches are named by it.
+	queueSequence int64
+
+	// Set once Close is called, Publish returns ErrProducerClosed afterwards.
+	closed bool
+
+	batchMu    sync.Mutex
+	queueMu    sync.Mutex
+	sequenceMu sync.Mutex
+	wg         sync.WaitGroup
+}
+
+func (mq *HTTPMQClient) String() string {
+	return "http"
+}
+
+func (mq *HTTPMQClient) Channel() string {
+	return "http"
+}
+
+// Supported options:
+//
+// urls ([]string or string): the URLs to POST payloads to, a string may be comma separated
+// secret (string): the secret used to sign requests, requests are not signed if empty
+// batchSize (int): the number of payloads sent in each request, defaults to 1
+// batchInterval (int): the maximum milliseconds to wait for a batch to fill, defaults to 100
+// concurrency (int): the maximum number of requests in flight, defaults to 4
+// maxRetries (int): the number of retries before a batch is queued on disk, defaults to 5
+// retryBackoff (int): the milliseconds before the first retry, doubled each retry, defaults to 500
+// queueDirectory (string): the directory to queue undelivered batches in, batches are dropped if empty
+// queueInterval (int): the milliseconds between retrying queued batches, defaults to 5000
+func (mq *HTTPMQClient) Connect(ctx context.Context, manager *Manager, clientName string, args map[string]interface{}) error {
+	mq.urls = GetEntryStrings(args, "URLs")
+	if len(mq.urls) == 0 {
+		mq.urls = GetEntryStrings(args, "URL")
+	}
+
+	if len(mq.urls) == 0 {
+		return errors.New("httpMQ connect: no URLs provided")
+	}
+
+	secret, _ := GetEntry(args, "Secret").(string)
+	mq.secret = []byte(secret)
+
+	mq.queueDirectory, _ = GetEntry(args, "QueueDirectory").(string)
+
+	mq.batchSize = GetEntryInt(args, "BatchSize", httpDefaultBatchSize)
+	mq.batchInterval = time.Duration(GetEntryInt(args, "BatchInterval", int(httpDefaultBatchInterval.Milliseconds()))) * time.Millisecond
+	mq.concurrency = GetEntryInt(args, "Concurrency", httpDefaultConcurrency)
+	mq.maxRetries = GetEntryInt(args, "MaxRetries", httpDefaultMaxRetries)
+	mq.retryBackoff = time.Duration(GetEntryInt(args, "RetryBackoff", int(httpDefaultRetryBackoff.Milliseconds()))) * time.Millisecond
+	mq.queueInterval = time.Duration(GetEntryInt(args, "QueueInterval", int(httpDefaultQueueInterval.Milliseconds()))) * time.Millisecond
+
+	if manager != nil {
+		mq.Logger = manager.Logger.With().Str("producer", "http").Logger()
+	}
+
+	return mq.Start(ctx)
+}
+
+// Start starts the workers that deliver batches.
+func (mq *HTTPMQClient) Start(ctx context.Context) error {
+	if mq.HTTP == nil {
+		mq.HTTP = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	mq.batchSize = max(mq.batchSize, 1)
+	mq.concurrency = max(mq.concurrency, 1)
+
+	if mq.queueDirectory != "" {
+		for i := range mq.urls {
+			directory := mq.urlQueueDirectory(i)
+
+			if err := os.MkdirAll(directory, 0o755); err != nil {
+				return fmt.Errorf("httpMQ connect: failed to create queue directory: %w", err)
+			}
+
+			entries, err := os.ReadDir(directory)
+			if err != nil {
+				return fmt.Errorf("httpMQ connect: failed to read queue directory: %w", err)
+			}
+
+			// Continue after batches queued before a restart so they are retried first.
+			for _, entry := range entries {
+				if sequence, ok := parseSpoolFileName(entry.Name()); ok && !entry.IsDir() {
+					mq.queueSequence = max(mq.queueSequence, sequence)
+				}
+			}
+		}
+	}
+
+	mq.ctx, mq.cancel = context.WithCancel(ctx)
+	mq.batches = make(chan [][]byte, httpPendingBatches)
+
+	for i := 0; i < mq.concurrency; i++ {
+		mq.wg.Add(1)
+
+		go mq.worker()
+	}
+
+	if mq.batchSize > 1 && mq.batchInterval > 0 {
+		mq.wg.Add(1)
+
+		go mq.flusher()
+	}
+
+	if mq.queueDirectory != "" && mq.queueInterval > 0 {
+		mq.wg.Add(1)
+
+		go mq.queueRetrier()
+	}
+
+	return nil
+}
+
+func (mq *HTTPMQClient) Publish(ctx context.Context, packet *structs.SandwichPayload, channelName string) error {
+	data, err := sandwichjson.Marshal(packet)
+	if err != nil {
+		return err
+	}
+
+	mq.batchMu.Lock()
+	if mq.closed {
+		mq.batchMu.Unlock()
+
+		return ErrProducerClosed
+	}
+
+	mq.batch = append(mq.batch, data)
+
+	var batch [][]byte
+	if len(mq.batch) >= mq.batchSize {
+		batch = mq.batch
+		mq.batch = nil
+	}
+	mq.batchMu.Unlock()
+
+	if batch != nil {
+		return mq.dispatch(batch)
+	}
+
+	return nil
+}
+
+// Flush sends the current batch, even if it is not full.
+func (mq *HTTPMQClient) Flush() error {
+	mq.batchMu.Lock()
+	batch := mq.batch
+	mq.batch = nil
+	mq.batchMu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return mq.dispatch(batch)
+}
+
+// dispatch passes a batch to the workers, or queues it on disk if all workers are busy or the
+// client has been closed.
+func (mq *HTTPMQClient) dispatch(batch [][]byte) error {
+	// The channel is closed by Close while holding batchMu, so it is never sent to once closed.
+	mq.batchMu.Lock()
+	if !mq.closed {
+		select {
+		case mq.batches <- batch:
+			mq.batchMu.Unlock()
+
+			return nil
+		default:
+		}
+	}
+	mq.batchMu.Unlock()
+
+	body := httpBody(batch)
+
+	for i := range mq.urls {
+		if err := mq.enqueue(i, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (mq *HTTPMQClient) flusher() {
+	defer mq.wg.Done()
+
+	ticker := time.NewTicker(mq.batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := mq.Flush(); err != nil {
+				mq.Logger.Error().Err(err).Msg("Failed to flush batch")
+			}
+		case <-mq.ctx.Done():
+			return
+		}
+	}
+}
+
+func (mq *HTTPMQClient) worker() {
+	defer mq.wg.Done()
+
+	for {
+		select {
+		case batch := <-mq.batches:
+			body := httpBody(batch)
+
+			for i, url := range mq.urls {
+				err := mq.deliver(url, body, mq.maxRetries)
+				if err == nil {
+					continue
+				}
+
+				mq.Logger.Warn().Err(err).Str("url", url).Int("payloads", len(batch)).Msg("Failed to deliver batch")
+
+				if err := mq.enqueue(i, body); err != nil {
+					mq.Logger.Error().Err(err).Str("url", url).Msg("Failed to queue batch")
+				}
+			}
+		case <-mq.ctx.Done():
+			return
+		}
+	}
+}
+
+// httpBody returns the request body of a batch. Single payloads are not wrapped in an array.
+func httpBody(batch [][]byte) []byte {
+	if len(batch) == 1 {
+		return batch[0]
+	}
+
+	return append(append([]byte{'['}, bytes.Join(batch, []byte{','})...), ']')
+}
+
+// Sign returns the signature of a request body sent at a unix timestamp.
+func (mq *HTTPMQClient) Sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, mq.secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver POSTs a body to a URL, retrying with exponential backoff.
+func (mq *HTTPMQClient) deliver(url string, body []byte, retries int) (err error) {
+	backoff := mq.retryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err = mq.post(url, body)
+		if err == nil || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-mq.ctx.Done():
+			return mq.ctx.Err()
+		}
+
+		backoff = min(backoff*2, httpMaxRetryBackoff)
+	}
+}
+
+func (mq *HTTPMQClient) post(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(mq.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sandwich/"+VERSION+" (github.com/WelcomerTeam/Sandwich-Daemon)")
+
+	if len(mq.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req.Header.Set(HTTPTimestampHeader, timestamp)
+		req.Header.Set(HTTPSignatureHeader, mq.Sign(timestamp, body))
+	}
+
+	res, err := mq.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to do HTTP request: %w", err)
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%w: %d", ErrUnexpectedStatus, res.StatusCode)
+	}
+
+	return nil
+}
+
+func (mq *HTTPMQClient) urlQueueDirectory(urlIndex int) string {
+	return filepath.Join(mq.queueDirectory, strconv.Itoa(urlIndex))
+}
+
+// enqueue writes an undelivered body to the queue directory of a URL.
+func (mq *HTTPMQClient) enqueue(urlIndex int, body []byte) error {
+	if mq.queueDirectory == "" {
+		return errors.New("httpMQ: no queue directory, dropping batch")
+	}
+
+	// Names must be unique and increasing even if batches are queued at the same time.
+	mq.sequenceMu.Lock()
+	mq.queueSequence = max(mq.queueSequence+1, time.Now().UnixNano())
+	sequence := mq.queueSequence
+	mq.sequenceMu.Unlock()
+
+	name := filepath.Join(mq.urlQueueDirectory(urlIndex), fmt.Sprintf("%020d.json", sequence))
+
+	// Write to a temporary file first so partially written batches are never retried.
+	err := os.WriteFile(name+".tmp", body, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write queued batch: %w", err)
+	}
+
+	err = os.Rename(name+".tmp", name)
+	if err != nil {
+		return fmt.Errorf("failed to write queued batch: %w", err)
+	}
+
+	return nil
+}
+
+func (mq *HTTPMQClient) queueRetrier() {
+	defer mq.wg.Done()
+
+	ticker := time.NewTicker(mq.queueInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			mq.RetryQueue()
+		case <-mq.ctx.Done():
+			return
+		}
+	}
+}
+
+// RetryQueue delivers queued batches in the order they were queued. Delivery to a URL stops at
+// the first failure. Batches are delivered by several workers, so they may not arrive in the
+// order they were published.
+func (mq *HTTPMQClient) RetryQueue() {
+	if mq.queueDirectory == "" {
+		return
+	}
+
+	mq.queueMu.Lock()
+	defer mq.queueMu.Unlock()
+
+	for i, url := range mq.urls {
+		directory := mq.urlQueueDirectory(i)
+
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			mq.Logger.Error().Err(err).Str("directory", directory).Msg("Failed to read queue directory")
+
+			continue
+		}
+
+		names := make([]string, 0, len(entries))
+
+		for _, entry := range entries {
+			if _, ok := parseSpoolFileName(entry.Name()); ok && !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := filepath.Join(directory, name)
+
+			body, err := os.ReadFile(path)
+			if err != nil {
+				mq.Logger.Error().Err(err).Str("path", path).Msg("Failed to read queued batch")
+
+				continue
+			}
+
+			if err := mq.deliver(url, body, 0); err != nil {
+				mq.Logger.Debug().Err(err).Str("url", url).Int("queued", len(names)).Msg("Endpoint is still unavailable")
+
+				break
+			}
+
+			if err := os.Remove(path); err != nil {
+				mq.Logger.Error().Err(err).Str("path", path).Msg("Failed to remove queued batch")
+			}
+		}
+	}
+}
+
+// QueueLength returns the number of payloads waiting for a worker.
+func (mq *HTTPMQClient) QueueLength() int {
+	return len(mq.batches) * mq.batchSize
+}
+
+// QueueCapacity returns the number of payloads that can wait for a worker before being queued on disk.
+func (mq *HTTPMQClient) QueueCapacity() int {
+	return cap(mq.batches) * mq.batchSize
+}
+
+func (mq *HTTPMQClient) IsClosed() bool {
+	mq.batchMu.Lock()
+	defer mq.batchMu.Unlock()
+
+	return mq.closed || mq.ctx == nil || mq.ctx.Err() != nil
+}
+
+func (mq *HTTPMQClient) CloseShard(shardID int32, reason MQCloseShardReason) {
+	// No-op
+}
+
+func (mq *HTTPMQClient) Close() {
+	if mq.cancel == nil {
+		return
+	}
+
+	// Batches that have not been sent are queued so they are delivered on the next start.
+	mq.batchMu.Lock()
+	if mq.closed {
+		mq.batchMu.Unlock()
+
+		return
+	}
+
+	mq.closed = true
+	batch := mq.batch
+	mq.batch = nil
+	mq.batchMu.Unlock()
+
+	mq.cancel()
+	mq.wg.Wait()
+
+	close(mq.batches)
+
+	for pending := range mq.batches {
+		batch = append(batch, pending...)
+	}
+
+	if len(batch) > 0 && mq.queueDirectory != "" {
+		body := httpBody(batch)
+
+		for i := range mq.urls {
+			if err := mq.enqueue(i, body); err != nil {
+				mq.Logger.Error().Err(err).Msg("Failed to queue batch")
+			}
+		}
+	}
+}
+
+func (mq *HTTPMQClient) StopSession(sessionID string) {
+	// No-op
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+func TestHTTPMQClient(t *testing.T) {
+	var (
+		bodiesMu sync.Mutex
+		bodies   [][]byte
+		failing  = true
+	)
+
+	mq := &HTTPMQClient{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if r.Header.Get(HTTPSignatureHeader) != mq.Sign(r.Header.Get(HTTPTimestampHeader), body) {
+			t.Errorf("invalid signature for body %s", body)
+		}
+
+		bodiesMu.Lock()
+		defer bodiesMu.Unlock()
+
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	queueDirectory := t.TempDir()
+
+	err := mq.Connect(context.Background(), nil, "", map[string]interface{}{
+		"urls":           server.URL,
+		"secret":         "secret",
+		"batchSize":      2,
+		"batchInterval":  0,
+		"maxRetries":     1,
+		"retryBackoff":   1,
+		"queueDirectory": queueDirectory,
+		"queueInterval":  0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mq.Close()
+
+	for _, eventType := range []string{"A", "B"} {
+		if err := mq.Publish(context.Background(), &structs.SandwichPayload{Type: eventType}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The batch cannot be delivered, so it is queued on disk.
+	var queued []os.DirEntry
+
+	for deadline := time.Now().Add(5 * time.Second); len(queued) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+
+		queued, _ = os.ReadDir(filepath.Join(queueDirectory, "0"))
+	}
+
+	if len(queued) != 1 {
+		t.Fatalf("expected 1 queued batch, got %d", len(queued))
+	}
+
+	bodiesMu.Lock()
+	failing = false
+	bodiesMu.Unlock()
+
+	mq.RetryQueue()
+
+	if queued, _ = os.ReadDir(filepath.Join(queueDirectory, "0")); len(queued) != 0 {
+		t.Fatalf("expected queue to be empty, got %d", len(queued))
+	}
+
+	bodiesMu.Lock()
+	defer bodiesMu.Unlock()
+
+	if len(bodies) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(bodies))
+	}
+
+	var payloads []structs.SandwichPayload
+
+	if err := sandwichjson.Unmarshal(bodies[0], &payloads); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payloads) != 2 || payloads[0].Type != "A" || payloads[1].Type != "B" {
+		t.Fatalf("unexpected batch %s", bodies[0])
+	}
+}
+
+func TestHTTPMQClientQueueOrder(t *testing.T) {
+	queueDirectory := t.TempDir()
+
+	mq := &HTTPMQClient{urls: []string{"http://localhost"}, queueDirectory: queueDirectory}
+
+	if err := os.MkdirAll(mq.urlQueueDirectory(0), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{"1", "2", "3"} {
+		if err := mq.enqueue(0, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queued, err := os.ReadDir(filepath.Join(queueDirectory, "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Batches queued at the same time must not overwrite each other.
+	if len(queued) != 3 {
+		t.Fatalf("expected 3 queued batches, got %d", len(queued))
+	}
+
+	for i, entry := range queued {
+		body, _ := os.ReadFile(filepath.Join(queueDirectory, "0", entry.Name()))
+
+		if string(body) != string(rune('1'+i)) {
+			t.Fatalf("expected batch %d to be %c, got %s", i, '1'+i, body)
+		}
+	}
+}
+
+func TestHTTPMQClientClose(t *testing.T) {
+	mq := &HTTPMQClient{urls: []string{"http://localhost"}}
+
+	if err := mq.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mq.Close()
+
+	// Closing again and publishing after close must not send on the closed channel.
+	mq.Close()
+
+	if err := mq.Publish(context.Background(), &structs.SandwichPayload{Type: "A"}, ""); !errors.Is(err, ErrProducerClosed) {
+		t.Fatalf("expected ErrProducerClosed, got %v", err)
+	}
+
+	if err := mq.dispatch([][]byte{[]byte("{}")}); err == nil {
+		t.Fatal("expected dispatch without a queue directory to fail once closed")
+	}
+
+	if !mq.IsClosed() {
+		t.Fatal("expected client to be closed")
+	}
+}
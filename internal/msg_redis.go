@@ -12,16 +12,30 @@ import (
 )
 
 func init() {
-	MQClients = append(MQClients, "redis")
+	MQClients = append(MQClients, "redis", "redis_streams")
 }
 
+// Default approximate maximum length of each stream in redis_streams mode.
+const redisStreamDefaultMaxLen = 100000
+
 type RedisMQClient struct {
 	redisClient *redis.Client
 
 	channel string
+
+	// Streams adds payloads to streams with XADD instead of publishing them, so events are
+	// kept while consumers are down.
+	Streams bool
+
+	streamMaxLen   int64
+	streamPerShard bool
 }
 
 func (redisMQ *RedisMQClient) String() string {
+	if redisMQ.Streams {
+		return "redis_streams"
+	}
+
 	return "redis"
 }
 
@@ -29,6 +43,16 @@ func (redisMQ *RedisMQClient) Channel() string {
 	return redisMQ.channel
 }
 
+// Supported options:
+//
+// address (string): the address of the redis server
+// password (string): the password of the redis server
+// db (string): the database to use, defaults to 0
+//
+// In redis_streams mode:
+//
+// maxLen (int): the approximate maximum length of each stream, defaults to 100000
+// streamPerShard (bool): add payloads to a stream per shard, named {channel}:{shard}, instead of per channel
 func (redisMQ *RedisMQClient) Connect(ctx context.Context, manager *Manager, clientName string, args map[string]interface{}) error {
 	var ok bool
 
@@ -47,7 +71,7 @@ func (redisMQ *RedisMQClient) Connect(ctx context.Context, manager *Manager, cli
 	var db int
 	var err error
 
-	if dbStr, ok := GetEntry(args, "DB").(string); !ok {
+	if dbStr, ok := GetEntry(args, "DB").(string); ok && dbStr != "" {
 		db, err = strconv.Atoi(dbStr)
 		if err != nil {
 			return fmt.Errorf("redisMQ connect db atoi: %w", err)
@@ -60,6 +84,15 @@ func (redisMQ *RedisMQClient) Connect(ctx context.Context, manager *Manager, cli
 		DB:       db,
 	})
 
+	redisMQ.streamMaxLen = int64(GetEntryInt(args, "MaxLen", redisStreamDefaultMaxLen))
+
+	switch streamPerShard := GetEntry(args, "StreamPerShard").(type) {
+	case bool:
+		redisMQ.streamPerShard = streamPerShard
+	case string:
+		redisMQ.streamPerShard, _ = strconv.ParseBool(streamPerShard)
+	}
+
 	err = redisMQ.redisClient.Ping(ctx).Err()
 	if err != nil {
 		return fmt.Errorf("redisMQ connect ping: %w", err)
@@ -75,6 +108,16 @@ func (redisMQ *RedisMQClient) Publish(ctx context.Context, packet *structs.Sandw
 		return err
 	}
 
+	if redisMQ.Streams {
+		return redisMQ.redisClient.XAdd(ctx, &redis.XAddArgs{
+			Stream: redisMQ.StreamName(packet, channelName),
+			MaxLen: redisMQ.streamMaxLen,
+			Approx: true,
+			ID:     "*",
+			Values: RedisStreamValues(packet, data),
+		}).Err()
+	}
+
 	return redisMQ.redisClient.Publish(
 		ctx,
 		channelName,
@@ -82,6 +125,34 @@ func (redisMQ *RedisMQClient) Publish(ctx context.Context, packet *structs.Sandw
 	).Err()
 }
 
+// StreamName returns the stream a payload is added to.
+func (redisMQ *RedisMQClient) StreamName(packet *structs.SandwichPayload, channelName string) string {
+	if redisMQ.streamPerShard && packet.Metadata != nil {
+		return channelName + ":" + strconv.Itoa(int(packet.Metadata.Shard[1]))
+	}
+
+	return channelName
+}
+
+// RedisStreamValues returns the fields of a stream entry. Fields consumers may filter on are
+// stored alongside the payload, the guild ID is omitted for events without a guild.
+func RedisStreamValues(packet *structs.SandwichPayload, data []byte) []interface{} {
+	values := []interface{}{"type", packet.Type}
+
+	if packet.EventDispatchIdentifier != nil && packet.EventDispatchIdentifier.GuildID != nil {
+		values = append(values, "guild_id", strconv.FormatInt(int64(*packet.EventDispatchIdentifier.GuildID), 10))
+	}
+
+	if packet.Metadata != nil {
+		values = append(values,
+			"identifier", packet.Metadata.Identifier,
+			"shard", strconv.Itoa(int(packet.Metadata.Shard[1])),
+		)
+	}
+
+	return append(values, "data", data)
+}
+
 func (redisMQ *RedisMQClient) IsClosed() bool {
 	return redisMQ.redisClient == nil
 }
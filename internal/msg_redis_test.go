@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisMQClientStreams(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	mq, err := NewMQClient("redis_streams")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mq.Connect(context.Background(), nil, "", map[string]interface{}{
+		"address":        server.Addr(),
+		"password":       "",
+		"maxLen":         2,
+		"streamPerShard": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mq.Close()
+
+	guildID := discord.GuildID(123)
+
+	for _, eventType := range []string{"A", "B", "C"} {
+		err = mq.Publish(context.Background(), &structs.SandwichPayload{
+			Metadata:                &structs.SandwichMetadata{Identifier: "bot", Shard: [3]int32{0, 1, 2}},
+			Type:                    eventType,
+			EventDispatchIdentifier: &structs.EventDispatchIdentifier{GuildID: &guildID},
+		}, "sandwich")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	entries, err := client.XRange(context.Background(), "sandwich:1", "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// miniredis trims approximate lengths exactly, so only the last two entries are kept.
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	values := entries[1].Values
+	if values["type"] != "C" || values["guild_id"] != "123" || values["shard"] != "1" || values["identifier"] != "bot" {
+		t.Fatalf("unexpected entry values %v", values)
+	}
+}
@@ -1,6 +1,9 @@
 package internal
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 // MQClients lists all current mqclients we have available.
 var MQClients = []string{}
@@ -16,3 +19,44 @@ func GetEntry(m map[string]interface{}, key string) interface{} {
 
 	return nil
 }
+
+// GetEntryInt returns an integer entry from a map, accepting numbers and numeric strings.
+// Returns the default value if the entry is missing or not a number.
+func GetEntryInt(m map[string]interface{}, key string, defaultValue int) int {
+	switch value := GetEntry(m, key).(type) {
+	case int:
+		return value
+	case int64:
+		return int(value)
+	case float64:
+		return int(value)
+	case string:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+
+	return defaultValue
+}
+
+// GetEntryStrings returns a list of strings from a map, accepting a list or a comma separated string.
+func GetEntryStrings(m map[string]interface{}, key string) (values []string) {
+	switch value := GetEntry(m, key).(type) {
+	case []string:
+		return value
+	case []interface{}:
+		for _, v := range value {
+			if s, ok := v.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+	case string:
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+
+	return values
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -109,6 +110,7 @@ type subscriber struct {
 	shard             [2]int32
 	seq               int32
 	meta              subscriberStatusMeta
+	encoding          GatewayEncoding // Requested with the encoding query param, defaults to json
 }
 
 // newChatServer constructs a chatServer with the defaults.
@@ -189,7 +191,7 @@ func (s *subscriber) dispatchInitial() error {
 	var guildIdShardIdMap = make(map[discord.GuildID]int32)
 
 	if !s.cs.quickStart {
-		s.cs.manager.Sandwich.State.Guilds.Range(func(id discord.GuildID, _ discord.Guild) bool {
+		for _, id := range s.cs.manager.Sandwich.State.GetAllGuildIDs() {
 			shardId := int32(s.cs.manager.GetShardIdOfGuild(id, s.cs.manager.ConsumerShardCount()))
 			guildIdShardIdMap[id] = shardId // We need this when dispatching guilds
 			if shardId == s.shard[0] {
@@ -198,8 +200,7 @@ func (s *subscriber) dispatchInitial() error {
 					Unavailable: false,
 				})
 			}
-			return false
-		})
+		}
 	}
 
 	// First send READY event with our initial state
@@ -239,7 +240,8 @@ func (s *subscriber) dispatchInitial() error {
 
 	// Next dispatch guilds
 	if !s.cs.quickStart {
-		s.cs.manager.Sandwich.State.Guilds.Range(func(id discord.GuildID, _ discord.Guild) bool {
+	dispatchGuilds:
+		for _, id := range s.cs.manager.Sandwich.State.GetAllGuildIDs() {
 			shardId, ok := guildIdShardIdMap[id]
 
 			if !ok {
@@ -248,36 +250,42 @@ func (s *subscriber) dispatchInitial() error {
 			}
 
 			if shardId != s.shard[0] {
-				return false // Skip to next guild if the shard id is not the same
+				continue // Skip to next guild if the shard id is not the same
 			}
 
 			guild, ok := s.cs.manager.Sandwich.State.GetGuild(id)
 
 			if !ok {
 				s.cs.manager.Logger.Error().Msgf("[WS] Failed to get guild: %d", id)
-				return false
+				continue
 			}
 
 			serializedGuild, err := sandwichjson.Marshal(guild)
 
 			if err != nil {
 				s.cs.manager.Logger.Error().Msgf("[WS] Failed to marshal guild: %s [shard %d]", err.Error(), s.shard[0])
-				return false
+				continue
 			}
 
-			s.writeNormal <- structs.SandwichPayload{
+			payload := structs.SandwichPayload{
 				Op:   discord.GatewayOpDispatch,
 				Data: serializedGuild,
 				Type: "GUILD_CREATE",
 			}
 
+			// Guilds loaded from a snapshot may be out of date until discord sends them again.
+			if s.cs.manager.Sandwich.State.IsGuildStale(id) {
+				payload.Extra = map[string]json.RawMessage{"stale": json.RawMessage("true")}
+			}
+
+			s.writeNormal <- payload
+
 			select {
 			case <-s.context.Done():
-				return true
+				break dispatchGuilds
 			default:
-				return false
 			}
-		})
+		}
 	}
 
 	s.cs.manager.Logger.Info().Msgf("[WS] Shard %d (initial state dispatched successfully)", s.shard[0])
@@ -479,12 +487,22 @@ func (s *subscriber) readMessages() {
 		case <-s.context.Done():
 			return
 		default:
-			_, ior, err := s.c.Read(s.context)
+			messageType, ior, err := s.c.Read(s.context)
 
 			if err != nil {
 				return
 			}
 
+			if s.encoding == GatewayEncodingETF && messageType == websocket.MessageBinary {
+				ior, err = DecodeETF(ior)
+
+				if err != nil {
+					s.cs.manager.Logger.Error().Msgf("[WS] Failed to decode packet: %s", err.Error())
+					s.cs.invalidSession(s, "failed to decode packet: "+err.Error(), true)
+					return
+				}
+			}
+
 			var payload structs.SandwichPayload
 
 			err = sandwichjson.Unmarshal(ior, &payload)
@@ -593,7 +611,7 @@ func (s *subscriber) writeMessages() {
 		select {
 		// Case 1: Done is closed, try closing the connection and quitting
 		case <-s.context.Done():
-			s.c.Write(s.context, websocket.MessageText, []byte(`{"op":9,"d":true}`))
+			s.write([]byte(`{"op":9,"d":true}`))
 
 			err := s.c.Close(invalidSessionOpCode, string(resumableInvalidSession))
 
@@ -618,7 +636,7 @@ func (s *subscriber) writeMessages() {
 				continue
 			}
 
-			err = s.c.Write(s.context, websocket.MessageText, serializedMessage)
+			err = s.write(serializedMessage)
 
 			if err != nil {
 				s.cs.manager.Logger.Error().Msgf("[WS] Failed to write message [serialized]: %s", err.Error())
@@ -627,7 +645,7 @@ func (s *subscriber) writeMessages() {
 			}
 		// Case 3: Optimized write bytes
 		case msg := <-s.writeBytes:
-			err := s.c.Write(s.context, websocket.MessageText, msg)
+			err := s.write(msg)
 
 			if err != nil {
 				s.cs.manager.Logger.Error().Msgf("[WS] Failed to write message [rawBytes]: %s", err.Error())
@@ -636,7 +654,7 @@ func (s *subscriber) writeMessages() {
 			}
 		// Case 4: Heartbeat
 		case <-s.writeHeartbeat:
-			err := s.c.Write(s.context, websocket.MessageText, heartbeatAck)
+			err := s.write(heartbeatAck)
 
 			if err != nil {
 				s.cs.manager.Logger.Error().Msgf("[WS] Failed to write heartbeat: %s", err.Error())
@@ -653,6 +671,20 @@ func (s *subscriber) writeMessages() {
 	}
 }
 
+// write writes a JSON message in the encoding of the subscriber.
+func (s *subscriber) write(msg []byte) error {
+	if s.encoding != GatewayEncodingETF {
+		return s.c.Write(s.context, websocket.MessageText, msg)
+	}
+
+	encoded, err := EncodeETF(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	return s.c.Write(s.context, websocket.MessageBinary, encoded)
+}
+
 // subscribe subscribes the given WebSocket to all broadcast messages.
 // It creates a subscriber with a buffered msgs chan to give some room to slower
 // connections and then registers the subscriber. It then listens for all messages
@@ -678,6 +710,16 @@ func (cs *chatServer) subscribe(ctx context.Context, w http.ResponseWriter, r *h
 		writeBytes:        make(chan []byte, cs.subscriberMessageBuffer),
 		writeHeartbeat:    make(chan void, cs.subscriberMessageBuffer),
 		meta:              newSubscriberStatusMeta(),
+		encoding:          GatewayEncoding(r.URL.Query().Get("encoding")),
+	}
+
+	if s.encoding == "" {
+		s.encoding = GatewayEncodingJSON
+	}
+
+	if !s.encoding.IsValid() {
+		http.Error(w, "{\"error\":\"Invalid encoding\"}", http.StatusBadRequest)
+		return fmt.Errorf("invalid encoding: %s", s.encoding)
 	}
 
 	// Create cancellable ctx
@@ -943,6 +985,35 @@ func (mq *WebsocketClient) Publish(ctx context.Context, packet *structs.Sandwich
 	return nil
 }
 
+// QueueLength returns the most payloads queued for a subscriber.
+func (mq *WebsocketClient) QueueLength() (length int) {
+	cs := mq.cs
+	if cs == nil {
+		return 0
+	}
+
+	cs.subscribersMu.RLock()
+	defer cs.subscribersMu.RUnlock()
+
+	for _, shardSubs := range cs.subscribers {
+		for _, s := range shardSubs {
+			length = max(length, len(s.writeNormal))
+		}
+	}
+
+	return length
+}
+
+// QueueCapacity returns the number of payloads that can be queued for a subscriber.
+func (mq *WebsocketClient) QueueCapacity() int {
+	cs := mq.cs
+	if cs == nil {
+		return 0
+	}
+
+	return cs.subscriberMessageBuffer
+}
+
 func (mq *WebsocketClient) IsClosed() bool {
 	return mq.cs == nil
 }
@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	gotils_strings "github.com/savsgio/gotils/strings"
+)
+
+// ProducerRouteConfiguration represents a producer events are routed to in addition to the
+// sandwich producer.
+type ProducerRouteConfiguration struct {
+	// Unique name of the route within the manager.
+	Name string `json:"name" yaml:"name"`
+
+	Type          string                 `json:"type" yaml:"type"`
+	Configuration map[string]interface{} `json:"configuration" yaml:"configuration"`
+
+	// Channel events are published to. Defaults to the messaging channel name.
+	ChannelName string `json:"channel_name" yaml:"channel_name"`
+
+	Filter ProducerFilter `json:"filter" yaml:"filter"`
+}
+
+// ProducerFilter selects the events routed to a producer. Empty lists match everything.
+type ProducerFilter struct {
+	Events        []string            `json:"events" yaml:"events"`
+	ExcludeEvents []string            `json:"exclude_events" yaml:"exclude_events"`
+	Guilds        []discord.GuildID   `json:"guilds" yaml:"guilds"`
+	ExcludeGuilds []discord.GuildID   `json:"exclude_guilds" yaml:"exclude_guilds"`
+	Ops           []discord.GatewayOp `json:"ops" yaml:"ops"`
+}
+
+// Matches returns if a payload passes the filter. Guild lists only apply to payloads with a guild,
+// so an allowlist of guilds does not exclude DMs or globally routed events.
+func (pf ProducerFilter) Matches(packet *sandwich_structs.SandwichPayload) bool {
+	if len(pf.Events) > 0 && !gotils_strings.Include(pf.Events, packet.Type) {
+		return false
+	}
+
+	if gotils_strings.Include(pf.ExcludeEvents, packet.Type) {
+		return false
+	}
+
+	if len(pf.Ops) > 0 && !includesOp(pf.Ops, packet.Op) {
+		return false
+	}
+
+	if packet.EventDispatchIdentifier == nil || packet.EventDispatchIdentifier.GuildID == nil {
+		return true
+	}
+
+	guildID := *packet.EventDispatchIdentifier.GuildID
+
+	if len(pf.Guilds) > 0 && !includesGuildID(pf.Guilds, guildID) {
+		return false
+	}
+
+	return !includesGuildID(pf.ExcludeGuilds, guildID)
+}
+
+func includesOp(ops []discord.GatewayOp, op discord.GatewayOp) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func includesGuildID(guildIDs []discord.GuildID, guildID discord.GuildID) bool {
+	for _, g := range guildIDs {
+		if g == guildID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Number of payloads that can wait to be published to a producer route before new ones are dropped.
+const producerRouteQueueSize = 1000
+
+// producerRoute is a connected producer and the configuration it was created from. Payloads are
+// published by a worker per route, so slow routes do not hold up shards or other producers.
+type producerRoute struct {
+	configuration ProducerRouteConfiguration
+	client        MQClient
+
+	queue chan producerRoutePayload
+	done  chan void
+
+	// Held while queueing payloads, so the queue is not closed while a payload is sent to it.
+	mu     sync.RWMutex
+	closed bool
+}
+
+type producerRoutePayload struct {
+	packet      *sandwich_structs.SandwichPayload
+	channelName string
+}
+
+// startProducerRoute starts the worker publishing the payloads of a route.
+func (mg *Manager) startProducerRoute(configuration ProducerRouteConfiguration, client MQClient) *producerRoute {
+	route := &producerRoute{
+		configuration: configuration,
+		client:        client,
+		queue:         make(chan producerRoutePayload, producerRouteQueueSize),
+		done:          make(chan void),
+	}
+
+	go func() {
+		defer close(route.done)
+
+		for payload := range route.queue {
+			err := route.client.Publish(mg.ctx, payload.packet, payload.channelName)
+			if err != nil {
+				mg.Logger.Error().Err(err).Str("producer", route.configuration.Name).Str("type", payload.packet.Type).Msg("Failed to publish event to producer")
+			}
+		}
+	}()
+
+	return route
+}
+
+// enqueue queues a payload to be published. Returns false if the route is closed or its queue is full.
+func (route *producerRoute) enqueue(packet *sandwich_structs.SandwichPayload, channelName string) bool {
+	route.mu.RLock()
+	defer route.mu.RUnlock()
+
+	if route.closed {
+		return false
+	}
+
+	select {
+	case route.queue <- producerRoutePayload{packet: packet, channelName: channelName}:
+		return true
+	default:
+		return false
+	}
+}
+
+// close publishes the payloads already queued and closes the producer.
+func (route *producerRoute) close() {
+	route.mu.Lock()
+
+	if route.closed {
+		route.mu.Unlock()
+
+		return
+	}
+
+	route.closed = true
+	close(route.queue)
+
+	route.mu.Unlock()
+
+	<-route.done
+
+	route.client.Close()
+}
+
+// setupProducerRoutes connects the producers of the manager configuration. Routes whose configuration
+// is unchanged keep their connection unless forceRestart is set, routes that were removed are closed.
+func (mg *Manager) setupProducerRoutes(clientName string, forceRestart bool) error {
+	mg.configurationMu.RLock()
+	configurations := mg.Configuration.Producers
+	mg.configurationMu.RUnlock()
+
+	mg.producerRoutesMu.RLock()
+	existing := make(map[string]*producerRoute, len(mg.producerRoutes))
+
+	for _, route := range mg.producerRoutes {
+		existing[route.configuration.Name] = route
+	}
+	mg.producerRoutesMu.RUnlock()
+
+	routes := make([]*producerRoute, 0, len(configurations))
+	kept := make(map[string]bool, len(configurations))
+
+	var created []MQClient
+
+	closeCreated := func() {
+		for _, client := range created {
+			client.Close()
+		}
+	}
+
+	for _, configuration := range configurations {
+		if configuration.Name == "" {
+			closeCreated()
+
+			return fmt.Errorf("%w: producer is missing a name", ErrInvalidProducerRoute)
+		}
+
+		if kept[configuration.Name] {
+			closeCreated()
+
+			return fmt.Errorf("%w: duplicate producer %s", ErrInvalidProducerRoute, configuration.Name)
+		}
+
+		kept[configuration.Name] = true
+
+		if route, ok := existing[configuration.Name]; ok && !forceRestart && !route.client.IsClosed() &&
+			reflect.DeepEqual(route.configuration, configuration) {
+			routes = append(routes, route)
+
+			continue
+		}
+
+		client, err := NewMQClient(configuration.Type)
+		if err != nil {
+			closeCreated()
+
+			return fmt.Errorf("%w: %w", ErrInvalidProducerRoute, err)
+		}
+
+		err = client.Connect(mg.ctx, mg, clientName, configuration.Configuration)
+		if err != nil {
+			closeCreated()
+
+			return fmt.Errorf("failed to connect to producer %s: %w", configuration.Name, err)
+		}
+
+		mg.Logger.Info().Str("producer", configuration.Name).Str("type", configuration.Type).Msg("Connected producer")
+
+		created = append(created, client)
+		routes = append(routes, mg.startProducerRoute(configuration, client))
+	}
+
+	mg.producerRoutesMu.Lock()
+	mg.producerRoutes = routes
+	mg.producerRoutesMu.Unlock()
+
+	// Close routes that were removed or replaced by a new connection, once payloads that were already
+	// queued have been published.
+	for _, route := range existing {
+		if !slices.Contains(routes, route) {
+			route.close()
+		}
+	}
+
+	return nil
+}
+
+// routePayload queues a payload to every producer route whose filter it matches. Payloads are dropped
+// if the queue of a route is full.
+func (mg *Manager) routePayload(packet *sandwich_structs.SandwichPayload, channelName string) {
+	mg.producerRoutesMu.RLock()
+	routes := mg.producerRoutes
+	mg.producerRoutesMu.RUnlock()
+
+	for _, route := range routes {
+		if !route.configuration.Filter.Matches(packet) {
+			continue
+		}
+
+		routeChannelName := channelName
+		if route.configuration.ChannelName != "" {
+			routeChannelName = route.configuration.ChannelName
+		}
+
+		if !route.enqueue(packet, routeChannelName) {
+			sandwichProducerRouteDropped.WithLabelValues(mg.Identifier.Load(), route.configuration.Name).Inc()
+
+			mg.Logger.Debug().Str("producer", route.configuration.Name).Str("type", packet.Type).Msg("Producer queue is full, dropping event")
+		}
+	}
+}
+
+// producerClients returns the sandwich producer and the producers of all routes.
+func (mg *Manager) producerClients() []MQClient {
+	mg.producerRoutesMu.RLock()
+	defer mg.producerRoutesMu.RUnlock()
+
+	clients := make([]MQClient, 0, len(mg.producerRoutes)+1)
+
+	if mg.ProducerClient != nil {
+		clients = append(clients, mg.ProducerClient)
+	}
+
+	for _, route := range mg.producerRoutes {
+		clients = append(clients, route.client)
+	}
+
+	return clients
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"go.uber.org/atomic"
+)
+
+func TestProducerRoutes(t *testing.T) {
+	producer := &testMQClient{}
+	moderation := &testMQClient{}
+	archive := &testMQClient{}
+
+	mg := &Manager{
+		ctx:              context.Background(),
+		Identifier:       atomic.NewString("sandwich"),
+		ProducerClient:   producer,
+		Configuration:    &ManagerConfiguration{},
+		produceBlacklist: []string{discord.DiscordEventMessageCreate},
+	}
+
+	mg.producerRoutes = []*producerRoute{
+		mg.startProducerRoute(ProducerRouteConfiguration{
+			Name:        "moderation",
+			ChannelName: "moderation",
+			Filter: ProducerFilter{
+				Events:        []string{discord.DiscordEventGuildBanAdd, discord.DiscordEventGuildMemberRemove},
+				ExcludeGuilds: []discord.GuildID{2},
+			},
+		}, moderation),
+		mg.startProducerRoute(ProducerRouteConfiguration{Name: "archive"}, archive),
+	}
+
+	guildID := discord.GuildID(1)
+	excludedGuildID := discord.GuildID(2)
+
+	for _, packet := range []*structs.SandwichPayload{
+		{Type: discord.DiscordEventGuildBanAdd, EventDispatchIdentifier: &structs.EventDispatchIdentifier{GuildID: &guildID}},
+		{Type: discord.DiscordEventGuildMemberRemove, EventDispatchIdentifier: &structs.EventDispatchIdentifier{GuildID: &excludedGuildID}},
+		{Type: discord.DiscordEventMessageCreate, EventDispatchIdentifier: &structs.EventDispatchIdentifier{GuildID: &guildID}},
+	} {
+		if err := mg.publish(context.Background(), packet, "sandwich"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Closing a route publishes the payloads it has queued.
+	for _, route := range mg.producerRoutes {
+		route.close()
+	}
+
+	assertPublished := func(name string, mq *testMQClient, expected ...string) {
+		t.Helper()
+
+		if len(mq.published) != len(expected) {
+			t.Fatalf("expected %s to receive %v, got %v", name, expected, mq.published)
+		}
+
+		for i := range expected {
+			if mq.published[i] != expected[i] {
+				t.Fatalf("expected %s to receive %v, got %v", name, expected, mq.published)
+			}
+		}
+	}
+
+	// The produce blacklist applies to every producer.
+	assertPublished("producer", producer,
+		"sandwich:"+discord.DiscordEventGuildBanAdd,
+		"sandwich:"+discord.DiscordEventGuildMemberRemove,
+	)
+	assertPublished("moderation", moderation,
+		"moderation:"+discord.DiscordEventGuildBanAdd,
+	)
+	assertPublished("archive", archive,
+		"sandwich:"+discord.DiscordEventGuildBanAdd,
+		"sandwich:"+discord.DiscordEventGuildMemberRemove,
+	)
+}
+
+type blockingMQClient struct {
+	testMQClient
+
+	unblock chan void
+}
+
+func (mq *blockingMQClient) Publish(ctx context.Context, packet *structs.SandwichPayload, channel string) error {
+	<-mq.unblock
+
+	return mq.testMQClient.Publish(ctx, packet, channel)
+}
+
+func TestProducerRouteSlowProducer(t *testing.T) {
+	slow := &blockingMQClient{unblock: make(chan void)}
+
+	mg := &Manager{
+		ctx:            context.Background(),
+		Identifier:     atomic.NewString("sandwich"),
+		ProducerClient: &testMQClient{},
+		Configuration:  &ManagerConfiguration{},
+	}
+
+	route := mg.startProducerRoute(ProducerRouteConfiguration{Name: "slow"}, slow)
+	mg.producerRoutes = []*producerRoute{route}
+
+	// A route that does not publish must not block publishing, payloads past its queue are dropped.
+	for range producerRouteQueueSize + 2 {
+		if err := mg.publish(context.Background(), &structs.SandwichPayload{Type: "A"}, "sandwich"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	close(slow.unblock)
+	route.close()
+
+	// Closing again and queueing after close do nothing.
+	route.close()
+
+	if route.enqueue(&structs.SandwichPayload{Type: "A"}, "sandwich") {
+		t.Fatal("expected closed route not to queue payloads")
+	}
+
+	// One payload may have been taken by the worker before the queue filled.
+	if published := len(slow.published); published != producerRouteQueueSize && published != producerRouteQueueSize+1 {
+		t.Fatalf("expected the queued payloads to be published, got %d", published)
+	}
+}
@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/valyala/fasthttp"
+)
+
+// User value set on proxied requests, so a 404 from Discord is not replaced by the dashboard.
+const proxyUserValue = "proxy"
+
+// Headers that are not forwarded between the caller and Discord.
+var proxySkippedHeaders = map[string]bool{
+	"Authorization":     true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+	"Keep-Alive":        true,
+	"Upgrade":           true,
+}
+
+// /{manager}/proxy/{path}: Forwards a request to Discord with the token of the manager. Requests wait
+// for their ratelimit bucket instead of being rejected. Cacheable GET routes are served from state.
+// Requests must send the proxy secret as their Authorization header.
+func (sg *Sandwich) ProxyEndpoint(ctx *fasthttp.RequestCtx) {
+	ctx.SetUserValue(proxyUserValue, true)
+
+	sg.configurationMu.RLock()
+	secret := sg.Configuration.HTTP.ProxySecret
+	sg.configurationMu.RUnlock()
+
+	if secret == "" {
+		writeResponse(ctx, fasthttp.StatusNotFound, sandwich_structs.BaseRestResponse{
+			Ok:    false,
+			Error: ErrProxySecret.Error(),
+		})
+
+		return
+	}
+
+	if subtle.ConstantTimeCompare(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization), []byte(secret)) != 1 {
+		writeResponse(ctx, fasthttp.StatusUnauthorized, sandwich_structs.BaseRestResponse{
+			Ok:    false,
+			Error: "Unauthorized",
+		})
+
+		return
+	}
+
+	managerKey := ctx.UserValue("manager").(string)
+
+	mg, ok := sg.Managers.Load(managerKey)
+	if !ok {
+		writeResponse(ctx, fasthttp.StatusBadRequest, sandwich_structs.BaseRestResponse{
+			Ok:    false,
+			Error: "Manager not found",
+		})
+
+		return
+	}
+
+	path := "/" + ctx.UserValue("path").(string)
+
+	stateRoute, cacheable := ParseProxyStateRoute(string(ctx.Method()), path, string(ctx.QueryArgs().QueryString()))
+	if cacheable {
+		if body, ok := sg.ProxyFromState(mg, stateRoute); ok {
+			ctx.Response.Header.Set(ProxyCacheHeader, ProxyCacheHit)
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(fasthttp.StatusOK)
+			ctx.SetBody(body)
+
+			return
+		}
+	}
+
+	if queryString := ctx.QueryArgs().QueryString(); len(queryString) > 0 {
+		path += "?" + string(queryString)
+	}
+
+	var body io.Reader
+	if postBody := ctx.PostBody(); len(postBody) > 0 {
+		body = bytes.NewReader(postBody)
+	}
+
+	// The upstream request is cancelled when the server shuts down or the manager is closed.
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(mg.ctx, cancel)
+	defer stop()
+
+	req, err := http.NewRequestWithContext(reqCtx, string(ctx.Method()), path, body)
+	if err != nil {
+		writeResponse(ctx, fasthttp.StatusBadRequest, sandwich_structs.BaseRestResponse{
+			Ok:    false,
+			Error: err.Error(),
+		})
+
+		return
+	}
+
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		if !proxySkippedHeaders[http.CanonicalHeaderKey(string(key))] {
+			req.Header.Add(string(key), string(value))
+		}
+	})
+
+	mg.clientMu.Lock()
+	client := mg.Client
+	mg.clientMu.Unlock()
+
+	res, err := client.HandleRequest(req, true)
+	if res == nil {
+		writeResponse(ctx, fasthttp.StatusBadGateway, sandwich_structs.BaseRestResponse{
+			Ok:    false,
+			Error: err.Error(),
+		})
+
+		return
+	}
+
+	defer res.Body.Close()
+
+	if err != nil {
+		mg.Logger.Debug().Err(err).Str("path", req.URL.Path).Msg("Proxied request failed")
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		writeResponse(ctx, fasthttp.StatusBadGateway, sandwich_structs.BaseRestResponse{
+			Ok:    false,
+			Error: err.Error(),
+		})
+
+		return
+	}
+
+	if cacheable && res.StatusCode == http.StatusOK {
+		if err := sg.ProxyToState(mg, stateRoute, resBody); err != nil {
+			mg.Logger.Debug().Err(err).Str("path", req.URL.Path).Msg("Failed to store proxied response")
+		}
+	}
+
+	for key, values := range res.Header {
+		if proxySkippedHeaders[key] {
+			continue
+		}
+
+		for _, value := range values {
+			ctx.Response.Header.Add(key, value)
+		}
+	}
+
+	ctx.Response.Header.Set(ProxyCacheHeader, ProxyCacheMiss)
+	ctx.SetStatusCode(res.StatusCode)
+	ctx.SetBody(resBody)
+}
@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+// Header set on proxied responses, with whether the response was served from state.
+const (
+	ProxyCacheHeader = "X-Sandwich-Cache"
+	ProxyCacheHit    = "HIT"
+	ProxyCacheMiss   = "MISS"
+)
+
+// Guild fields that are only sent over the gateway and are not part of GET /guilds/{id}.
+var proxyGatewayGuildFields = []string{
+	"joined_at", "large", "unavailable", "member_count", "voice_states", "members", "channels",
+	"threads", "presences", "stage_instances", "guild_scheduled_events", "approximate_member_count",
+	"approximate_presence_count",
+}
+
+// ProxyStateRoute is a GET route of the Discord API that can be served from state.
+type ProxyStateRoute struct {
+	// Name of the route, one of guild, channels, roles or member.
+	Name    string
+	GuildID discord.GuildID
+	UserID  discord.UserID
+}
+
+// ParseProxyStateRoute returns the route of a request if it can be served from state.
+func ParseProxyStateRoute(method string, path string, rawQuery string) (route ProxyStateRoute, ok bool) {
+	// Query parameters such as with_counts are not known by state.
+	if method != http.MethodGet || rawQuery != "" {
+		return route, false
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api"), "/"), "/")
+
+	if len(parts) > 0 && strings.HasPrefix(parts[0], "v") && isNumeric(parts[0][1:]) {
+		parts = parts[1:]
+	}
+
+	if len(parts) < 2 || parts[0] != "guilds" {
+		return route, false
+	}
+
+	guildID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return route, false
+	}
+
+	route.GuildID = discord.GuildID(guildID)
+
+	switch {
+	case len(parts) == 2:
+		route.Name = "guild"
+	case len(parts) == 3 && parts[2] == "channels":
+		route.Name = "channels"
+	case len(parts) == 3 && parts[2] == "roles":
+		route.Name = "roles"
+	case len(parts) == 4 && parts[2] == "members":
+		userID, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			return route, false
+		}
+
+		route.Name = "member"
+		route.UserID = discord.UserID(userID)
+	default:
+		return route, false
+	}
+
+	return route, true
+}
+
+// ProxyFromState returns the response of a route from state, in the same shape as the Discord API.
+// Guild routes are only served once the manager has received the guild, so the cache is complete.
+func (sg *Sandwich) ProxyFromState(mg *Manager, route ProxyStateRoute) (body []byte, ok bool) {
+	ctx := NewFakeCtx(mg)
+
+	var value interface{}
+
+	switch route.Name {
+	case "guild":
+		if !mg.HasGuild(route.GuildID) || !ctx.Caches(route.GuildID, CacheCollectionRoles|CacheCollectionEmojis) {
+			return nil, false
+		}
+
+		guild, ok := sg.State.GetGuild(route.GuildID)
+		if !ok {
+			return nil, false
+		}
+
+		return proxyGuildBody(guild)
+	case "channels":
+		if !mg.HasGuild(route.GuildID) || !ctx.Caches(route.GuildID, CacheCollectionChannels) {
+			return nil, false
+		}
+
+		guildChannels, _ := sg.State.GetAllGuildChannels(route.GuildID)
+
+		// Threads are not returned by GET /guilds/{id}/channels.
+		channels := make([]discord.Channel, 0, len(guildChannels))
+
+		for _, channel := range guildChannels {
+			if !IsThread(channel) {
+				channels = append(channels, channel)
+			}
+		}
+
+		value = channels
+	case "roles":
+		if !mg.HasGuild(route.GuildID) || !ctx.Caches(route.GuildID, CacheCollectionRoles) {
+			return nil, false
+		}
+
+		roles, ok := sg.State.GetAllGuildRoles(route.GuildID)
+		if !ok {
+			return nil, false
+		}
+
+		value = roles
+	case "member":
+		if !mg.HasGuild(route.GuildID) || !ctx.Caches(route.GuildID, CacheCollectionMembers) {
+			return nil, false
+		}
+
+		guildMember, ok := sg.State.GetGuildMember(route.GuildID, route.UserID)
+		if !ok || guildMember.User == nil {
+			return nil, false
+		}
+
+		sg.State.TouchGuildMember(route.GuildID, route.UserID)
+
+		value = guildMember
+	default:
+		return nil, false
+	}
+
+	body, err := sandwichjson.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+
+	return body, true
+}
+
+// proxyGuildBody returns a guild without the fields that are only sent over the gateway.
+func proxyGuildBody(guild discord.Guild) (body []byte, ok bool) {
+	guildJSON, err := sandwichjson.Marshal(guild)
+	if err != nil {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+
+	err = sandwichjson.Unmarshal(guildJSON, &fields)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, field := range proxyGatewayGuildFields {
+		delete(fields, field)
+	}
+
+	body, err = sandwichjson.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+
+	return body, true
+}
+
+// ProxyToState stores the response of a route from the Discord API in state. Responses are only
+// stored for guilds the manager has received, so state is not populated with guilds it does not
+// receive updates for.
+func (sg *Sandwich) ProxyToState(mg *Manager, route ProxyStateRoute, body []byte) error {
+	if !mg.HasGuild(route.GuildID) {
+		return nil
+	}
+
+	ctx := NewFakeCtx(mg)
+
+	switch route.Name {
+	case "guild":
+		var guild discord.Guild
+
+		if err := sandwichjson.Unmarshal(body, &guild); err != nil {
+			return err
+		}
+
+		for _, role := range guild.Roles {
+			sg.State.SetGuildRole(ctx, guild.ID, role)
+		}
+
+		sg.State.SetGuildEmojis(ctx, guild.ID, guild.Emojis)
+
+		// Keep the gateway only fields of a cached guild.
+		if _, ok := sg.State.UpdateGuild(guild.ID, func(cachedGuild discord.Guild) discord.Guild {
+			guild.JoinedAt = cachedGuild.JoinedAt
+			guild.Large = cachedGuild.Large
+			guild.MemberCount = cachedGuild.MemberCount
+			guild.Presences = cachedGuild.Presences
+
+			guild.Roles = nil
+			guild.Emojis = nil
+
+			return guild
+		}); !ok {
+			guild.Roles = nil
+			guild.Emojis = nil
+
+			sg.State.Backend.SetGuild(guild)
+		}
+	case "channels":
+		var channels []discord.Channel
+
+		if err := sandwichjson.Unmarshal(body, &channels); err != nil {
+			return err
+		}
+
+		for _, channel := range channels {
+			sg.State.SetGuildChannel(ctx, route.GuildID, channel)
+		}
+	case "roles":
+		var roles []discord.Role
+
+		if err := sandwichjson.Unmarshal(body, &roles); err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			sg.State.SetGuildRole(ctx, route.GuildID, role)
+		}
+	case "member":
+		var guildMember discord.GuildMember
+
+		if err := sandwichjson.Unmarshal(body, &guildMember); err != nil {
+			return err
+		}
+
+		sg.State.SetGuildMember(ctx, route.GuildID, guildMember)
+		sg.State.TouchGuildMember(route.GuildID, route.UserID)
+	}
+
+	return nil
+}
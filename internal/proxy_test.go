@@ -0,0 +1,322 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/atomic"
+)
+
+func TestRateLimitRoute(t *testing.T) {
+	tests := map[string]string{
+		"/api/v10/channels/123/messages/456":                  "GET /channels/123/messages/{id}",
+		"/api/v10/guilds/123/members/456":                     "GET /guilds/123/members/{id}",
+		"/api/v10/channels/123/messages/456/reactions/%F0%9F": "GET /channels/123/messages/{id}/reactions",
+		"/api/v10/webhooks/123/token/messages/456":            "GET /webhooks/123/token/messages/{id}",
+		"/users/@me": "GET /users/@me",
+	}
+
+	for path, expected := range tests {
+		if route := RateLimitRoute(http.MethodGet, path); route != expected {
+			t.Errorf("RateLimitRoute(%q) = %q, expected %q", path, route, expected)
+		}
+	}
+}
+
+func TestProxyEndpoint(t *testing.T) {
+	requests := atomic.NewInt32(0)
+
+	discordServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Bucket", "abcd")
+
+		// The first request is ratelimited and should be retried.
+		if requests.Inc() == 1 {
+			w.Header().Set("Retry-After", "0.05")
+			w.Header().Set("X-RateLimit-Scope", "user")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("X-RateLimit-Remaining", "4")
+		w.Header().Set("X-RateLimit-Reset-After", "1")
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.Header().Set("X-Path", r.URL.RequestURI())
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}))
+	defer discordServer.Close()
+
+	sg, _ := newProxyTestSandwich(t, discordServer.URL)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&fasthttp.Request{}, nil, nil)
+	ctx.Request.Header.SetMethod(http.MethodPost)
+	ctx.Request.Header.Set("Authorization", "secret")
+	ctx.Request.SetRequestURI("/test/proxy/api/v10/channels/123/messages?limit=1")
+	ctx.Request.SetBodyString(`{"content":"hello"}`)
+	ctx.SetUserValue("manager", "test")
+	ctx.SetUserValue("path", "api/v10/channels/123/messages")
+
+	sg.ProxyEndpoint(ctx)
+
+	if ctx.Response.StatusCode() != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", ctx.Response.StatusCode())
+	}
+
+	if cache := string(ctx.Response.Header.Peek(ProxyCacheHeader)); cache != ProxyCacheMiss {
+		t.Errorf("Expected a cache miss, got %q", cache)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("Expected the ratelimited request to be retried, got %d requests", requests.Load())
+	}
+
+	if authorization := string(ctx.Response.Header.Peek("X-Authorization")); authorization != "Bot token" {
+		t.Errorf("Expected the manager token to be used, got %q", authorization)
+	}
+
+	if path := string(ctx.Response.Header.Peek("X-Path")); path != "/api/v10/channels/123/messages?limit=1" {
+		t.Errorf("Unexpected path %q", path)
+	}
+
+	if body := string(ctx.Response.Body()); body != `{"content":"hello"}` {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
+
+func TestProxyEndpointAuthorization(t *testing.T) {
+	requests := atomic.NewInt32(0)
+
+	discordServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+	}))
+	defer discordServer.Close()
+
+	sg, _ := newProxyTestSandwich(t, discordServer.URL)
+
+	proxy := func(authorization string) int {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Init(&fasthttp.Request{}, nil, nil)
+		ctx.Request.Header.SetMethod(http.MethodDelete)
+		ctx.Request.Header.Set("Authorization", authorization)
+		ctx.Request.SetRequestURI("/test/proxy/api/v10/channels/123")
+		ctx.SetUserValue("manager", "test")
+		ctx.SetUserValue("path", "api/v10/channels/123")
+
+		sg.ProxyEndpoint(ctx)
+
+		return ctx.Response.StatusCode()
+	}
+
+	if status := proxy("Bot other"); status != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a wrong secret, got %d", status)
+	}
+
+	sg.Configuration.HTTP.ProxySecret = ""
+
+	if status := proxy(""); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a configured secret, got %d", status)
+	}
+
+	if requests.Load() != 0 {
+		t.Errorf("Expected no requests to Discord, got %d", requests.Load())
+	}
+}
+
+func TestRateLimiterRemaining(t *testing.T) {
+	rl := NewRateLimiter()
+	route := RateLimitRoute(http.MethodPost, "/channels/123/messages")
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "2")
+	header.Set("X-RateLimit-Reset-After", "60")
+
+	bucket, err := rl.Acquire(context.Background(), route)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rl.Release(route, bucket, http.StatusOK, header)
+
+	// Both remaining requests can be in flight at once, the next waits for the reset.
+	for range 2 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+		if _, err := rl.Acquire(ctx, route); err != nil {
+			t.Fatalf("Expected a remaining request to be acquired, got %v", err)
+		}
+
+		cancel()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := rl.Acquire(ctx, route); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected an exhausted bucket to wait, got %v", err)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	rl := NewRateLimiter()
+	route := RateLimitRoute(http.MethodGet, "/channels/123/messages")
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Bucket", "abcd")
+	header.Set("X-RateLimit-Remaining", "4")
+	header.Set("X-RateLimit-Reset-After", "0")
+
+	bucket, err := rl.Acquire(context.Background(), route)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	held, err := rl.Acquire(context.Background(), RateLimitRoute(http.MethodGet, "/guilds/456"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rl.Release(route, bucket, http.StatusOK, header)
+
+	rl.mu.Lock()
+	rl.sweep(time.Now())
+	buckets, routes := len(rl.buckets), len(rl.routes)
+	rl.mu.Unlock()
+
+	// Only the bucket still used by a request is kept.
+	if buckets != 1 || routes != 0 {
+		t.Errorf("Expected 1 bucket and no routes after sweeping, got %d buckets and %d routes", buckets, routes)
+	}
+
+	rl.Release(RateLimitRoute(http.MethodGet, "/guilds/456"), held, 0, nil)
+}
+
+func TestProxyEndpointState(t *testing.T) {
+	requests := atomic.NewInt32(0)
+
+	discordServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/api/v10/guilds/1/members/2":
+			_, _ = w.Write([]byte(`{"user":{"id":"2","username":"member"},"roles":[]}`))
+		case "/api/v10/guilds/1/roles":
+			_, _ = w.Write([]byte(`[{"id":"1","name":"@everyone"},{"id":"3","name":"role"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer discordServer.Close()
+
+	sg, mg := newProxyTestSandwich(t, discordServer.URL)
+
+	proxy := func(path string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Init(&fasthttp.Request{}, nil, nil)
+		ctx.Request.Header.SetMethod(http.MethodGet)
+		ctx.Request.Header.Set("Authorization", "secret")
+		ctx.Request.SetRequestURI("/test/proxy/" + path)
+		ctx.SetUserValue("manager", "test")
+		ctx.SetUserValue("path", path)
+
+		sg.ProxyEndpoint(ctx)
+
+		if ctx.Response.StatusCode() != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", path, ctx.Response.StatusCode())
+		}
+
+		return ctx
+	}
+
+	// Guild routes are not served or stored until the manager has received the guild.
+	for _, path := range []string{"api/v10/guilds/1/members/2", "api/v10/guilds/1/roles"} {
+		proxy(path)
+
+		if cache := string(proxy(path).Response.Header.Peek(ProxyCacheHeader)); cache != ProxyCacheMiss {
+			t.Errorf("Expected a cache miss for a guild the manager has not received, got %q", cache)
+		}
+	}
+
+	shardGroup := &ShardGroup{Guilds: NewCache[discord.GuildID, struct{}](0)}
+	shardGroup.Guilds.Store(1, struct{}{})
+	mg.ShardGroups.Store(1, shardGroup)
+
+	// Responses are written back into state on a miss.
+	if cache := string(proxy("api/v10/guilds/1/members/2").Response.Header.Peek(ProxyCacheHeader)); cache != ProxyCacheMiss {
+		t.Errorf("Expected a cache miss, got %q", cache)
+	}
+
+	ctx := proxy("api/v10/guilds/1/members/2")
+
+	if cache := string(ctx.Response.Header.Peek(ProxyCacheHeader)); cache != ProxyCacheHit {
+		t.Errorf("Expected a cache hit, got %q", cache)
+	}
+
+	if body := string(ctx.Response.Body()); !strings.Contains(body, `"username":"member"`) {
+		t.Errorf("Unexpected member body %s", body)
+	}
+
+	proxy("api/v10/guilds/1/roles")
+
+	ctx = proxy("api/v10/guilds/1/roles")
+
+	if cache := string(ctx.Response.Header.Peek(ProxyCacheHeader)); cache != ProxyCacheHit {
+		t.Errorf("Expected a cache hit, got %q", cache)
+	}
+
+	if body := string(ctx.Response.Body()); !strings.Contains(body, `"name":"role"`) {
+		t.Errorf("Unexpected roles body %s", body)
+	}
+
+	if requests.Load() != 6 {
+		t.Errorf("Expected 6 requests to Discord, got %d", requests.Load())
+	}
+}
+
+func newProxyTestSandwich(t *testing.T, discordURL string) (*Sandwich, *Manager) {
+	t.Helper()
+
+	baseURL, err := url.Parse(discordURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configurationPath := filepath.Join(t.TempDir(), "sandwich.yaml")
+	if err := os.WriteFile(configurationPath, []byte("managers: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sg, err := NewSandwich(io.Discard, SandwichOptions{
+		ConfigurationLocation: configurationPath,
+		BaseURL:               *baseURL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sg.Configuration.HTTP.ProxySecret = "secret"
+
+	mg := sg.NewManager(&ManagerConfiguration{Identifier: "test", Token: "token"})
+	mg.Configuration.Caching.CacheUsers = true
+	mg.Configuration.Caching.CacheMembers = true
+
+	sg.Managers.Store("test", mg)
+
+	return sg, mg
+}
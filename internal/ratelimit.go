@@ -0,0 +1,350 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of times a request is retried after being ratelimited.
+const RateLimitMaxRetries = 5
+
+// Major parameters of routes. Buckets are shared between routes with the same major parameters.
+var rateLimitMajorParameters = map[string]bool{
+	"channels": true,
+	"guilds":   true,
+	"webhooks": true,
+}
+
+// Interval buckets that are idle after their reset are removed at.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter tracks the ratelimit buckets of the Discord API. Requests on an exhausted bucket, or while
+// globally ratelimited, wait for the reset instead of failing.
+type RateLimiter struct {
+	// Bucket hash of each route, from the X-RateLimit-Bucket header.
+	routes map[string]string
+
+	buckets map[string]*RateLimitBucket
+
+	globalReset time.Time
+
+	// Last time idle buckets were removed.
+	swept time.Time
+
+	mu sync.Mutex
+}
+
+// RateLimitBucket is a ratelimit bucket. Each request reserves one of the remaining requests of the
+// bucket, so no more requests are in flight than the bucket allows. Once the bucket is exhausted and
+// has reset, a single request is made to learn the remaining requests of the new window.
+type RateLimitBucket struct {
+	reset time.Time
+
+	// Closed and replaced whenever a request on the bucket finishes.
+	released chan void
+
+	remaining int
+	inflight  int
+
+	// Number of requests using the bucket. Guarded by the mutex of the RateLimiter.
+	references int
+
+	mu sync.Mutex
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		routes:  make(map[string]string),
+		buckets: make(map[string]*RateLimitBucket),
+		mu:      sync.Mutex{},
+	}
+}
+
+// RateLimitRoute returns the route of a request. Snowflakes are replaced unless they are
+// a major parameter.
+func RateLimitRoute(method string, path string) string {
+	path = strings.TrimPrefix(path, "/api")
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	// Remove the API version.
+	if len(parts) > 0 && strings.HasPrefix(parts[0], "v") && isNumeric(parts[0][1:]) {
+		parts = parts[1:]
+	}
+
+	route := make([]string, 0, len(parts))
+
+	for i, part := range parts {
+		switch {
+		case i > 0 && rateLimitMajorParameters[parts[i-1]]:
+			route = append(route, part)
+		case i > 1 && parts[i-2] == "webhooks":
+			// Webhook tokens are part of the major parameter.
+			route = append(route, part)
+		case i > 1 && parts[i-2] == "interactions":
+			route = append(route, "{token}")
+		case isNumeric(part):
+			route = append(route, "{id}")
+		default:
+			route = append(route, part)
+		}
+
+		// All reactions of a message share a bucket.
+		if part == "reactions" {
+			break
+		}
+	}
+
+	return method + " /" + strings.Join(route, "/")
+}
+
+// majorParameters returns the major parameters of a route.
+func majorParameters(route string) string {
+	_, path, _ := strings.Cut(route, " ")
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	major := make([]string, 0, 2)
+
+	for i := 1; i < len(parts); i++ {
+		if rateLimitMajorParameters[parts[i-1]] || (i > 1 && parts[i-2] == "webhooks") {
+			major = append(major, parts[i])
+		}
+	}
+
+	return strings.Join(major, "/")
+}
+
+// bucket returns the bucket of a route and references it. Routes without a known bucket hash have
+// their own bucket.
+func (rl *RateLimiter) bucket(route string) *RateLimitBucket {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if now := time.Now(); now.Sub(rl.swept) >= rateLimitSweepInterval {
+		rl.sweep(now)
+	}
+
+	key := route
+	if hash, ok := rl.routes[route]; ok {
+		key = hash + ":" + majorParameters(route)
+	}
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &RateLimitBucket{
+			released:  make(chan void),
+			remaining: 1,
+		}
+
+		rl.buckets[key] = bucket
+	}
+
+	bucket.references++
+
+	return bucket
+}
+
+// unreference marks a request as no longer using a bucket.
+func (rl *RateLimiter) unreference(bucket *RateLimitBucket) {
+	rl.mu.Lock()
+	bucket.references--
+	rl.mu.Unlock()
+}
+
+// sweep removes buckets that are not used by any request and have reset, along with the routes
+// of the removed buckets. The mutex must be held.
+func (rl *RateLimiter) sweep(now time.Time) {
+	rl.swept = now
+
+	for key, bucket := range rl.buckets {
+		bucket.mu.Lock()
+		idle := bucket.references == 0 && !now.Before(bucket.reset)
+		bucket.mu.Unlock()
+
+		if idle {
+			delete(rl.buckets, key)
+		}
+	}
+
+	for route, hash := range rl.routes {
+		if _, ok := rl.buckets[hash+":"+majorParameters(route)]; !ok {
+			delete(rl.routes, route)
+		}
+	}
+}
+
+// Acquire waits until a request can be made on the route. The bucket must be released with the
+// response headers once the request has finished.
+func (rl *RateLimiter) Acquire(ctx context.Context, route string) (*RateLimitBucket, error) {
+	bucket := rl.bucket(route)
+
+	if err := bucket.reserve(ctx); err != nil {
+		rl.unreference(bucket)
+
+		return nil, err
+	}
+
+	for {
+		rl.mu.Lock()
+		globalReset := rl.globalReset
+		rl.mu.Unlock()
+
+		if !time.Now().Before(globalReset) {
+			break
+		}
+
+		if err := sleepContext(ctx, time.Until(globalReset)); err != nil {
+			rl.Release(route, bucket, 0, nil)
+
+			return nil, err
+		}
+	}
+
+	return bucket, nil
+}
+
+// reserve waits until the bucket has a remaining request and reserves it.
+func (bucket *RateLimitBucket) reserve(ctx context.Context) error {
+	for {
+		bucket.mu.Lock()
+
+		if bucket.remaining > 0 || (bucket.inflight == 0 && !time.Now().Before(bucket.reset)) {
+			bucket.remaining = max(bucket.remaining-1, 0)
+			bucket.inflight++
+			bucket.mu.Unlock()
+
+			return nil
+		}
+
+		released := bucket.released
+		wait := time.Until(bucket.reset)
+		bucket.mu.Unlock()
+
+		// Requests in flight update the bucket when they finish, otherwise the bucket is
+		// exhausted until the reset.
+		if err := waitReleased(ctx, released, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// waitReleased waits until a request on a bucket has finished or for the duration if positive.
+func waitReleased(ctx context.Context, released chan void, duration time.Duration) error {
+	var timeout <-chan time.Time
+
+	if duration > 0 {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case <-released:
+		return nil
+	case <-timeout:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release updates the bucket from the response headers and lets the next request on the bucket be made.
+// A nil header releases the bucket without changes, returning its reserved request.
+func (rl *RateLimiter) Release(route string, bucket *RateLimitBucket, statusCode int, header http.Header) {
+	defer rl.unreference(bucket)
+
+	now := time.Now()
+
+	var global bool
+
+	var reset time.Time
+
+	if header != nil {
+		if hash := header.Get("X-RateLimit-Bucket"); hash != "" {
+			rl.mu.Lock()
+			rl.routes[route] = hash
+
+			// The first bucket seen for a hash is shared by all routes with the hash.
+			if key := hash + ":" + majorParameters(route); rl.buckets[key] == nil {
+				rl.buckets[key] = bucket
+			}
+			rl.mu.Unlock()
+		}
+
+		if statusCode == http.StatusTooManyRequests {
+			retryAfter, err := strconv.ParseFloat(header.Get("Retry-After"), 64)
+			if err != nil {
+				retryAfter = 1
+			}
+
+			reset = now.Add(time.Duration(retryAfter * float64(time.Second)))
+			global = header.Get("X-RateLimit-Global") == "true" || header.Get("X-RateLimit-Scope") == "global"
+		}
+	}
+
+	if global {
+		rl.mu.Lock()
+		rl.globalReset = reset
+		rl.mu.Unlock()
+	}
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	bucket.inflight--
+
+	close(bucket.released)
+	bucket.released = make(chan void)
+
+	if header == nil {
+		bucket.remaining++
+
+		return
+	}
+
+	// Requests still in flight have already reserved their request.
+	if remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		bucket.remaining = max(remaining-bucket.inflight, 0)
+	}
+
+	if resetAfter, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset-After"), 64); err == nil {
+		bucket.reset = now.Add(time.Duration(resetAfter * float64(time.Second)))
+	}
+
+	if statusCode == http.StatusTooManyRequests && !global {
+		bucket.remaining = 0
+		bucket.reset = reset
+	}
+}
+
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+)
+
+type RescalePhase string
+
+const (
+	// The new shard group is connecting and receiving READY and GUILD_CREATE events while the
+	// previous shard groups keep producing.
+	RescalePhaseConnecting RescalePhase = "connecting"
+	// Both shard groups are producing and events received by both are deduplicated.
+	RescalePhaseHandoff RescalePhase = "handoff"
+	// The previous shard groups have been closed.
+	RescalePhaseCompleted RescalePhase = "completed"
+	// The new shard group failed to connect and the previous shard groups keep producing.
+	RescalePhaseFailed RescalePhase = "failed"
+)
+
+const DefaultRescaleHandoffWindow = 10
+
+// handoffEvent is the shard group that produced an event during handoff.
+type handoffEvent struct {
+	shardGroupID int32
+	expiresAt    int64
+}
+
+// rescaleState tracks the progress of replacing the running shard groups with a new one.
+type rescaleState struct {
+	shardGroup *ShardGroup
+	previous   []*ShardGroup
+	phase      RescalePhase
+	started    time.Time
+	err        string
+}
+
+// handoffWindow returns how long both shard groups produce events before the previous ones are closed.
+func (mg *Manager) handoffWindow() time.Duration {
+	mg.configurationMu.RLock()
+	window := mg.Configuration.Sharding.HandoffWindow
+	mg.configurationMu.RUnlock()
+
+	if window <= 0 {
+		window = DefaultRescaleHandoffWindow
+	}
+
+	return time.Duration(window) * time.Second
+}
+
+// startRescale marks the running shard groups for closure once sg replaces them.
+// Returns false if there are no shard groups to replace.
+func (mg *Manager) startRescale(sg *ShardGroup) bool {
+	var previous []*ShardGroup
+
+	mg.ShardGroups.Range(func(shardGroupID int32, shardGroup *ShardGroup) bool {
+		if shardGroupID == sg.ID {
+			return false
+		}
+
+		switch shardGroup.GetStatus() {
+		case sandwich_structs.ShardGroupStatusErroring, sandwich_structs.ShardGroupStatusClosing,
+			sandwich_structs.ShardGroupStatusClosed:
+		default:
+			shardGroup.SetStatus(sandwich_structs.ShardGroupStatusMarkedForClosure)
+			previous = append(previous, shardGroup)
+		}
+
+		return false
+	})
+
+	if len(previous) == 0 {
+		return false
+	}
+
+	sg.Logger.Info().Int("previous", len(previous)).Msg("Rescaling, previous shard groups keep producing until handoff")
+
+	mg.rescaleMu.Lock()
+	mg.rescale = &rescaleState{
+		shardGroup: sg,
+		previous:   previous,
+		phase:      RescalePhaseConnecting,
+		started:    time.Now(),
+	}
+	mg.rescaleMu.Unlock()
+
+	return true
+}
+
+// failRescale keeps the previous shard groups running when the new shard group fails to connect.
+func (mg *Manager) failRescale(sg *ShardGroup, err error) {
+	mg.rescaleMu.Lock()
+	defer mg.rescaleMu.Unlock()
+
+	if mg.rescale == nil || mg.rescale.shardGroup != sg {
+		return
+	}
+
+	mg.rescale.phase = RescalePhaseFailed
+	mg.rescale.err = err.Error()
+
+	for _, shardGroup := range mg.rescale.previous {
+		if shardGroup.GetStatus() == sandwich_structs.ShardGroupStatusMarkedForClosure {
+			shardGroup.SetStatus(sandwich_structs.ShardGroupStatusConnected)
+		}
+	}
+}
+
+// handoff hands event production over to a shard group that has received all of its guilds.
+// Both shard groups produce for the handoff window with events received by both deduplicated,
+// then the previous shard groups are suppressed and closed.
+func (mg *Manager) handoff(sg *ShardGroup) {
+	mg.rescaleMu.Lock()
+	rescale := mg.rescale
+	if rescale != nil && rescale.shardGroup == sg {
+		rescale.phase = RescalePhaseHandoff
+	} else {
+		rescale = nil
+	}
+	mg.rescaleMu.Unlock()
+
+	if rescale == nil {
+		sg.setFloodgate(true)
+
+		return
+	}
+
+	window := mg.handoffWindow()
+
+	sg.Logger.Info().Dur("window", window).Msg("Handing off to shard group")
+
+	mg.handoffDedupe.Store(true)
+	sg.setFloodgate(true)
+
+	select {
+	case <-time.After(window):
+	case <-mg.ctx.Done():
+	}
+
+	for _, shardGroup := range rescale.previous {
+		shardGroup.setFloodgate(false)
+	}
+
+	for _, shardGroup := range rescale.previous {
+		shardGroup.Close()
+	}
+
+	// Events of the previous shard groups may still be in flight until they have closed.
+	mg.handoffDedupe.Store(false)
+	mg.handoffEvents.Clear()
+
+	mg.rescaleMu.Lock()
+	rescale.phase = RescalePhaseCompleted
+	mg.rescaleMu.Unlock()
+
+	sg.Logger.Info().Dur("duration", time.Since(rescale.started).Round(time.Second)).Msg("Completed rescale")
+}
+
+// isHandoffDuplicate returns if an event has already been produced by another shard group during handoff.
+// Identical events received by the same shard group, such as repeated typing events, are not duplicates.
+func (mg *Manager) isHandoffDuplicate(shardGroupID int32, msg discord.GatewayPayload) bool {
+	if !mg.handoffDedupe.Load() {
+		return false
+	}
+
+	var duplicate bool
+
+	mg.handoffEvents.SetIf(createDedupeEventKey(msg), func(event handoffEvent, ok bool) (handoffEvent, bool) {
+		now := time.Now()
+		duplicate = ok && now.Unix() < event.expiresAt && event.shardGroupID != shardGroupID
+
+		return handoffEvent{
+			shardGroupID: shardGroupID,
+			expiresAt:    now.Add(mg.handoffWindow() + memberDedupeExpiration).Unix(),
+		}, !duplicate
+	})
+
+	return duplicate
+}
+
+// createDedupeEventKey identifies a dispatch by its type and content, as the sequence differs
+// between connections.
+func createDedupeEventKey(msg discord.GatewayPayload) string {
+	hash := fnv.New128a()
+	hash.Write(msg.Data)
+
+	return "EV:" + msg.Type + ":" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// rescaleStatus returns the progress of the last rescale for the status endpoint.
+func (mg *Manager) rescaleStatus() *sandwich_structs.StatusEndpointRescale {
+	mg.rescaleMu.RLock()
+	defer mg.rescaleMu.RUnlock()
+
+	if mg.rescale == nil {
+		return nil
+	}
+
+	sg := mg.rescale.shardGroup
+
+	status := &sandwich_structs.StatusEndpointRescale{
+		ShardGroupID: sg.ID,
+		ShardCount:   sg.ShardCount,
+		Phase:        string(mg.rescale.phase),
+		Duration:     int(time.Since(mg.rescale.started).Seconds()),
+		Error:        mg.rescale.err,
+	}
+
+	for _, shardGroup := range mg.rescale.previous {
+		status.PreviousShardGroupIDs = append(status.PreviousShardGroupIDs, shardGroup.ID)
+	}
+
+	sg.Shards.Range(func(_ int32, sh *Shard) bool {
+		status.Shards++
+
+		if sh.IsReady {
+			status.ShardsReady++
+		}
+
+		status.GuildsPending += sh.Lazy.Count()
+
+		return false
+	})
+
+	return status
+}
+
+func (sg *ShardGroup) setFloodgate(floodgate bool) {
+	sg.floodgateMu.Lock()
+	sg.floodgate = floodgate
+	sg.floodgateMu.Unlock()
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	csmap "github.com/mhmtszr/concurrent-swiss-map"
+	"go.uber.org/atomic"
+)
+
+func TestRescaleHandoff(t *testing.T) {
+	sandwich := &Sandwich{
+		Managers: csmap.Create(csmap.WithSize[string, *Manager](1)),
+	}
+
+	mg := &Manager{
+		ctx:           context.Background(),
+		Identifier:    atomic.NewString("sandwich"),
+		Sandwich:      sandwich,
+		Configuration: &ManagerConfiguration{},
+		ShardGroups:   csmap.Create(csmap.WithSize[int32, *ShardGroup](2)),
+		handoffEvents: csmap.Create(csmap.WithSize[string, handoffEvent](10)),
+	}
+
+	mg.Configuration.Sharding.HandoffWindow = 1
+
+	previous := mg.NewShardGroup(1, []int32{0}, 1)
+	previous.setFloodgate(true)
+	previous.SetStatus(structs.ShardGroupStatusConnected)
+	mg.ShardGroups.Store(previous.ID, previous)
+
+	sg := mg.NewShardGroup(2, []int32{0, 1}, 2)
+	mg.ShardGroups.Store(sg.ID, sg)
+
+	if !mg.startRescale(sg) {
+		t.Fatal("expected rescale to start")
+	}
+
+	if status := mg.rescaleStatus(); status.Phase != string(RescalePhaseConnecting) || previous.GetStatus() != structs.ShardGroupStatusMarkedForClosure {
+		t.Fatalf("unexpected rescale status %+v", status)
+	}
+
+	done := make(chan void)
+
+	go func() {
+		mg.handoff(sg)
+		close(done)
+	}()
+
+	for !mg.handoffDedupe.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	msg := discord.GatewayPayload{Op: discord.GatewayOpDispatch, Type: discord.DiscordEventMessageCreate, Data: []byte(`{"id":"1"}`)}
+
+	if mg.isHandoffDuplicate(previous.ID, msg) {
+		t.Fatal("expected first event not to be a duplicate")
+	}
+
+	if mg.isHandoffDuplicate(previous.ID, msg) {
+		t.Fatal("expected identical event from the same shard group not to be a duplicate")
+	}
+
+	msg.Sequence = 10
+
+	if !mg.isHandoffDuplicate(sg.ID, msg) {
+		t.Fatal("expected event received by both shard groups to be a duplicate")
+	}
+
+	<-done
+
+	if previous.floodgate || !sg.floodgate || previous.GetStatus() != structs.ShardGroupStatusClosed {
+		t.Fatalf("expected previous shard group to be closed, got status %d", previous.GetStatus())
+	}
+
+	if status := mg.rescaleStatus(); status.Phase != string(RescalePhaseCompleted) || mg.handoffDedupe.Load() {
+		t.Fatalf("unexpected rescale status %+v", status)
+	}
+}
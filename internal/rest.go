@@ -68,12 +68,18 @@ func (sg *Sandwich) NewRestRouter() (routerHandler fasthttp.RequestHandler, fsHa
 	r.GET("/{manager}/api/current-user", sg.internalEndpoint(sg.CurrentUserEndpoint))
 	r.POST("/{manager}/api/bulk-has-guild", sg.internalEndpoint(sg.BulkHasGuildEndpoint))
 
+	// Identify coordinator
+	r.POST("/api/identify", sg.IdentifyEndpoint)
+
 	// Discord gateway routes (uses cached data)
 	//
 	// This can then be freely used for any discord library that just needs get gateway bot information
 	r.GET("/{manager}/api/{version}/gateway/bot", sg.internalEndpoint(sg.GatewayEndpoint))
 	r.GET("/{manager}/api/gateway/bot", sg.internalEndpoint(sg.GatewayEndpoint))
 
+	// Discord REST proxy
+	r.ANY("/{manager}/proxy/{path:*}", sg.internalEndpoint(sg.ProxyEndpoint))
+
 	// Sandwich related endpoints
 	r.GET("/api/sandwich", sg.requireDiscordAuthentication(sg.SandwichGetEndpoint))
 	r.PATCH("/api/sandwich", sg.requireDiscordAuthentication(sg.SandwichUpdateEndpoint))
@@ -264,7 +270,7 @@ func (sg *Sandwich) HandleRequest(ctx *fasthttp.RequestCtx) {
 		func(ctx *fasthttp.RequestCtx) {
 			sg.RouterHandler(ctx)
 
-			if ctx.Response.StatusCode() == fasthttp.StatusNotFound {
+			if ctx.Response.StatusCode() == fasthttp.StatusNotFound && ctx.UserValue(proxyUserValue) == nil {
 				ctx.Response.Reset()
 				sg.DistHandler(ctx)
 			}
@@ -380,7 +386,7 @@ func (sg *Sandwich) StatusEndpoint(ctx *fasthttp.RequestCtx) {
 	unsortedManagers := make(map[string]sandwich_structs.StatusEndpointManager)
 
 	manager := gotils_strconv.B2S(ctx.QueryArgs().Peek("manager"))
-	userCount := sg.State.Users.Count()
+	userCount := sg.LastKnownUsers
 	memberCount := sg.LastKnownTotalMembers
 
 	if manager == "" {
@@ -391,11 +397,18 @@ func (sg *Sandwich) StatusEndpoint(ctx *fasthttp.RequestCtx) {
 				keyName := manager.Configuration.FriendlyName + ":" + manager.Configuration.Identifier
 				manager.configurationMu.RUnlock()
 
+				spoolDepth, spoolAge := manager.spoolStatus()
+
 				unsortedManagers[keyName] = sandwich_structs.StatusEndpointManager{
 					DisplayName: friendlyName,
 					ShardGroups: getManagerShardGroupStatus(manager),
 					UserCount:   userCount,
 					MemberCount: memberCount,
+					SpoolDepth:  spoolDepth,
+					SpoolAge:    spoolAge,
+
+					Backpressure: manager.backpressureStatus(),
+					Rescale:      manager.rescaleStatus(),
 				}
 
 				return false
@@ -440,6 +453,8 @@ func (sg *Sandwich) StatusEndpoint(ctx *fasthttp.RequestCtx) {
 		friendlyName := manager.Configuration.FriendlyName
 		manager.configurationMu.RUnlock()
 
+		spoolDepth, spoolAge := manager.spoolStatus()
+
 		writeResponse(ctx, fasthttp.StatusOK, sandwich_structs.BaseRestResponse{
 			Ok: true,
 			Data: &sandwich_structs.StatusEndpointManager{
@@ -447,6 +462,11 @@ func (sg *Sandwich) StatusEndpoint(ctx *fasthttp.RequestCtx) {
 				ShardGroups: getManagerShardGroupStatus(manager),
 				UserCount:   userCount,
 				MemberCount: memberCount,
+				SpoolDepth:  spoolDepth,
+				SpoolAge:    spoolAge,
+
+				Backpressure: manager.backpressureStatus(),
+				Rescale:      manager.rescaleStatus(),
 			},
 		})
 	}
@@ -556,7 +576,9 @@ func (sg *Sandwich) CreateChaosEndpoint(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	mg.ProducerClient.StopSession(string(sessionId))
+	for _, producerClient := range mg.producerClients() {
+		producerClient.StopSession(string(sessionId))
+	}
 }
 
 // /{manager}/api/state?col={collection}&id={id}: Returns data from the sandwich state
@@ -630,7 +652,7 @@ func (sg *Sandwich) StateEndpoint(ctx *fasthttp.RequestCtx) {
 				return
 			}
 
-			sg.State.Users.Store(user.ID, sg.State.UserToState(user))
+			sg.State.Backend.SetUser(sg.State.UserToState(user))
 		}
 	case "guild_channels":
 		idInt64, err := strconv.ParseInt(gotils_strconv.B2S(id), 10, 64)
@@ -780,7 +802,7 @@ func (sg *Sandwich) StateEndpoint(ctx *fasthttp.RequestCtx) {
 				sg.State.SetGuildChannel(NewFakeCtx(mg), *ch.GuildID, ch)
 			} else if ch.Type == discord.ChannelTypeDM || ch.Type == discord.ChannelTypeGroupDM {
 				if len(ch.Recipients) > 0 {
-					sg.State.AddDMChannel(ch.Recipients[0].ID, ch)
+					sg.State.AddDMChannel(NewFakeCtx(mg), ch.Recipients[0].ID, ch)
 				}
 			}
 		}
@@ -829,6 +851,8 @@ func (sg *Sandwich) StateEndpoint(ctx *fasthttp.RequestCtx) {
 				return
 			}
 
+			sg.State.TouchGuildMember(discord.GuildID(guildIdInt64), discord.UserID(idInt64))
+
 			sg.Logger.Info().Any("member", member).Msg("Getting member")
 
 			writeResponse(ctx, fasthttp.StatusOK, sandwich_structs.BaseRestResponse{
@@ -858,6 +882,10 @@ func (sg *Sandwich) StateEndpoint(ctx *fasthttp.RequestCtx) {
 				member,
 			)
 
+			if member.User != nil {
+				sg.State.TouchGuildMember(discord.GuildID(guildIdInt64), member.User.ID)
+			}
+
 			writeResponse(ctx, fasthttp.StatusOK, sandwich_structs.BaseRestResponse{
 				Ok:   true,
 				Data: nil,
@@ -971,7 +999,7 @@ func (sg *Sandwich) BulkHasGuildEndpoint(ctx *fasthttp.RequestCtx) {
 	results := make([]int, len(guildIDs))
 
 	for i, guildID := range guildIDs {
-		if sg.State.Guilds.Has(guildID) {
+		if sg.State.HasGuild(guildID) {
 			results[i] = 1 // Guild found
 		} else {
 			results[i] = 0 // Guild not found
@@ -1140,6 +1168,8 @@ func (sg *Sandwich) SandwichUpdateEndpoint(ctx *fasthttp.RequestCtx) {
 				m.produceBlacklistMu.Unlock()
 			}
 
+			m.SetCachePolicies(manager.Caching.Policy, manager.Caching.Guilds)
+
 			m.metadataMu.Lock()
 			m.metadata = &sandwich_structs.SandwichMetadata{
 				Version:       VERSION,
@@ -1313,7 +1343,7 @@ func (sg *Sandwich) ManagerUpdateEndpoint(ctx *fasthttp.RequestCtx) {
 	manager.configurationMu.Unlock()
 
 	manager.clientMu.Lock()
-	manager.Client = NewClient(baseURL, manager.Configuration.Token)
+	manager.Client = NewClient(sg.BaseURL(), manager.Configuration.Token)
 	manager.clientMu.Unlock()
 
 	forceRestartProducers := gotils_strconv.B2S(ctx.QueryArgs().Peek("forceRestartProducers")) == "true"
@@ -1386,6 +1416,8 @@ func (sg *Sandwich) ManagerUpdateEndpoint(ctx *fasthttp.RequestCtx) {
 			m.produceBlacklistMu.Unlock()
 		}
 
+		m.SetCachePolicies(managerConfiguration.Caching.Policy, managerConfiguration.Caching.Guilds)
+
 		/*if managerConfiguration.Bot.DefaultPresence.Status != "" {
 			p := managerConfiguration.Bot.DefaultPresence
 			// Update presence.
@@ -25,6 +25,7 @@ import (
 	"github.com/valyala/fasthttp"
 	"go.uber.org/atomic"
 	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
 	"gopkg.in/yaml.v3"
 )
 
@@ -48,7 +49,7 @@ var baseURL = url.URL{
 	Host:   "discord.com",
 }
 
-var rawQuery = "v=10&encoding=json"
+var rawQuery = "v=10"
 var gatewayURL = url.URL{
 	Scheme: "wss",
 	Host:   "gateway.discord.gg",
@@ -73,12 +74,19 @@ type Sandwich struct {
 
 	IdentifyBuckets *bucketstore.BucketStore `json:"-"`
 
+	identifyCoordinator *IdentifyCoordinator
+
 	EventsInflight *atomic.Int32 `json:"-"`
 
 	Managers *csmap.CsMap[string, *Manager] `json:"managers" yaml:"managers"`
 
 	Dedupe *csmap.CsMap[string, int64]
 
+	// Recent member joins and leaves of each guild.
+	MemberRates Cache[discord.GuildID, *GuildMemberRate]
+
+	Sessions *SessionStore `json:"-"`
+
 	State  *SandwichState `json:"-"`
 	Client *Client        `json:"-"`
 
@@ -90,17 +98,21 @@ type Sandwich struct {
 	RouterHandler fasthttp.RequestHandler `json:"-"`
 	DistHandler   fasthttp.RequestHandler `json:"-"`
 
+	grpcServer *grpc.Server
+
 	guildChunks Cache[discord.GuildID, GuildChunks]
 
 	ConfigurationLocation string `json:"configuration_location"`
 
 	LastKnownTotalMembers int `json:"-"`
+	LastKnownUsers        int `json:"-"`
 
 	Options SandwichOptions `json:"options" yaml:"options"`
 
 	Configuration SandwichConfiguration `json:"configuration" yaml:"configuration"`
 
 	configurationMu sync.RWMutex
+	snapshotMu      sync.Mutex
 	sync.Mutex
 }
 
@@ -111,11 +123,15 @@ type SandwichConfiguration struct {
 		// URL allows for variables:
 		// {shard_id}, {shard_count}, {token} {token_hash}, {max_concurrency}
 		URL string `json:"url" yaml:"url"`
+
+		Coordinator IdentifyCoordinatorConfiguration `json:"coordinator" yaml:"coordinator"`
 	} `json:"identify" yaml:"identify"`
 
 	Producer struct {
 		Configuration map[string]interface{} `json:"configuration" yaml:"configuration"`
 		Type          string                 `json:"type" yaml:"type"`
+		// Spool payloads that fail to publish to disk, replaying them once the producer recovers.
+		Spool SpoolConfiguration `json:"spool" yaml:"spool"`
 	} `json:"producer" yaml:"producer"`
 
 	HTTP struct {
@@ -124,8 +140,41 @@ type SandwichConfiguration struct {
 
 		// List of discord user IDs that can access the dashboard.
 		UserAccess []string `json:"user_access" yaml:"user_access"`
+
+		// Required to use the proxy. Proxy requests must send the secret as their Authorization header.
+		ProxySecret string `json:"proxy_secret" yaml:"proxy_secret"`
 	} `json:"http" yaml:"http"`
 
+	Sessions struct {
+		// Path of the file shard sessions are saved to on shutdown, allowing shards
+		// to resume on the next start instead of identifying. Disabled if empty.
+		Path string `json:"path" yaml:"path"`
+		// Number of seconds a saved session can be resumed for. Defaults to 120.
+		Expiry int32 `json:"expiry" yaml:"expiry"`
+	} `json:"sessions" yaml:"sessions"`
+
+	State struct {
+		// Backend used to store state. Either "memory" or "redis". Defaults to "memory".
+		// The backend is only created on start.
+		Backend string                  `json:"backend" yaml:"backend"`
+		Redis   RedisStateConfiguration `json:"redis" yaml:"redis"`
+
+		Members MemberRetentionConfiguration `json:"members" yaml:"members"`
+
+		Messages MessageCacheConfiguration `json:"messages" yaml:"messages"`
+	} `json:"state" yaml:"state"`
+
+	Snapshots struct {
+		// Path of the file state snapshots are written to. State is loaded from
+		// this file on start. Disabled if empty.
+		Path string `json:"path" yaml:"path"`
+		// Number of seconds between snapshots. Defaults to 300.
+		Interval int32 `json:"interval" yaml:"interval"`
+		// Number of seconds guilds loaded from a snapshot are kept without receiving
+		// a GUILD_CREATE before they can be ejected. Defaults to 600.
+		StaleTimeout int32 `json:"stale_timeout" yaml:"stale_timeout"`
+	} `json:"snapshots" yaml:"snapshots"`
+
 	Webhooks []string `json:"webhooks" yaml:"webhooks"`
 
 	Managers []ManagerConfiguration `json:"managers" yaml:"managers"`
@@ -142,6 +191,13 @@ type SandwichOptions struct {
 
 	HTTPHost    string `json:"http_host" yaml:"http_host"`
 	HTTPEnabled bool   `json:"http_enabled" yaml:"http_enabled"`
+
+	// Network and host to serve gRPC on. gRPC is disabled if the host is empty.
+	GRPCNetwork string `json:"grpc_network" yaml:"grpc_network"`
+	GRPCHost    string `json:"grpc_host" yaml:"grpc_host"`
+
+	// Token gRPC clients must send as the authorization metadata. gRPC is unauthenticated if empty.
+	GRPCToken string `json:"grpc_token" yaml:"grpc_token"`
 }
 
 type GuildChunks struct {
@@ -186,8 +242,12 @@ func NewSandwich(logger io.Writer, options SandwichOptions) (sg *Sandwich, err e
 			csmap.WithSize[string, int64](10),
 		),
 
+		MemberRates: NewCache[discord.GuildID, *GuildMemberRate](10),
+
 		IdentifyBuckets: bucketstore.NewBucketStore(),
 
+		Sessions: NewSessionStore(),
+
 		EventsInflight: atomic.NewInt32(0),
 
 		State: NewSandwichState(),
@@ -221,11 +281,20 @@ func NewSandwich(logger io.Writer, options SandwichOptions) (sg *Sandwich, err e
 
 	sg.Configuration = configuration
 
-	sg.Client = NewClient(baseURL, "")
+	sg.Client = NewClient(sg.BaseURL(), "")
 
 	return sg, nil
 }
 
+// BaseURL returns the URL HTTP requests to Discord are sent to.
+func (sg *Sandwich) BaseURL() url.URL {
+	if sg.Options.BaseURL.Host != "" {
+		return sg.Options.BaseURL
+	}
+
+	return baseURL
+}
+
 // LoadConfiguration handles loading the configuration file.
 func (sg *Sandwich) LoadConfiguration(path string) (configuration SandwichConfiguration, err error) {
 	sg.Logger.Debug().
@@ -287,12 +356,39 @@ func (sg *Sandwich) Open() {
 
 	go sg.PublishSimpleWebhook("Starting sandwich", "", "Version "+VERSION, EmbedColourSandwich)
 
+	sg.setupStateBackend()
+	sg.setupIdentifyCoordinator()
+
 	// Setup Prometheus
 	go sg.setupPrometheus()
 
 	// Setup HTTP
 	go sg.setupHTTP()
 
+	// Setup gRPC
+	if sg.Options.GRPCHost != "" {
+		sg.grpcServer = sg.NewGRPCServer()
+
+		go sg.setupGRPC()
+	}
+
+	sg.loadSessions()
+	sg.loadSnapshot()
+
+	if sg.snapshotsEnabled() {
+		go sg.snapshotter()
+	}
+
+	if sg.spoolEnabled() {
+		go sg.spoolReplayer()
+	}
+
+	go sg.autoRescaler()
+	go sg.backpressureSampler()
+
+	sg.State.TrackMemberActivity.Store(sg.memberRetention().Retention == MemberRetentionActive)
+	sg.State.SetMessageCache(sg.messageCache())
+
 	sg.Logger.Info().Msg("Creating managers")
 	sg.startManagers()
 }
@@ -318,7 +414,7 @@ func (sg *Sandwich) PublishGlobalEvent(eventType string, data json.RawMessage) e
 			sg.Logger.Error().Err(err).Msg("Failed to route payload to consumer")
 		}
 
-		err = manager.ProducerClient.Publish(sg.ctx, packet, manager.Configuration.Messaging.ChannelName)
+		err = manager.publish(sg.ctx, packet, manager.Configuration.Messaging.ChannelName)
 
 		if err != nil {
 			sg.Logger.Error().Err(err).Msg("Failed to publish event")
@@ -341,6 +437,12 @@ func (sg *Sandwich) Close() error {
 		return false
 	})
 
+	sg.saveSnapshot()
+
+	if sg.grpcServer != nil {
+		sg.grpcServer.GracefulStop()
+	}
+
 	if sg.cancel != nil {
 		sg.cancel()
 	}
@@ -407,9 +509,18 @@ func (sg *Sandwich) setupPrometheus() error {
 	prometheus.MustRegister(sandwichStateUserCount)
 	prometheus.MustRegister(sandwichStateChannelCount)
 	prometheus.MustRegister(sandwichStateVoiceStatesCount)
+	prometheus.MustRegister(sandwichStateMemberCacheHits)
+	prometheus.MustRegister(sandwichStateMemberCacheMisses)
+	prometheus.MustRegister(sandwichStateMemberCacheEvictions)
+	prometheus.MustRegister(sandwichGuildJoinRate)
+	prometheus.MustRegister(sandwichGuildLeaveRate)
 	prometheus.MustRegister(grpcCacheRequests)
 	prometheus.MustRegister(grpcCacheHits)
 	prometheus.MustRegister(grpcCacheMisses)
+	prometheus.MustRegister(sandwichSpoolDepth)
+	prometheus.MustRegister(sandwichSpoolAge)
+	prometheus.MustRegister(sandwichSpoolDropped)
+	prometheus.MustRegister(sandwichProducerRouteDropped)
 
 	http.Handle("/metrics", promhttp.HandlerFor(
 		prometheus.DefaultGatherer,
@@ -487,17 +598,34 @@ func (sg *Sandwich) cacheEjector() {
 
 		ejectedGuilds := make([]discord.GuildID, 0)
 
-		sg.State.Guilds.Range(func(guildID discord.GuildID, guild discord.Guild) bool {
-			if val, ok := allGuildIDs[guildID]; !val || !ok {
-				ejectedGuilds = append(ejectedGuilds, guildID)
+		// Shared backends hold the guilds of other processes, which remove their own guilds.
+		if !sg.State.Backend.Shared() {
+			for _, guildID := range sg.State.GetAllGuildIDs() {
+				// Keep guilds loaded from a snapshot until shards have had a chance to receive them.
+				if deadline, stale := sg.State.StaleGuilds.Load(guildID); stale && now < deadline {
+					continue
+				}
+
+				if val, ok := allGuildIDs[guildID]; !val || !ok {
+					ejectedGuilds = append(ejectedGuilds, guildID)
+				}
 			}
-			return false
-		})
+		}
 
 		for _, guildID := range ejectedGuilds {
 			sg.State.RemoveGuild(cacheEjectorStateCtx, guildID)
 		}
 
+		// Member Ejector
+		ejectedMembers := sg.ejectGuildMembers()
+
+		// Audit Correlation Ejector
+		sg.ejectAuditCorrelations()
+
+		// Message Ejector
+		sg.State.SetMessageCache(sg.messageCache())
+		ejectedMessageChannels := sg.State.ExpireMessages()
+
 		ejectedDedupes := make([]string, 0)
 
 		// MemberDedup Ejector
@@ -517,6 +645,8 @@ func (sg *Sandwich) cacheEjector() {
 		sg.Logger.Debug().
 			Int("guildsEjected", len(ejectedGuilds)).
 			Int("guildsTotal", len(allGuildIDs)).
+			Int("membersEjected", ejectedMembers).
+			Int("messageChannelsEjected", ejectedMessageChannels).
 			Int("ejectedDedupes", len(ejectedDedupes)).
 			Msg("Ejected cache")
 	}
@@ -526,76 +656,36 @@ func (sg *Sandwich) prometheusGatherer() {
 	t := time.NewTicker(prometheusGatherInterval)
 
 	for range t.C {
-		stateTotalMemberCount := 0
-		stateGuilds := 0
-
-		sg.State.Guilds.Range(func(guildID discord.GuildID, guild discord.Guild) bool {
-			stateTotalMemberCount += int(guild.MemberCount)
-			stateGuilds++
-			return false
-		})
-
-		sg.LastKnownTotalMembers = stateTotalMemberCount
-
-		stateMembers := 0
-		stateRoles := 0
-		stateEmojis := 0
-		stateChannels := 0
-		stateUsers := 0
-		stateVoiceStates := 0
-
-		sg.State.GuildMembers.Range(func(guildID discord.GuildID, guildMembers Cache[discord.UserID, discord.GuildMember]) bool {
-			stateMembers += guildMembers.Count()
-			return false
-		})
-
-		sg.State.GuildRoles.Range(func(guildID discord.GuildID, guildRoles Cache[discord.RoleID, discord.Role]) bool {
-			stateRoles += guildRoles.Count()
-			return false
-		})
-
-		sg.State.GuildEmojis.Range(func(guildID discord.GuildID, guildEmojis []discord.Emoji) bool {
-			stateEmojis += len(guildEmojis)
-			return false
-		})
+		counts := sg.State.Backend.Counts()
 
-		sg.State.GuildChannels.Range(func(guildID discord.GuildID, guildChannels Cache[discord.ChannelID, discord.Channel]) bool {
-			stateChannels += guildChannels.Count()
-			return false
-		})
+		sg.LastKnownTotalMembers = counts.TotalMembers
+		sg.LastKnownUsers = counts.Users
 
-		stateUsers = sg.State.Users.Count()
-
-		sg.State.GuildVoiceStates.Range(func(guildID discord.GuildID, guildVoiceStates Cache[discord.UserID, discord.VoiceState]) bool {
-			stateVoiceStates += guildVoiceStates.Count()
-			return false
-		})
-
-		sandwichStateTotalCount.Set(float64(
-			stateGuilds + stateMembers + stateRoles + stateEmojis + stateUsers + stateChannels + stateVoiceStates,
-		))
+		sandwichStateTotalCount.Set(float64(counts.Total()))
 
 		eventsInflight := sg.EventsInflight.Load()
 
-		sandwichStateGuildCount.Set(float64(stateGuilds))
-		sandwichStateGuildMembersCount.Set(float64(stateMembers))
-		sandwichStateRoleCount.Set(float64(stateRoles))
-		sandwichStateEmojiCount.Set(float64(stateEmojis))
-		sandwichStateUserCount.Set(float64(stateUsers))
-		sandwichStateChannelCount.Set(float64(stateChannels))
-		sandwichStateVoiceStatesCount.Set(float64(stateVoiceStates))
+		sandwichStateGuildCount.Set(float64(counts.Guilds))
+		sandwichStateGuildMembersCount.Set(float64(counts.Members))
+		sandwichStateRoleCount.Set(float64(counts.Roles))
+		sandwichStateEmojiCount.Set(float64(counts.Emojis))
+		sandwichStateUserCount.Set(float64(counts.Users))
+		sandwichStateChannelCount.Set(float64(counts.Channels))
+		sandwichStateVoiceStatesCount.Set(float64(counts.VoiceStates))
 
 		sandwichEventInflightCount.Set(float64(eventsInflight))
 
+		sg.gatherMemberRates()
+
 		sg.Logger.Debug().
-			Int("guilds", stateGuilds).
-			Int("members", stateMembers).
-			Int("totalMembers", stateTotalMemberCount).
-			Int("roles", stateRoles).
-			Int("emojis", stateEmojis).
-			Int("users", stateUsers).
-			Int("channels", stateChannels).
-			Int("voiceStates", stateVoiceStates).
+			Int("guilds", counts.Guilds).
+			Int("members", counts.Members).
+			Int("totalMembers", counts.TotalMembers).
+			Int("roles", counts.Roles).
+			Int("emojis", counts.Emojis).
+			Int("users", counts.Users).
+			Int("channels", counts.Channels).
+			Int("voiceStates", counts.VoiceStates).
 			Int32("eventsInflight", eventsInflight).
 			Msg("Updated prometheus gauges")
 	}
@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+// Time a saved shard session can be resumed for if no expiry is configured.
+const DefaultSessionExpiry = 2 * time.Minute
+
+// ShardSession represents the state required for a shard to resume instead of identifying.
+type ShardSession struct {
+	SessionID        string            `json:"session_id"`
+	ResumeGatewayURL string            `json:"resume_gateway_url"`
+	Guilds           []discord.GuildID `json:"guilds"`
+	ExpiresAt        discord.Int64     `json:"expires_at"`
+	Sequence         int32             `json:"sequence"`
+	ShardCount       int32             `json:"shard_count"`
+}
+
+// SessionStore holds shard sessions for all managers, keyed by manager identifier and shard ID.
+type SessionStore struct {
+	Managers map[string]map[int32]ShardSession `json:"managers"`
+
+	mu sync.Mutex
+}
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		Managers: make(map[string]map[int32]ShardSession),
+	}
+}
+
+// Store adds or replaces the session of a shard.
+func (ss *SessionStore) Store(identifier string, shardID int32, session ShardSession) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	shards, ok := ss.Managers[identifier]
+	if !ok {
+		shards = make(map[int32]ShardSession)
+		ss.Managers[identifier] = shards
+	}
+
+	shards[shardID] = session
+}
+
+// Take returns the session of a shard and removes it from the store. Sessions are only
+// returned if they have not expired and were created with the same shard count.
+func (ss *SessionStore) Take(identifier string, shardID int32, shardCount int32) (session ShardSession, ok bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	shards, ok := ss.Managers[identifier]
+	if !ok {
+		return
+	}
+
+	session, ok = shards[shardID]
+	if !ok {
+		return
+	}
+
+	delete(shards, shardID)
+
+	if len(shards) == 0 {
+		delete(ss.Managers, identifier)
+	}
+
+	if session.ShardCount != shardCount || int64(session.ExpiresAt) < time.Now().Unix() {
+		return session, false
+	}
+
+	return session, true
+}
+
+// Count returns the number of sessions in the store.
+func (ss *SessionStore) Count() (count int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for _, shards := range ss.Managers {
+		count += len(shards)
+	}
+
+	return count
+}
+
+// Save writes the store to a file.
+func (ss *SessionStore) Save(path string) error {
+	ss.mu.Lock()
+	data, err := sandwichjson.Marshal(ss)
+	ss.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal sessions: %w", err)
+	}
+
+	err = os.WriteFile(path, data, PermissionWrite)
+	if err != nil {
+		return fmt.Errorf("failed to write sessions to file: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads the store from a file, discarding any sessions that have expired.
+// A missing file is not treated as an error.
+func (ss *SessionStore) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to read sessions file: %w", err)
+	}
+
+	var loaded SessionStore
+
+	err = sandwichjson.Unmarshal(data, &loaded)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal sessions: %w", err)
+	}
+
+	now := time.Now().Unix()
+
+	for identifier, shards := range loaded.Managers {
+		for shardID, session := range shards {
+			if int64(session.ExpiresAt) >= now {
+				ss.Store(identifier, shardID, session)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sessionsEnabled returns if shard sessions should be persisted on shutdown.
+func (sg *Sandwich) sessionsEnabled() bool {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	return sg.Configuration.Sessions.Path != ""
+}
+
+// sessionExpiry returns how long a saved session can be resumed for.
+func (sg *Sandwich) sessionExpiry() time.Duration {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	if sg.Configuration.Sessions.Expiry > 0 {
+		return time.Duration(sg.Configuration.Sessions.Expiry) * time.Second
+	}
+
+	return DefaultSessionExpiry
+}
+
+// loadSessions loads any saved shard sessions. The file is removed once loaded
+// as sessions can only be resumed once.
+func (sg *Sandwich) loadSessions() {
+	sg.configurationMu.RLock()
+	path := sg.Configuration.Sessions.Path
+	sg.configurationMu.RUnlock()
+
+	if path == "" {
+		return
+	}
+
+	err := sg.Sessions.Load(path)
+	if err != nil {
+		sg.Logger.Warn().Err(err).Str("path", path).Msg("Failed to load shard sessions")
+
+		return
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		sg.Logger.Warn().Err(err).Str("path", path).Msg("Failed to remove shard sessions file")
+	}
+
+	sg.Logger.Info().Int("sessions", sg.Sessions.Count()).Msg("Loaded shard sessions")
+}
+
+// saveSessions writes all stored shard sessions to disk.
+func (sg *Sandwich) saveSessions() {
+	sg.configurationMu.RLock()
+	path := sg.Configuration.Sessions.Path
+	sg.configurationMu.RUnlock()
+
+	if path == "" {
+		return
+	}
+
+	err := sg.Sessions.Save(path)
+	if err != nil {
+		sg.Logger.Error().Err(err).Str("path", path).Msg("Failed to save shard sessions")
+
+		return
+	}
+
+	sg.Logger.Info().Int("sessions", sg.Sessions.Count()).Msg("Saved shard sessions")
+}
+
+// storeSession stores the current session of the shard, if it has one.
+func (sh *Shard) storeSession() {
+	sessionID := sh.SessionID.Load()
+	sequence := sh.Sequence.Load()
+
+	if sessionID == "" || sequence == 0 {
+		return
+	}
+
+	guilds := make([]discord.GuildID, 0, sh.Guilds.Count())
+
+	sh.Guilds.Range(func(guildID discord.GuildID, _ struct{}) bool {
+		guilds = append(guilds, guildID)
+		return false
+	})
+
+	sh.Sandwich.Sessions.Store(sh.Manager.Identifier.Load(), sh.ShardID, ShardSession{
+		SessionID:        sessionID,
+		ResumeGatewayURL: sh.ResumeGatewayURL.Load(),
+		Guilds:           guilds,
+		ExpiresAt:        discord.Int64(time.Now().Add(sh.Sandwich.sessionExpiry()).Unix()),
+		Sequence:         sequence,
+		ShardCount:       sh.ShardGroup.ShardCount,
+	})
+}
+
+// restoreSession populates the shard with a saved session, if one is present,
+// so the shard will resume when connecting.
+func (sh *Shard) restoreSession() {
+	session, ok := sh.Sandwich.Sessions.Take(sh.Manager.Identifier.Load(), sh.ShardID, sh.ShardGroup.ShardCount)
+	if !ok {
+		return
+	}
+
+	sh.SessionID.Store(session.SessionID)
+	sh.Sequence.Store(session.Sequence)
+	sh.ResumeGatewayURL.Store(session.ResumeGatewayURL)
+
+	for _, guildID := range session.Guilds {
+		sh.Guilds.Store(guildID, struct{}{})
+		sh.ShardGroup.Guilds.Store(guildID, struct{}{})
+	}
+
+	sh.Logger.Info().
+		Str("session_id", session.SessionID).
+		Int32("sequence", session.Sequence).
+		Int("guilds", len(session.Guilds)).
+		Msg("Restored saved session")
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"go.uber.org/atomic"
+)
+
+func newTestSessionShard(sandwich *Sandwich, shardCount int32) *Shard {
+	return &Shard{
+		Sandwich: sandwich,
+		Manager:  &Manager{Identifier: atomic.NewString("sandwich")},
+		ShardGroup: &ShardGroup{
+			ShardCount: shardCount,
+			Guilds:     NewCache[discord.GuildID, struct{}](0),
+		},
+		ShardID:          1,
+		Guilds:           NewCache[discord.GuildID, struct{}](0),
+		Sequence:         atomic.NewInt32(0),
+		SessionID:        atomic.NewString(""),
+		ResumeGatewayURL: atomic.NewString(""),
+	}
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	sandwich := &Sandwich{
+		Sessions: NewSessionStore(),
+	}
+
+	shard := newTestSessionShard(sandwich, 2)
+	shard.SessionID.Store("session")
+	shard.Sequence.Store(42)
+	shard.ResumeGatewayURL.Store("wss://gateway.discord.gg")
+	shard.Guilds.Store(3, struct{}{})
+
+	shard.storeSession()
+
+	if err := sandwich.Sessions.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	sandwich.Sessions = NewSessionStore()
+
+	if err := sandwich.Sessions.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newTestSessionShard(sandwich, 2)
+	restored.restoreSession()
+
+	if restored.SessionID.Load() != "session" || restored.Sequence.Load() != 42 ||
+		restored.ResumeGatewayURL.Load() != "wss://gateway.discord.gg" {
+		t.Fatalf("unexpected restored session %s %d %s", restored.SessionID.Load(), restored.Sequence.Load(), restored.ResumeGatewayURL.Load())
+	}
+
+	if !restored.Guilds.Has(3) || !restored.ShardGroup.Guilds.Has(3) {
+		t.Error("expected guilds of the session to be restored")
+	}
+
+	// Sessions can only be resumed once.
+	if sandwich.Sessions.Count() != 0 {
+		t.Errorf("expected session to be taken, got %d sessions", sandwich.Sessions.Count())
+	}
+}
+
+func TestSessionNotResumable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	store := NewSessionStore()
+	store.Store("sandwich", 1, ShardSession{SessionID: "expired", ExpiresAt: discord.Int64(time.Now().Add(-time.Minute).Unix()), ShardCount: 2})
+	store.Store("sandwich", 2, ShardSession{SessionID: "current", ExpiresAt: discord.Int64(time.Now().Add(time.Minute).Unix()), ShardCount: 2})
+
+	if err := store.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewSessionStore()
+
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expired sessions are discarded when loading.
+	if loaded.Count() != 1 {
+		t.Fatalf("expected only the current session to be loaded, got %d sessions", loaded.Count())
+	}
+
+	if _, ok := loaded.Take("sandwich", 1, 2); ok {
+		t.Error("expected expired session not to be resumable")
+	}
+
+	// Sessions of a different shard count are not resumed and are discarded.
+	shard := newTestSessionShard(&Sandwich{Sessions: loaded}, 4)
+	shard.ShardID = 2
+	shard.restoreSession()
+
+	if shard.SessionID.Load() != "" || loaded.Count() != 0 {
+		t.Errorf("expected session of a different shard count not to be restored, got %q", shard.SessionID.Load())
+	}
+}
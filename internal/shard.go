@@ -92,6 +92,17 @@ type Shard struct {
 
 	wsConn *websocket.Conn
 
+	// Transport compression of the current connection and its decompression context.
+	// Guarded by wsConnMu.
+	compression  GatewayCompression
+	decompressor *streamDecompressor
+
+	// Encoding of the current connection. Guarded by wsConnMu.
+	encoding GatewayEncoding
+
+	// Set once transport compression has failed, so the shard reconnects without it.
+	compressionFallback atomic.Bool
+
 	wsRatelimit *limiter.DurationLimiter
 
 	ready chan void
@@ -102,6 +113,11 @@ type Shard struct {
 	// Duration since last heartbeat Ack before reconnecting.
 	HeartbeatFailureInterval time.Duration `json:"-"`
 
+	// Start of the current heartbeat interval and how long reading has been paused for
+	// backpressure within it. Only used by Listen.
+	backpressureWindow time.Time
+	backpressurePaused time.Duration
+
 	statusMu sync.RWMutex
 
 	wsConnMu sync.RWMutex
@@ -175,6 +191,8 @@ func (sg *ShardGroup) NewShard(shardID int32) (sh *Shard) {
 
 	sh.ctx, sh.cancel = context.WithCancel(sg.Manager.ctx)
 
+	sh.restoreSession()
+
 	return sh
 }
 
@@ -202,22 +220,59 @@ func (sh *Shard) Open() {
 }
 
 func (sh *Shard) readMessage() (payload discord.GatewayPayload, err error) {
-	messageType, data, connectionErr := sh.wsConn.Read(sh.ctx)
-	if connectionErr != nil {
-		select {
-		case <-sh.ctx.Done():
+	sh.wsConnMu.RLock()
+	decompressor := sh.decompressor
+	encoding := sh.encoding
+	sh.wsConnMu.RUnlock()
+
+	var messageType websocket.MessageType
+
+	var data []byte
+
+	var connectionErr error
+
+	// With transport compression, a message can be split across frames which are read until it is complete.
+	for {
+		messageType, data, connectionErr = sh.wsConn.Read(sh.ctx)
+		if connectionErr != nil {
+			select {
+			case <-sh.ctx.Done():
+				return payload, connectionErr
+			default:
+			}
+
+			sh.Logger.Error().Err(connectionErr).Msg("Failed to read from gateway")
+
 			return payload, connectionErr
-		default:
 		}
 
-		sh.Logger.Error().Err(connectionErr).Msg("Failed to read from gateway")
+		if messageType != websocket.MessageBinary || decompressor == nil {
+			break
+		}
 
-		return payload, connectionErr
+		var complete bool
+
+		data, complete, connectionErr = decompressor.Decompress(data)
+		if connectionErr != nil {
+			if !sh.compressionFallback.Swap(true) {
+				sh.Logger.Warn().Str("compression", string(decompressor.compression)).
+					Msg("Transport compression failed, falling back to payload compression on reconnect")
+			}
+
+			sh.Logger.Error().Err(connectionErr).Msg("Failed to decompress data")
+
+			return payload, connectionErr
+		}
+
+		if complete {
+			break
+		}
 	}
 
 	sandwichEventCount.WithLabelValues(sh.Manager.Identifier.Load()).Add(1)
 
-	if messageType == websocket.MessageBinary {
+	// ETF frames are binary, but only compressed when they are large.
+	if messageType == websocket.MessageBinary && decompressor == nil && (encoding != GatewayEncodingETF || !isETF(data)) {
 		data, connectionErr = czlib.Decompress(data)
 		if connectionErr != nil {
 			sh.Logger.Error().Err(connectionErr).Msg("Failed to decompress data")
@@ -226,6 +281,17 @@ func (sh *Shard) readMessage() (payload discord.GatewayPayload, err error) {
 		}
 	}
 
+	if encoding == GatewayEncodingETF {
+		payload, connectionErr = DecodeETFGatewayPayload(data)
+		if connectionErr != nil {
+			sh.Logger.Error().Err(connectionErr).Msg("Failed to decode message")
+
+			return payload, connectionErr
+		}
+
+		return payload, nil
+	}
+
 	msg, _ := sh.Sandwich.receivedPool.Get().(*discord.GatewayPayload)
 
 	connectionErr = json.Unmarshal(data, &msg)
@@ -514,6 +580,8 @@ func (sh *Shard) Listen(ctx context.Context) error {
 		default:
 		}
 
+		sh.waitForBackpressure(ctx)
+
 		msg, err := sh.readMessage()
 
 		var trace map[string]discord.Int64
@@ -563,6 +631,10 @@ func (sh *Shard) Listen(ctx context.Context) error {
 
 					sh.ShardGroup.Error.Store(err.Error())
 
+					if closeError.Code == discord.CloseShardingRequired {
+						go sh.Manager.autoRescale(true)
+					}
+
 					return err
 				default:
 					sh.Logger.Warn().Msgf("Websocket was closed with code %d", closeError.Code)
@@ -602,12 +674,32 @@ func (sh *Shard) dial(ctx context.Context, u string, opts *websocket.DialOptions
 		return fmt.Errorf("failed to parse url: %w", err)
 	}
 
-	// Add version and encoding
-	urlp.RawQuery = rawQuery
+	compression := sh.transportCompression()
+	encoding := sh.gatewayEncoding()
+
+	var decompressor *streamDecompressor
+
+	if compression != GatewayCompressionPayload {
+		decompressor, err = newStreamDecompressor(compression)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add version, encoding and compression
+	urlp.RawQuery = rawQuery + "&encoding=" + string(encoding)
+	if compression != GatewayCompressionPayload {
+		urlp.RawQuery += "&compress=" + string(compression)
+	}
+
 	u = urlp.String()
 
 	conn, _, err := websocket.Dial(ctx, u, opts)
 	if err != nil {
+		if decompressor != nil {
+			decompressor.Close()
+		}
+
 		sh.Logger.Error().Err(err).Msg("Failed to dial websocket")
 		sh.ResumeGatewayURL.Store("")
 
@@ -619,12 +711,57 @@ func (sh *Shard) dial(ctx context.Context, u string, opts *websocket.DialOptions
 	conn.SetReadLimit(-1)
 
 	sh.wsConnMu.Lock()
+	if sh.decompressor != nil {
+		sh.decompressor.Close()
+	}
+
 	sh.wsConn = conn
+	sh.compression = compression
+	sh.decompressor = decompressor
+	sh.encoding = encoding
 	sh.wsConnMu.Unlock()
 
 	return nil
 }
 
+// transportCompression returns the transport compression to use for the next connection.
+func (sh *Shard) transportCompression() GatewayCompression {
+	if sh.compressionFallback.Load() {
+		return GatewayCompressionPayload
+	}
+
+	sh.Manager.configurationMu.RLock()
+	compression := sh.Manager.Configuration.Bot.Compression
+	sh.Manager.configurationMu.RUnlock()
+
+	if !compression.IsValid() {
+		sh.Logger.Warn().Str("compression", string(compression)).Msg("Unknown gateway compression, using payload compression")
+
+		return GatewayCompressionPayload
+	}
+
+	return compression
+}
+
+// gatewayEncoding returns the encoding to use for the next connection.
+func (sh *Shard) gatewayEncoding() GatewayEncoding {
+	sh.Manager.configurationMu.RLock()
+	encoding := sh.Manager.Configuration.Bot.Encoding
+	sh.Manager.configurationMu.RUnlock()
+
+	if encoding == "" {
+		return GatewayEncodingJSON
+	}
+
+	if !encoding.IsValid() {
+		sh.Logger.Warn().Str("encoding", string(encoding)).Msg("Unknown gateway encoding, using json")
+
+		return GatewayEncodingJSON
+	}
+
+	return encoding
+}
+
 // Identify sends the identify packet to discord.
 func (sh *Shard) Identify(ctx context.Context) error {
 	sh.Manager.gatewayMu.Lock()
@@ -644,6 +781,10 @@ func (sh *Shard) Identify(ctx context.Context) error {
 	intents := sh.Manager.Configuration.Bot.Intents
 	sh.Manager.configurationMu.RUnlock()
 
+	sh.wsConnMu.RLock()
+	compression := sh.compression
+	sh.wsConnMu.RUnlock()
+
 	sh.Logger.Debug().Msg("Sending identify")
 
 	return sh.SendEvent(ctx, discord.GatewayOpIdentify, discord.Identify{
@@ -653,7 +794,8 @@ func (sh *Shard) Identify(ctx context.Context) error {
 			Browser: "Sandwich " + VERSION,
 			Device:  "Sandwich " + VERSION,
 		},
-		Compress:       true,
+		// Payloads are not compressed individually when the connection is compressed.
+		Compress:       compression == GatewayCompressionPayload,
 		LargeThreshold: GatewayLargeThreshold,
 		Shard:          [2]int32{sh.ShardID, sh.ShardGroup.ShardCount},
 		Presence:       &presence,
@@ -751,9 +893,21 @@ func (sh *Shard) WriteJSON(ctx context.Context, op discord.GatewayOp, i interfac
 
 	sh.wsConnMu.RLock()
 	wsConn := sh.wsConn
+	encoding := sh.encoding
 	sh.wsConnMu.RUnlock()
 
-	err = wsConn.Write(ctx, websocket.MessageText, res)
+	messageType := websocket.MessageText
+
+	if encoding == GatewayEncodingETF {
+		res, err = EncodeETF(res)
+		if err != nil {
+			return fmt.Errorf("failed to encode payload: %w", err)
+		}
+
+		messageType = websocket.MessageBinary
+	}
+
+	err = wsConn.Write(ctx, messageType, res)
 	if err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
@@ -761,9 +915,17 @@ func (sh *Shard) WriteJSON(ctx context.Context, op discord.GatewayOp, i interfac
 	return nil
 }
 
-// decodeContent converts the stored msg into the passed interface.
+// decodeContent converts the stored msg into the passed interface. Data received with ETF is decoded
+// without going through JSON.
 func (sh *Shard) decodeContent(msg discord.GatewayPayload, out interface{}) error {
-	err := sandwichjson.Unmarshal(msg.Data, &out)
+	var err error
+
+	if isETF(msg.Data) {
+		err = UnmarshalETF(msg.Data, out)
+	} else {
+		err = sandwichjson.Unmarshal(msg.Data, &out)
+	}
+
 	if err != nil {
 		sh.Logger.Error().Err(err).Str("type", msg.Type).Msg("Failed to decode event")
 
@@ -792,8 +954,7 @@ func (sh *Shard) Close(code websocket.StatusCode, intermittentGwIssue bool) {
 	}
 
 	// Try killing the producer's shard as well
-	pc := sh.Manager.ProducerClient
-	if pc != nil {
+	for _, pc := range sh.Manager.producerClients() {
 		if intermittentGwIssue {
 			pc.CloseShard(sh.ShardID, MQCloseShardReasonGateway)
 		} else {
@@ -820,7 +981,13 @@ func (sh *Shard) CloseWS(statusCode websocket.StatusCode) error {
 			}
 		}
 
+		if sh.decompressor != nil {
+			sh.decompressor.Close()
+		}
+
 		sh.wsConn = nil
+		sh.compression = GatewayCompressionPayload
+		sh.decompressor = nil
 		sh.wsConnMu.Unlock()
 	}
 
@@ -955,13 +1122,9 @@ func (sh *Shard) ChunkGuild(
 	var memberCount int
 	var needsChunking bool
 
-	gm, ok := sh.Sandwich.State.GuildMembers.Inner(guildID)
-
-	if ok {
-		memberCount = gm.Count()
-	}
+	memberCount = sh.Sandwich.State.CountGuildMembers(guildID)
 
-	guild, ok := sh.Sandwich.State.Guilds.Load(guildID)
+	guild, ok := sh.Sandwich.State.Backend.GetGuild(guildID)
 
 	if !ok {
 		sh.Sandwich.Logger.Warn().Int64("guild_id", int64(guildID)).Msg("Guild not found in state")
@@ -98,12 +98,7 @@ func (mg *Manager) NewShardGroup(shardGroupID int32, shardIDs []int32, shardCoun
 func (sg *ShardGroup) Open() (ready chan bool, err error) {
 	sg.Start.Store(time.Now().UTC())
 
-	sg.Manager.ShardGroups.Range(func(i int32, shardGroup *ShardGroup) bool {
-		if shardGroup.GetStatus() != sandwich_structs.ShardGroupStatusErroring && shardGroup.ID != sg.ID {
-			shardGroup.SetStatus(sandwich_structs.ShardGroupStatusMarkedForClosure)
-		}
-		return false
-	})
+	sg.Manager.startRescale(sg)
 
 	ready = make(chan bool, 1)
 
@@ -120,6 +115,8 @@ func (sg *ShardGroup) Open() (ready chan bool, err error) {
 	sg.shardIdsMu.Unlock()
 
 	if len(sg.ShardIDs) == 0 {
+		sg.Manager.failRescale(sg, ErrMissingShards)
+
 		return nil, ErrMissingShards
 	}
 
@@ -153,6 +150,8 @@ func (sg *ShardGroup) Open() (ready chan bool, err error) {
 
 				sg.SetStatus(sandwich_structs.ShardGroupStatusErroring)
 
+				sg.Manager.failRescale(sg, err)
+
 				sg.Close()
 
 				return
@@ -221,20 +220,7 @@ func (sg *ShardGroup) Open() (ready chan bool, err error) {
 		sg.Logger.Info().Msg("All shards are now ready")
 		sg.allShardsReady.Store(true)
 
-		sg.Manager.ShardGroups.Range(func(shardGroupID int32, shardGroup *ShardGroup) bool {
-			if shardGroupID != sg.ID {
-				sg.floodgateMu.Lock()
-				sg.floodgate = false
-				sg.floodgateMu.Unlock()
-
-				shardGroup.Close()
-			}
-			return false
-		})
-
-		sg.floodgateMu.Lock()
-		sg.floodgate = true
-		sg.floodgateMu.Unlock()
+		sg.Manager.handoff(sg)
 
 		close(ready)
 	}(sg)
@@ -248,13 +234,26 @@ func (sg *ShardGroup) Close() {
 
 	sg.SetStatus(sandwich_structs.ShardGroupStatusClosing)
 
+	// When the manager is shutting down, keep the sessions of shards so they can be
+	// resumed on the next start. Closing with a normal closure would invalidate them.
+	persistSessions := sg.Manager.IsClosing && sg.Manager.Sandwich.sessionsEnabled()
+
+	closeCode := websocket.StatusNormalClosure
+	if persistSessions {
+		closeCode = WebsocketReconnectCloseCode
+	}
+
 	closeWaiter := sync.WaitGroup{}
 
 	sg.Shards.Range(func(i int32, sh *Shard) bool {
+		if persistSessions {
+			sh.storeSession()
+		}
+
 		closeWaiter.Add(1)
 
 		go func(sh *Shard) {
-			sh.Close(websocket.StatusNormalClosure, false)
+			sh.Close(closeCode, false)
 			closeWaiter.Done()
 		}(sh)
 
@@ -0,0 +1,439 @@
+package internal
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+)
+
+// Snapshots start with a fixed header followed by a zlib compressed body.
+// The header consists of the magic, a big endian uint16 version and a big
+// endian int64 unix timestamp of when the snapshot was created.
+//
+// The body contains a section for each collection in MemoryStateBackend, in the
+// order they are written by WriteSnapshot. Each entry in a section is
+// prefixed with snapshotEntry and contains a varint key followed by either a
+// length prefixed JSON value or, for a DoubleCache, a nested section. Sections
+// are terminated with snapshotSectionEnd.
+const (
+	snapshotMagic   = "SWSS"
+	SnapshotVersion = 1
+
+	snapshotEntry      byte = 1
+	snapshotSectionEnd byte = 0
+)
+
+const (
+	// Time between state snapshots if no interval is configured.
+	DefaultSnapshotInterval = 5 * time.Minute
+	// Time entries loaded from a snapshot are kept without a GUILD_CREATE before they can be ejected.
+	DefaultSnapshotStaleTimeout = 10 * time.Minute
+)
+
+// Largest value that will be read from a snapshot, to avoid allocating
+// arbitrary amounts of memory on a corrupt file.
+const snapshotMaxValueLength = 64 * 1024 * 1024
+
+type snapshotWriter struct {
+	w       *bufio.Writer
+	scratch [binary.MaxVarintLen64]byte
+	err     error
+}
+
+func (sw *snapshotWriter) writeByte(b byte) {
+	if sw.err == nil {
+		sw.err = sw.w.WriteByte(b)
+	}
+}
+
+func (sw *snapshotWriter) writeVarint(v int64) {
+	if sw.err == nil {
+		_, sw.err = sw.w.Write(sw.scratch[:binary.PutVarint(sw.scratch[:], v)])
+	}
+}
+
+func (sw *snapshotWriter) writeValue(v any) {
+	if sw.err != nil {
+		return
+	}
+
+	data, err := sandwichjson.Marshal(v)
+	if err != nil {
+		sw.err = fmt.Errorf("failed to marshal value: %w", err)
+
+		return
+	}
+
+	if _, sw.err = sw.w.Write(sw.scratch[:binary.PutUvarint(sw.scratch[:], uint64(len(data)))]); sw.err == nil {
+		_, sw.err = sw.w.Write(data)
+	}
+}
+
+func writeSnapshotCache[K ~int64, V any](sw *snapshotWriter, c *Cache[K, V]) {
+	c.Range(func(key K, value V) bool {
+		sw.writeByte(snapshotEntry)
+		sw.writeVarint(int64(key))
+		sw.writeValue(value)
+
+		return sw.err != nil
+	})
+
+	sw.writeByte(snapshotSectionEnd)
+}
+
+func writeSnapshotDoubleCache[KA ~int64, KB ~int64, V any](sw *snapshotWriter, c *DoubleCache[KA, KB, V]) {
+	c.Range(func(key KA, inner Cache[KB, V]) bool {
+		sw.writeByte(snapshotEntry)
+		sw.writeVarint(int64(key))
+		writeSnapshotCache(sw, &inner)
+
+		return sw.err != nil
+	})
+
+	sw.writeByte(snapshotSectionEnd)
+}
+
+type snapshotReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// next returns true if there is another entry in the current section.
+func (sr *snapshotReader) next() (bool, error) {
+	marker, err := sr.r.ReadByte()
+	if err != nil {
+		return false, err
+	}
+
+	return marker == snapshotEntry, nil
+}
+
+func (sr *snapshotReader) readValue(v any) error {
+	length, err := binary.ReadUvarint(sr.r)
+	if err != nil {
+		return err
+	}
+
+	if length > snapshotMaxValueLength {
+		return ErrSnapshotValueLength
+	}
+
+	if uint64(cap(sr.buf)) < length {
+		sr.buf = make([]byte, length)
+	}
+
+	sr.buf = sr.buf[:length]
+
+	if _, err = io.ReadFull(sr.r, sr.buf); err != nil {
+		return err
+	}
+
+	return sandwichjson.Unmarshal(sr.buf, v)
+}
+
+func readSnapshotCache[K ~int64, V any](sr *snapshotReader, c *Cache[K, V]) error {
+	for {
+		ok, err := sr.next()
+		if err != nil || !ok {
+			return err
+		}
+
+		key, err := binary.ReadVarint(sr.r)
+		if err != nil {
+			return err
+		}
+
+		var value V
+
+		if err = sr.readValue(&value); err != nil {
+			return err
+		}
+
+		c.Store(K(key), value)
+	}
+}
+
+func readSnapshotDoubleCache[KA ~int64, KB ~int64, V any](sr *snapshotReader, c *DoubleCache[KA, KB, V]) error {
+	for {
+		ok, err := sr.next()
+		if err != nil || !ok {
+			return err
+		}
+
+		key, err := binary.ReadVarint(sr.r)
+		if err != nil {
+			return err
+		}
+
+		inner, ok := c.Inner(KA(key))
+		if !ok {
+			inner = NewCache[KB, V](c.sizeInner)
+		}
+
+		if err = readSnapshotCache(sr, &inner); err != nil {
+			return err
+		}
+
+		c.inner.Store(KA(key), inner)
+	}
+}
+
+// WriteSnapshot writes every collection in the backend to w.
+func (ms *MemoryStateBackend) WriteSnapshot(w io.Writer) error {
+	header := make([]byte, len(snapshotMagic)+2+8)
+	copy(header, snapshotMagic)
+	binary.BigEndian.PutUint16(header[len(snapshotMagic):], SnapshotVersion)
+	binary.BigEndian.PutUint64(header[len(snapshotMagic)+2:], uint64(time.Now().Unix()))
+
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+
+	zw := zlib.NewWriter(w)
+	sw := &snapshotWriter{w: bufio.NewWriter(zw)}
+
+	writeSnapshotCache(sw, &ms.Guilds)
+	writeSnapshotDoubleCache(sw, &ms.GuildMembers)
+	writeSnapshotDoubleCache(sw, &ms.GuildChannels)
+	writeSnapshotDoubleCache(sw, &ms.GuildRoles)
+	writeSnapshotCache(sw, &ms.GuildEmojis)
+	writeSnapshotCache(sw, &ms.Users)
+	writeSnapshotCache(sw, &ms.DmChannels)
+	writeSnapshotDoubleCache(sw, &ms.Mutuals)
+	writeSnapshotDoubleCache(sw, &ms.GuildVoiceStates)
+
+	if sw.err == nil {
+		sw.err = sw.w.Flush()
+	}
+
+	if sw.err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", sw.err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// ReadSnapshot populates the backend from a snapshot written by WriteSnapshot and
+// returns the time the snapshot was created.
+func (ms *MemoryStateBackend) ReadSnapshot(r io.Reader) (createdAt time.Time, err error) {
+	header := make([]byte, len(snapshotMagic)+2+8)
+
+	if _, err = io.ReadFull(r, header); err != nil {
+		return createdAt, fmt.Errorf("failed to read snapshot header: %w", err)
+	}
+
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return createdAt, ErrSnapshotInvalid
+	}
+
+	if version := binary.BigEndian.Uint16(header[len(snapshotMagic):]); version != SnapshotVersion {
+		return createdAt, fmt.Errorf("%w: %d", ErrSnapshotVersion, version)
+	}
+
+	createdAt = time.Unix(int64(binary.BigEndian.Uint64(header[len(snapshotMagic)+2:])), 0)
+
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return createdAt, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	defer zr.Close()
+
+	sr := &snapshotReader{r: bufio.NewReader(zr)}
+
+	for _, read := range []func() error{
+		func() error { return readSnapshotCache(sr, &ms.Guilds) },
+		func() error { return readSnapshotDoubleCache(sr, &ms.GuildMembers) },
+		func() error { return readSnapshotDoubleCache(sr, &ms.GuildChannels) },
+		func() error { return readSnapshotDoubleCache(sr, &ms.GuildRoles) },
+		func() error { return readSnapshotCache(sr, &ms.GuildEmojis) },
+		func() error { return readSnapshotCache(sr, &ms.Users) },
+		func() error { return readSnapshotCache(sr, &ms.DmChannels) },
+		func() error { return readSnapshotDoubleCache(sr, &ms.Mutuals) },
+		func() error { return readSnapshotDoubleCache(sr, &ms.GuildVoiceStates) },
+	} {
+		if err = read(); err != nil {
+			return createdAt, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+	}
+
+	return createdAt, nil
+}
+
+// MarkGuildStale marks a guild as loaded from a snapshot. The guild will be kept
+// until the deadline even if no shard has received it.
+func (ss *SandwichState) MarkGuildStale(guildID discord.GuildID, deadline time.Time) {
+	ss.StaleGuilds.Store(guildID, deadline.Unix())
+}
+
+// IsGuildStale returns if a guild was loaded from a snapshot and has not yet been
+// refreshed by a GUILD_CREATE.
+func (ss *SandwichState) IsGuildStale(guildID discord.GuildID) bool {
+	return ss.StaleGuilds.Has(guildID)
+}
+
+// ClearGuildStale marks a guild as refreshed.
+func (ss *SandwichState) ClearGuildStale(guildID discord.GuildID) {
+	ss.StaleGuilds.Delete(guildID)
+}
+
+// snapshotsEnabled returns if state snapshots should be written. Snapshots are only
+// supported by the memory state backend.
+func (sg *Sandwich) snapshotsEnabled() bool {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	_, ok := sg.State.Backend.(*MemoryStateBackend)
+
+	return ok && sg.Configuration.Snapshots.Path != ""
+}
+
+// snapshotInterval returns the time between periodic snapshots.
+func (sg *Sandwich) snapshotInterval() time.Duration {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	if sg.Configuration.Snapshots.Interval > 0 {
+		return time.Duration(sg.Configuration.Snapshots.Interval) * time.Second
+	}
+
+	return DefaultSnapshotInterval
+}
+
+// snapshotStaleTimeout returns how long loaded guilds are kept without a GUILD_CREATE.
+func (sg *Sandwich) snapshotStaleTimeout() time.Duration {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	if sg.Configuration.Snapshots.StaleTimeout > 0 {
+		return time.Duration(sg.Configuration.Snapshots.StaleTimeout) * time.Second
+	}
+
+	return DefaultSnapshotStaleTimeout
+}
+
+// loadSnapshot populates the state from the last snapshot, if one exists.
+// Every guild loaded is marked as stale.
+func (sg *Sandwich) loadSnapshot() {
+	sg.configurationMu.RLock()
+	path := sg.Configuration.Snapshots.Path
+	sg.configurationMu.RUnlock()
+
+	if path == "" {
+		return
+	}
+
+	memory, ok := sg.State.Backend.(*MemoryStateBackend)
+	if !ok {
+		sg.Logger.Warn().Str("backend", sg.State.Backend.String()).Msg("State snapshots are only supported by the memory state backend")
+
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			sg.Logger.Warn().Err(err).Str("path", path).Msg("Failed to open state snapshot")
+		}
+
+		return
+	}
+	defer file.Close()
+
+	start := time.Now()
+
+	createdAt, err := memory.ReadSnapshot(file)
+	if err != nil {
+		sg.Logger.Warn().Err(err).Str("path", path).Msg("Failed to load state snapshot")
+
+		return
+	}
+
+	deadline := time.Now().Add(sg.snapshotStaleTimeout())
+
+	memory.Guilds.Range(func(guildID discord.GuildID, _ discord.Guild) bool {
+		sg.State.MarkGuildStale(guildID, deadline)
+
+		return false
+	})
+
+	sg.Logger.Info().
+		Int("guilds", memory.Guilds.Count()).
+		Int("members", memory.GuildMembers.TotalCount()).
+		Int("users", memory.Users.Count()).
+		Time("created_at", createdAt).
+		Dur("duration", time.Since(start)).
+		Msg("Loaded state snapshot")
+}
+
+// saveSnapshot writes the state to disk. The snapshot is written to a temporary
+// file first so a partially written snapshot never replaces a complete one.
+func (sg *Sandwich) saveSnapshot() {
+	sg.configurationMu.RLock()
+	path := sg.Configuration.Snapshots.Path
+	sg.configurationMu.RUnlock()
+
+	memory, ok := sg.State.Backend.(*MemoryStateBackend)
+	if path == "" || !ok {
+		return
+	}
+
+	sg.snapshotMu.Lock()
+	defer sg.snapshotMu.Unlock()
+
+	start := time.Now()
+	tempPath := path + ".tmp"
+
+	err := writeSnapshotFile(memory, tempPath)
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+
+	if err != nil {
+		sg.Logger.Error().Err(err).Str("path", path).Msg("Failed to save state snapshot")
+
+		return
+	}
+
+	sg.Logger.Debug().Dur("duration", time.Since(start)).Msg("Saved state snapshot")
+}
+
+func writeSnapshotFile(memory *MemoryStateBackend, path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, PermissionWrite)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+
+	err = memory.WriteSnapshot(file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close snapshot file: %w", closeErr)
+	}
+
+	return err
+}
+
+// snapshotter periodically writes state snapshots until sandwich is closed.
+func (sg *Sandwich) snapshotter() {
+	t := time.NewTicker(sg.snapshotInterval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-sg.ctx.Done():
+			return
+		case <-t.C:
+			sg.saveSnapshot()
+		}
+	}
+}
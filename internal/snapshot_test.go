@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	state := NewMemoryStateBackend()
+
+	guildID := discord.GuildID(1)
+	userID := discord.UserID(2)
+
+	state.Guilds.Store(guildID, discord.Guild{ID: guildID, Name: "Guild"})
+	state.GuildRoles.Store(guildID, discord.RoleID(3), discord.Role{ID: 3, Name: "Role"})
+	state.GuildChannels.Store(guildID, discord.ChannelID(4), discord.Channel{ID: 4, Name: "channel"})
+	state.GuildMembers.Store(guildID, userID, discord.GuildMember{User: &discord.User{ID: userID}})
+	state.Users.Store(userID, StateUser{User: discord.User{ID: userID, Username: "user"}})
+	state.Mutuals.Store(userID, guildID, struct{}{})
+
+	var buf bytes.Buffer
+
+	if err := state.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	loaded := NewMemoryStateBackend()
+
+	if _, err := loaded.ReadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+
+	if guild, ok := loaded.Guilds.Load(guildID); !ok || guild.Name != "Guild" {
+		t.Errorf("Expected guild to be loaded, got %v", guild)
+	}
+
+	if role, ok := loaded.GuildRoles.Load(guildID, 3); !ok || role.Name != "Role" {
+		t.Errorf("Expected role to be loaded, got %v", role)
+	}
+
+	if channel, ok := loaded.GuildChannels.Load(guildID, 4); !ok || channel.Name != "channel" {
+		t.Errorf("Expected channel to be loaded, got %v", channel)
+	}
+
+	if _, ok := loaded.GuildMembers.Load(guildID, userID); !ok {
+		t.Errorf("Expected member to be loaded")
+	}
+
+	if user, ok := loaded.Users.Load(userID); !ok || user.Username != "user" {
+		t.Errorf("Expected user to be loaded, got %v", user)
+	}
+
+	if _, ok := loaded.Mutuals.Load(userID, guildID); !ok {
+		t.Errorf("Expected mutual to be loaded")
+	}
+}
+
+func TestSnapshotInvalidVersion(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := NewMemoryStateBackend().WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	data := buf.Bytes()
+	data[len(snapshotMagic)+1]++
+
+	_, err := NewMemoryStateBackend().ReadSnapshot(bytes.NewReader(data))
+	if !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("Expected ErrSnapshotVersion, got %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sandwich_structs "github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+	gotils_strings "github.com/savsgio/gotils/strings"
+)
+
+const (
+	DefaultSpoolMaxSize  = 512 * 1024 * 1024
+	DefaultSpoolInterval = 5 * time.Second
+)
+
+// SpoolConfiguration represents the configuration of the spool payloads are written to
+// when they fail to publish.
+type SpoolConfiguration struct {
+	// Directory payloads are spooled in, each manager uses a subdirectory. Disabled if empty.
+	Path string `json:"path" yaml:"path"`
+	// Maximum number of bytes spooled per manager, the oldest payloads are dropped once
+	// exceeded. Defaults to 512MiB.
+	MaxSize int64 `json:"max_size" yaml:"max_size"`
+	// Number of seconds between replaying spooled payloads. Defaults to 5.
+	Interval int32 `json:"interval" yaml:"interval"`
+}
+
+// Spool persists payloads to disk while the producer is unavailable. Each payload is
+// written to its own file, named by the time it was spooled, so they are replayed in order.
+type Spool struct {
+	directory string
+	maxSize   int64
+
+	files    []spoolFile
+	size     int64
+	sequence int64
+	dropped  int64
+
+	mu sync.Mutex
+
+	// Held while replaying so payloads are only published once.
+	replayMu sync.Mutex
+}
+
+type spoolFile struct {
+	name string
+	size int64
+
+	// Unix nano the payload was spooled at.
+	spooledAt int64
+}
+
+type spoolEntry struct {
+	Channel string                            `json:"channel"`
+	Payload *sandwich_structs.SandwichPayload `json:"payload"`
+}
+
+// NewSpool creates a spool in a directory, loading any payloads already spooled.
+func NewSpool(directory string, maxSize int64) (*Spool, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultSpoolMaxSize
+	}
+
+	err := os.MkdirAll(directory, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	spool := &Spool{
+		directory: directory,
+		maxSize:   maxSize,
+	}
+
+	for _, entry := range entries {
+		spooledAt, ok := parseSpoolFileName(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spool directory: %w", err)
+		}
+
+		spool.files = append(spool.files, spoolFile{name: entry.Name(), size: info.Size(), spooledAt: spooledAt})
+		spool.size += info.Size()
+		spool.sequence = max(spool.sequence, spooledAt)
+	}
+
+	sort.Slice(spool.files, func(i, j int) bool {
+		return spool.files[i].spooledAt < spool.files[j].spooledAt
+	})
+
+	return spool, nil
+}
+
+func parseSpoolFileName(name string) (int64, bool) {
+	if !strings.HasSuffix(name, ".json") {
+		return 0, false
+	}
+
+	spooledAt, err := strconv.ParseInt(strings.TrimSuffix(name, ".json"), 10, 64)
+
+	return spooledAt, err == nil
+}
+
+// Write spools a payload. The oldest payloads are dropped if the spool exceeds its maximum size.
+func (sp *Spool) Write(channelName string, packet *sandwich_structs.SandwichPayload) error {
+	data, err := sandwichjson.Marshal(spoolEntry{Channel: channelName, Payload: packet})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	// Names must be unique and increasing even if the clock is not.
+	sp.sequence = max(sp.sequence+1, time.Now().UnixNano())
+
+	file := spoolFile{
+		name:      fmt.Sprintf("%020d.json", sp.sequence),
+		size:      int64(len(data)),
+		spooledAt: sp.sequence,
+	}
+
+	// Write to a temporary file first so partially written payloads are never replayed.
+	path := filepath.Join(sp.directory, file.name)
+
+	err = os.WriteFile(path+".tmp", data, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+
+	err = os.Rename(path+".tmp", path)
+	if err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+
+	sp.files = append(sp.files, file)
+	sp.size += file.size
+
+	for sp.size > sp.maxSize && len(sp.files) > 1 {
+		sp.removeLocked(sp.files[0].name)
+		sp.dropped++
+	}
+
+	return nil
+}
+
+// removeLocked removes a spooled payload. sp.mu must be held.
+func (sp *Spool) removeLocked(name string) {
+	for i, file := range sp.files {
+		if file.name != name {
+			continue
+		}
+
+		sp.files = append(sp.files[:i], sp.files[i+1:]...)
+		sp.size -= file.size
+
+		_ = os.Remove(filepath.Join(sp.directory, name))
+
+		return
+	}
+}
+
+// Replay publishes spooled payloads in order, stopping at the first payload that fails to publish.
+// Returns the number of payloads replayed.
+func (sp *Spool) Replay(ctx context.Context, producer MQClient) (replayed int, err error) {
+	sp.replayMu.Lock()
+	defer sp.replayMu.Unlock()
+
+	for {
+		sp.mu.Lock()
+		if len(sp.files) == 0 {
+			sp.mu.Unlock()
+
+			return replayed, nil
+		}
+
+		name := sp.files[0].name
+		sp.mu.Unlock()
+
+		data, err := os.ReadFile(filepath.Join(sp.directory, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				sp.mu.Lock()
+				sp.removeLocked(name)
+				sp.mu.Unlock()
+
+				continue
+			}
+
+			return replayed, fmt.Errorf("failed to read spooled payload: %w", err)
+		}
+
+		var entry spoolEntry
+
+		err = sandwichjson.Unmarshal(data, &entry)
+		if err == nil && entry.Payload != nil {
+			err = producer.Publish(ctx, entry.Payload, entry.Channel)
+			if err != nil {
+				return replayed, fmt.Errorf("failed to publish spooled payload: %w", err)
+			}
+
+			replayed++
+		}
+
+		// Payloads that cannot be read are removed so they do not block the spool.
+		sp.mu.Lock()
+		sp.removeLocked(name)
+		sp.mu.Unlock()
+	}
+}
+
+// Depth returns the number of spooled payloads.
+func (sp *Spool) Depth() int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	return len(sp.files)
+}
+
+// Age returns how long the oldest payload has been spooled for.
+func (sp *Spool) Age() time.Duration {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if len(sp.files) == 0 {
+		return 0
+	}
+
+	return time.Since(time.Unix(0, sp.files[0].spooledAt))
+}
+
+// Dropped returns the number of payloads dropped because the spool was full.
+func (sp *Spool) Dropped() int64 {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	return sp.dropped
+}
+
+// publish publishes a payload to the producer and then queues it to the producer routes, unless the
+// event is in the produce blacklist. Payloads are spooled if the producer fails, if the spool
+// backpressure policy applies, or if older payloads are still spooled so they are delivered in order.
+func (mg *Manager) publish(ctx context.Context, packet *sandwich_structs.SandwichPayload, channelName string) error {
+	mg.produceBlacklistMu.RLock()
+	blacklisted := gotils_strings.Include(mg.produceBlacklist, packet.Type)
+	mg.produceBlacklistMu.RUnlock()
+
+	if blacklisted {
+		return nil
+	}
+
+	defer mg.routePayload(packet, channelName)
+
+	drop, spool := mg.applyBackpressure(packet)
+	if drop {
+		return nil
+	}
+
+	if mg.spool == nil {
+		return mg.ProducerClient.Publish(ctx, packet, channelName)
+	}
+
+	if !spool && mg.spool.Depth() == 0 {
+		err := mg.ProducerClient.Publish(ctx, packet, channelName)
+		if err == nil {
+			return nil
+		}
+
+		mg.Logger.Warn().Err(err).Str("type", packet.Type).Msg("Failed to publish event, spooling")
+	}
+
+	err := mg.spool.Write(channelName, packet)
+	if err != nil {
+		return fmt.Errorf("failed to spool event: %w", err)
+	}
+
+	return nil
+}
+
+// setupSpool creates the spool of the manager if spooling is enabled.
+func (mg *Manager) setupSpool() error {
+	if mg.spool != nil {
+		return nil
+	}
+
+	mg.Sandwich.configurationMu.RLock()
+	configuration := mg.Sandwich.Configuration.Producer.Spool
+	mg.Sandwich.configurationMu.RUnlock()
+
+	if configuration.Path == "" {
+		return nil
+	}
+
+	spool, err := NewSpool(filepath.Join(configuration.Path, mg.Identifier.Load()), configuration.MaxSize)
+	if err != nil {
+		return err
+	}
+
+	mg.spool = spool
+
+	return nil
+}
+
+// replaySpool replays spooled payloads once the producer is available and has caught up.
+func (mg *Manager) replaySpool() {
+	producer := mg.ProducerClient
+	if mg.spool == nil || producer == nil || producer.IsClosed() || mg.spool.Depth() == 0 || mg.isBackpressured() {
+		return
+	}
+
+	replayed, err := mg.spool.Replay(mg.ctx, producer)
+	if err != nil {
+		mg.Logger.Warn().Err(err).Int("replayed", replayed).Int("depth", mg.spool.Depth()).Msg("Failed to replay spool")
+
+		return
+	}
+
+	mg.Logger.Info().Int("replayed", replayed).Msg("Replayed spool")
+}
+
+// spoolStatus returns the number of spooled payloads and the age of the oldest in seconds.
+func (mg *Manager) spoolStatus() (depth int, age int) {
+	if mg.spool == nil {
+		return 0, 0
+	}
+
+	return mg.spool.Depth(), int(mg.spool.Age().Seconds())
+}
+
+func (sg *Sandwich) spoolEnabled() bool {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	return sg.Configuration.Producer.Spool.Path != ""
+}
+
+func (sg *Sandwich) spoolInterval() time.Duration {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	if sg.Configuration.Producer.Spool.Interval > 0 {
+		return time.Duration(sg.Configuration.Producer.Spool.Interval) * time.Second
+	}
+
+	return DefaultSpoolInterval
+}
+
+// spoolReplayer periodically replays the spools of managers and updates spool metrics.
+func (sg *Sandwich) spoolReplayer() {
+	t := time.NewTicker(sg.spoolInterval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-sg.ctx.Done():
+			return
+		case <-t.C:
+			sg.Managers.Range(func(key string, mg *Manager) bool {
+				mg.replaySpool()
+
+				depth, age := mg.spoolStatus()
+				sandwichSpoolDepth.WithLabelValues(key).Set(float64(depth))
+				sandwichSpoolAge.WithLabelValues(key).Set(float64(age))
+
+				if mg.spool != nil {
+					dropped := mg.spool.Dropped()
+					sandwichSpoolDropped.WithLabelValues(key).Add(float64(dropped - mg.spoolDropped))
+					mg.spoolDropped = dropped
+				}
+
+				return false
+			})
+		}
+	}
+}
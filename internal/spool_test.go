@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/internal/structs"
+)
+
+// testMQClient records published payloads, failing while fail is set.
+type testMQClient struct {
+	fail      bool
+	published []string
+	queue     int
+	capacity  int
+}
+
+func (mq *testMQClient) String() string  { return "test" }
+func (mq *testMQClient) Channel() string { return "test" }
+
+func (mq *testMQClient) Connect(ctx context.Context, manager *Manager, clientName string, args map[string]interface{}) error {
+	return nil
+}
+
+func (mq *testMQClient) Publish(ctx context.Context, packet *structs.SandwichPayload, channel string) error {
+	if mq.fail {
+		return errors.New("producer unavailable")
+	}
+
+	mq.published = append(mq.published, channel+":"+packet.Type)
+
+	return nil
+}
+
+func (mq *testMQClient) QueueLength() int   { return mq.queue }
+func (mq *testMQClient) QueueCapacity() int { return mq.capacity }
+
+func (mq *testMQClient) IsClosed() bool                                      { return mq.fail }
+func (mq *testMQClient) CloseShard(shardID int32, reason MQCloseShardReason) {}
+func (mq *testMQClient) StopSession(sessionID string)                        {}
+func (mq *testMQClient) Close()                                              {}
+
+func TestSpoolReplay(t *testing.T) {
+	directory := t.TempDir()
+
+	spool, err := NewSpool(directory, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, eventType := range []string{"A", "B", "C"} {
+		if err := spool.Write("sandwich", &structs.SandwichPayload{Type: eventType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	producer := &testMQClient{fail: true}
+
+	if _, err := spool.Replay(context.Background(), producer); err == nil {
+		t.Fatal("expected replay to fail while the producer is unavailable")
+	}
+
+	// Spooled payloads are loaded again after a restart.
+	spool, err = NewSpool(directory, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spool.Depth() != 3 {
+		t.Fatalf("expected 3 spooled payloads, got %d", spool.Depth())
+	}
+
+	producer.fail = false
+
+	replayed, err := spool.Replay(context.Background(), producer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replayed != 3 || spool.Depth() != 0 {
+		t.Fatalf("expected 3 replayed payloads and an empty spool, got %d and %d", replayed, spool.Depth())
+	}
+
+	for i, expected := range []string{"sandwich:A", "sandwich:B", "sandwich:C"} {
+		if producer.published[i] != expected {
+			t.Fatalf("expected payload %d to be %s, got %s", i, expected, producer.published[i])
+		}
+	}
+}
+
+func TestSpoolMaxSize(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, eventType := range []string{"A", "B"} {
+		if err := spool.Write("sandwich", &structs.SandwichPayload{Type: eventType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The newest payload is always kept, even if it exceeds the maximum size.
+	if spool.Depth() != 1 || spool.Dropped() != 1 {
+		t.Fatalf("expected 1 spooled and 1 dropped payload, got %d and %d", spool.Depth(), spool.Dropped())
+	}
+}
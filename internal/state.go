@@ -2,10 +2,10 @@ package internal
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"go.uber.org/atomic"
 )
 
 type StateCtx struct {
@@ -15,62 +15,81 @@ type StateCtx struct {
 	CacheMembers bool
 	Stateless    bool
 	StoreMutuals bool
+	TrackInvites bool
+
+	// Policies used to decide what is cached. Everything is cached if nil.
+	CachePolicies *StateCachePolicies
 }
 
+// NewFakeCtx returns a StateCtx for state changes made outside of a shard, using the
+// caching configuration of the manager.
 func NewFakeCtx(mg *Manager) StateCtx {
+	mg.configurationMu.RLock()
+	cacheUsers := mg.Configuration.Caching.CacheUsers
+	cacheMembers := mg.Configuration.Caching.CacheMembers
+	mg.configurationMu.RUnlock()
+
+	mg.cachePoliciesMu.RLock()
+	cachePolicies := mg.cachePolicies
+	mg.cachePoliciesMu.RUnlock()
+
 	return StateCtx{
-		CacheUsers:   true,
-		CacheMembers: true,
+		CacheUsers:   cacheUsers,
+		CacheMembers: cacheMembers,
 		StoreMutuals: true,
 		Shard: &Shard{
 			ctx:     mg.ctx,
 			Manager: mg,
 			Logger:  mg.Logger,
 		},
+
+		CachePolicies: cachePolicies,
 	}
 }
 
 // SandwichState stores the collective state of all ShardGroups
 // across all Managers.
 type SandwichState struct {
-	Guilds Cache[discord.GuildID, discord.Guild]
-
-	GuildMembers DoubleCache[discord.GuildID, discord.UserID, discord.GuildMember]
-
-	GuildChannels DoubleCache[discord.GuildID, discord.ChannelID, discord.Channel]
-
-	GuildRoles DoubleCache[discord.GuildID, discord.RoleID, discord.Role]
+	// Backend used to store state.
+	Backend StateBackend
 
-	GuildEmojis Cache[discord.GuildID, []discord.Emoji]
+	// Guilds loaded from a snapshot that have not been refreshed by a GUILD_CREATE,
+	// with the unix time they can be ejected at. This is not included in snapshots.
+	StaleGuilds Cache[discord.GuildID, int64]
 
-	Users Cache[discord.UserID, StateUser]
+	// Unix nano time each guildMember was last active, used to evict the least recently
+	// active members. Only populated while TrackMemberActivity is true.
+	MemberActivity      DoubleCache[discord.GuildID, discord.UserID, int64]
+	TrackMemberActivity atomic.Bool
 
-	DmChannels Cache[discord.ChannelID, StateDMChannel]
+	// Recent messages of each channel, used to provide the before state of message events.
+	Messages         Cache[discord.ChannelID, *MessageRing]
+	MessageCacheSize atomic.Int32
+	MessageCacheTTL  atomic.Duration
 
-	Mutuals DoubleCache[discord.UserID, discord.GuildID, struct{}]
+	// Known members of each thread. Threads themselves are stored with guild channels.
+	ThreadMembers DoubleCache[discord.ChannelID, discord.UserID, discord.ThreadMember]
 
-	GuildVoiceStates DoubleCache[discord.GuildID, discord.UserID, discord.VoiceState]
+	// Invites of each guild by code, used to find the invite a member joined with.
+	GuildInvites DoubleCache[discord.GuildID, string, discord.Invite]
+	// Guilds whose invites have been fetched, uses are only diffed once a guild is seeded.
+	GuildInvitesSeeded Cache[discord.GuildID, struct{}]
 }
 
 func NewSandwichState() *SandwichState {
 	state := &SandwichState{
-		Guilds: NewCache[discord.GuildID, discord.Guild](100),
+		Backend: NewMemoryStateBackend(),
 
-		GuildMembers: NewDoubleCache[discord.GuildID, discord.UserID, discord.GuildMember](0, 50),
+		StaleGuilds: NewCache[discord.GuildID, int64](50),
 
-		GuildChannels: NewDoubleCache[discord.GuildID, discord.ChannelID, discord.Channel](0, 50),
+		MemberActivity: NewDoubleCache[discord.GuildID, discord.UserID, int64](0, 50),
 
-		GuildRoles: NewDoubleCache[discord.GuildID, discord.RoleID, discord.Role](0, 50),
+		Messages: NewCache[discord.ChannelID, *MessageRing](50),
 
-		GuildEmojis: NewCache[discord.GuildID, []discord.Emoji](50),
+		ThreadMembers: NewDoubleCache[discord.ChannelID, discord.UserID, discord.ThreadMember](0, 10),
 
-		Users: NewCache[discord.UserID, StateUser](100),
-
-		DmChannels: NewCache[discord.ChannelID, StateDMChannel](50),
-
-		Mutuals: NewDoubleCache[discord.UserID, discord.GuildID, struct{}](0, 50),
-
-		GuildVoiceStates: NewDoubleCache[discord.GuildID, discord.UserID, discord.VoiceState](0, 50),
+		GuildInvites:       NewDoubleCache[discord.GuildID, string, discord.Invite](0, 10),
+		GuildInvitesSeeded: NewCache[discord.GuildID, struct{}](0),
 	}
 
 	return state
@@ -79,7 +98,7 @@ func NewSandwichState() *SandwichState {
 // GetGuild returns the guild with the same ID from the cache.
 // Returns a boolean to signify a match or not.
 func (ss *SandwichState) GetGuild(guildID discord.GuildID) (guild discord.Guild, ok bool) {
-	guild, ok = ss.Guilds.Load(guildID)
+	guild, ok = ss.Backend.GetGuild(guildID)
 
 	if !ok {
 		return
@@ -88,33 +107,32 @@ func (ss *SandwichState) GetGuild(guildID discord.GuildID) (guild discord.Guild,
 	// Get list of roles
 	roles, ok := ss.GetAllGuildRoles(guildID)
 
-	if !ok {
-		fmt.Println("Failed to get roles")
-		return
+	if ok {
+		guild.Roles = roles
+	} else {
+		guild.Roles = make([]discord.Role, 0)
 	}
 
-	guild.Roles = roles
-
 	// Get list of channels
-	guildChannels, ok := ss.GetAllGuildChannels(guildID)
+	guildChannels, _ := ss.GetAllGuildChannels(guildID)
 
-	if ok {
-		guild.Channels = guildChannels
-	} else {
-		guild.Channels = make([]discord.Channel, 0)
+	guild.Channels = make([]discord.Channel, 0, len(guildChannels))
+	guild.Threads = make([]discord.Channel, 0)
+
+	// Threads are stored with channels but are sent separately, as in GUILD_CREATE.
+	for _, channel := range guildChannels {
+		if IsThread(channel) {
+			guild.Threads = append(guild.Threads, channel)
+		} else {
+			guild.Channels = append(guild.Channels, channel)
+		}
 	}
 
 	// Get list of voice states, if any
-	voiceStates, ok := ss.GuildVoiceStates.Inner(guildID)
+	voiceStates, ok := ss.Backend.GetAllVoiceStates(guildID)
 
 	if ok {
-		// Pre-allocate the list
-		guild.VoiceStates = make([]discord.VoiceState, 0, voiceStates.Count())
-
-		voiceStates.Range(func(_ discord.UserID, voiceState discord.VoiceState) bool {
-			guild.VoiceStates = append(guild.VoiceStates, voiceState)
-			return false
-		})
+		guild.VoiceStates = voiceStates
 	} else {
 		guild.VoiceStates = make([]discord.VoiceState, 0)
 	}
@@ -122,19 +140,17 @@ func (ss *SandwichState) GetGuild(guildID discord.GuildID) (guild discord.Guild,
 	// Get list of emojis
 	emojis, ok := ss.GetAllGuildEmojis(guildID)
 
-	if !ok {
-		fmt.Println("Failed to get emojis")
-		return
+	if ok {
+		guild.Emojis = emojis
+	} else {
+		guild.Emojis = make([]discord.Emoji, 0)
 	}
 
-	guild.Emojis = emojis
-
 	// Get list of members
 	members, ok := ss.GetAllGuildMembers(guildID)
 
 	if !ok {
-		fmt.Println("Failed to get members")
-		return
+		members = make([]discord.GuildMember, 0)
 	}
 
 	// Fix AFK channel
@@ -155,18 +171,21 @@ func (ss *SandwichState) GetGuild(guildID discord.GuildID) (guild discord.Guild,
 // NOT fake-ctx-safe UNLESS
 func (ss *SandwichState) SetGuild(ctx StateCtx, guild discord.Guild) {
 	ctx.ShardGroup.Guilds.Store(guild.ID, struct{}{})
-	ss.Guilds.Store(guild.ID, guild)
 	ctx.Guilds.Store(guild.ID, struct{}{})
 
 	// Safety: there is guaranteed to be at least one role
 	for _, role := range guild.Roles {
-		ss.SetGuildRole(guild.ID, role)
+		ss.SetGuildRole(ctx, guild.ID, role)
 	}
 
 	for _, channel := range guild.Channels {
 		ss.SetGuildChannel(ctx, guild.ID, channel)
 	}
 
+	for _, thread := range guild.Threads {
+		ss.SetGuildThread(ctx, guild.ID, thread)
+	}
+
 	ss.SetGuildEmojis(ctx, guild.ID, guild.Emojis)
 
 	for _, member := range guild.Members {
@@ -181,23 +200,54 @@ func (ss *SandwichState) SetGuild(ctx StateCtx, guild discord.Guild) {
 	// Clear out some data that we don't need to cache in guild
 	guild.Roles = nil
 	guild.Channels = nil
+	guild.Threads = nil
 	guild.VoiceStates = nil
 	guild.Members = nil // No need to duplicate this data.
 	guild.Emojis = nil  // No need to duplicate this data.
+
+	if !ctx.Caches(guild.ID, CacheCollectionPresences) {
+		guild.Presences = nil
+	}
+
+	ss.Backend.SetGuild(guild)
+}
+
+// UpdateGuild runs a function on a guild in the cache, updating the value in cache based on returned value.
+// Unlike SetGuild, roles, channels and other nested entities are not updated.
+func (ss *SandwichState) UpdateGuild(guildID discord.GuildID, fn func(guild discord.Guild) discord.Guild) (guild discord.Guild, ok bool) {
+	return ss.Backend.UpdateGuild(guildID, fn)
+}
+
+// HasGuild returns if a guild is in the cache.
+func (ss *SandwichState) HasGuild(guildID discord.GuildID) bool {
+	_, ok := ss.Backend.GetGuild(guildID)
+
+	return ok
+}
+
+// GetAllGuildIDs returns the IDs of all guilds in the cache.
+func (ss *SandwichState) GetAllGuildIDs() []discord.GuildID {
+	return ss.Backend.GetAllGuildIDs()
 }
 
 // RemoveGuild removes a guild from the cache.
 //
 // NOT fake-ctx-safe
 func (ss *SandwichState) RemoveGuild(ctx StateCtx, guildID discord.GuildID) {
-	ss.Guilds.Delete(guildID)
+	ss.Backend.RemoveGuild(guildID)
+	ss.StaleGuilds.Delete(guildID)
 
 	if !ctx.Stateless {
 		ctx.ShardGroup.Guilds.Delete(guildID)
 	}
 
+	for _, thread := range ss.GetAllGuildThreads(guildID) {
+		ss.ThreadMembers.ClearKey(thread.ID)
+	}
+
 	ss.RemoveAllGuildRoles(guildID)
 	ss.RemoveAllGuildChannels(guildID)
+	ss.RemoveAllGuildInvites(guildID)
 	ss.RemoveAllGuildEmojis(guildID)
 	ss.RemoveAllGuildMembers(guildID)
 }
@@ -205,18 +255,16 @@ func (ss *SandwichState) RemoveGuild(ctx StateCtx, guildID discord.GuildID) {
 // GetGuildMember returns the guildMember with the same ID from the cache. Populated user field from cache.
 // Returns a boolean to signify a match or not.
 func (ss *SandwichState) GetGuildMember(guildID discord.GuildID, guildMemberID discord.UserID) (guildMember discord.GuildMember, ok bool) {
-	guildMembers, ok := ss.GuildMembers.Inner(guildID)
+	guildMember, ok = ss.Backend.GetGuildMember(guildID, guildMemberID)
 
 	if !ok {
-		return
-	}
-
-	guildMember, ok = guildMembers.Load(guildMemberID)
+		sandwichStateMemberCacheMisses.Inc()
 
-	if !ok {
 		return
 	}
 
+	sandwichStateMemberCacheHits.Inc()
+
 	// FIX: Ensure that joined_at is set correctly, it tends to get corrupted for some reason
 	//
 	// This is common enough to not warrning a log message for it.
@@ -239,11 +287,11 @@ func (ss *SandwichState) GetGuildMember(guildID discord.GuildID, guildMemberID d
 // fake-ctx-safe
 func (ss *SandwichState) SetGuildMember(ctx StateCtx, guildID discord.GuildID, guildMember discord.GuildMember) {
 	// We will always cache the guild member of the bot that receives this event.
-	if !ctx.CacheMembers && guildMember.User.ID != ctx.Manager.User.ID {
+	if (!ctx.CacheMembers || !ctx.Caches(guildID, CacheCollectionMembers)) && guildMember.User.ID != ctx.Manager.User.ID {
 		return
 	}
 
-	ss.GuildMembers.Store(guildID, guildMember.User.ID, guildMember)
+	ss.Backend.SetGuildMember(guildID, ctx.trimGuildMember(guildID, guildMember))
 
 	if guildMember.User != nil {
 		ss.SetUser(ctx, *guildMember.User)
@@ -252,78 +300,60 @@ func (ss *SandwichState) SetGuildMember(ctx StateCtx, guildID discord.GuildID, g
 
 // RemoveGuildMember removes a guildMember from the cache.
 func (ss *SandwichState) RemoveGuildMember(guildID discord.GuildID, guildMemberID discord.UserID) {
-	ss.GuildMembers.Delete(guildID, guildMemberID)
+	ss.Backend.RemoveGuildMember(guildID, guildMemberID)
+	ss.MemberActivity.Delete(guildID, guildMemberID)
 }
 
 // GetAllGuildMembers returns all guildMembers of a specific guild from the cache.
 func (ss *SandwichState) GetAllGuildMembers(guildID discord.GuildID) (guildMembersList []discord.GuildMember, ok bool) {
-	guildMembers, ok := ss.GuildMembers.Inner(guildID)
-
-	if !ok {
-		return
-	}
-
-	// Pre-allocate the list
-	guildMembersList = make([]discord.GuildMember, 0, guildMembers.Count())
-
-	guildMembers.Range(func(_ discord.UserID, guildMember discord.GuildMember) bool {
-		guildMembersList = append(guildMembersList, guildMember)
-		return false
-	})
+	return ss.Backend.GetAllGuildMembers(guildID)
+}
 
-	return
+// CountGuildMembers returns the number of guildMembers of a specific guild in the cache.
+func (ss *SandwichState) CountGuildMembers(guildID discord.GuildID) int {
+	return ss.Backend.CountGuildMembers(guildID)
 }
 
 // RemoveAllGuildMembers removes all guildMembers of a specific guild from the cache.
 func (ss *SandwichState) RemoveAllGuildMembers(guildID discord.GuildID) {
-	ss.GuildMembers.ClearKey(guildID)
+	ss.Backend.RemoveAllGuildMembers(guildID)
+	ss.MemberActivity.ClearKey(guildID)
 }
 
 // GetGuildRole returns the role with the same ID from the cache.
 // Returns a boolean to signify a match or not.
 func (ss *SandwichState) GetGuildRole(guildID discord.GuildID, roleID discord.RoleID) (role discord.Role, ok bool) {
-	return ss.GuildRoles.Load(guildID, roleID)
+	return ss.Backend.GetGuildRole(guildID, roleID)
 }
 
 // SetGuildRole creates or updates a role entry in the cache.
-func (ss *SandwichState) SetGuildRole(guildID discord.GuildID, role discord.Role) {
+//
+// fake-ctx-safe
+func (ss *SandwichState) SetGuildRole(ctx StateCtx, guildID discord.GuildID, role discord.Role) {
 	if role.ID == 0 {
 		panic("roleID cannot be '0'")
 	}
-	ss.GuildRoles.Store(guildID, role.ID, role)
+
+	if !ctx.Caches(guildID, CacheCollectionRoles) {
+		return
+	}
+
+	ss.Backend.SetGuildRole(guildID, role)
 }
 
 // RemoveGuildRole removes a role from the cache.
 func (ss *SandwichState) RemoveGuildRole(guildID discord.GuildID, roleID discord.RoleID) {
-	ss.GuildRoles.Delete(guildID, roleID)
+	ss.Backend.RemoveGuildRole(guildID, roleID)
 }
 
 // GetAllGuildRoles returns all guildRoles of a specific guild from the cache.
 func (ss *SandwichState) GetAllGuildRoles(guildID discord.GuildID) (guildRolesList []discord.Role, ok bool) {
-	guildRoles, ok := ss.GuildRoles.Inner(guildID)
-
-	if !ok {
-		return
-	}
-
-	// Pre-allocate the list
-	guildRolesList = make([]discord.Role, 0, guildRoles.Count())
-
-	guildRoles.Range(func(id discord.RoleID, role discord.Role) bool {
-		if role.ID == 0 {
-			role.ID = id
-		}
-
-		guildRolesList = append(guildRolesList, role)
-		return false
-	})
-
-	return
+	return ss.Backend.GetAllGuildRoles(guildID)
 }
 
 // RemoveGuildRoles removes all guild roles of a specifi guild from the cache.
 func (ss *SandwichState) RemoveAllGuildRoles(guildID discord.GuildID) {
-	ss.GuildRoles.ClearKey(guildID)
+	ss.Backend.RemoveAllGuildRoles(guildID)
 }
 
 //
@@ -333,7 +363,7 @@ func (ss *SandwichState) RemoveAllGuildRoles(guildID discord.GuildID) {
 // GetGuildEmoji returns the emoji with the same ID from the cache. Populated user field from cache.
 // Returns a boolean to signify a match or not.
 func (ss *SandwichState) GetGuildEmoji(guildID discord.GuildID, emojiID discord.EmojiID) (guildEmoji discord.Emoji, ok bool) {
-	guildEmojis, ok := ss.GuildEmojis.Load(guildID)
+	guildEmojis, ok := ss.Backend.GetGuildEmojis(guildID)
 
 	if !ok {
 		return
@@ -361,7 +391,11 @@ func (ss *SandwichState) GetGuildEmoji(guildID discord.GuildID, emojiID discord.
 //
 // fake-ctx-safe
 func (ss *SandwichState) SetGuildEmojis(ctx StateCtx, guildID discord.GuildID, emojis []discord.Emoji) {
-	ss.GuildEmojis.Store(guildID, emojis)
+	if !ctx.Caches(guildID, CacheCollectionEmojis) {
+		return
+	}
+
+	ss.Backend.SetGuildEmojis(guildID, emojis)
 
 	for _, emoji := range emojis {
 		if emoji.User != nil {
@@ -372,12 +406,12 @@ func (ss *SandwichState) SetGuildEmojis(ctx StateCtx, guildID discord.GuildID, e
 
 // GetAllGuildEmojis returns all guildEmojis on a specific guild from the cache.
 func (ss *SandwichState) GetAllGuildEmojis(guildID discord.GuildID) (guildEmojisList []discord.Emoji, ok bool) {
-	return ss.GuildEmojis.Load(guildID)
+	return ss.Backend.GetGuildEmojis(guildID)
 }
 
 // RemoveGuildEmojis removes all guildEmojis of a specific guild from the cache.
 func (ss *SandwichState) RemoveAllGuildEmojis(guildID discord.GuildID) {
-	ss.GuildEmojis.Delete(guildID)
+	ss.Backend.RemoveGuildEmojis(guildID)
 }
 
 //
@@ -400,7 +434,7 @@ func (ss *SandwichState) UserToState(user discord.User) StateUser {
 // GetUser returns the user with the same ID from the cache.
 // Returns a boolean to signify a match or not.
 func (ss *SandwichState) GetUser(userID discord.UserID) (user discord.User, ok bool) {
-	stateUser, ok := ss.Users.Load(userID)
+	stateUser, ok := ss.Backend.GetUser(userID)
 
 	if !ok {
 		return
@@ -420,12 +454,12 @@ func (ss *SandwichState) SetUser(ctx StateCtx, user discord.User) {
 		return
 	}
 
-	ss.Users.Store(user.ID, ss.UserToState(user))
+	ss.Backend.SetUser(ss.UserToState(ctx.trimUser(user)))
 }
 
 // RemoveUser removes a user from the cache.
 func (ss *SandwichState) RemoveUser(userID discord.UserID) {
-	ss.Users.Delete(userID)
+	ss.Backend.RemoveUser(userID)
 }
 
 //
@@ -435,7 +469,7 @@ func (ss *SandwichState) RemoveUser(userID discord.UserID) {
 // GetGuildChannel returns the channel with the same ID from the cache.
 // Returns a boolean to signify a match or not.
 func (ss *SandwichState) GetGuildChannel(guildID discord.GuildID, channelID discord.ChannelID) (guildChannel discord.Channel, ok bool) {
-	guildChannel, ok = ss.GuildChannels.Load(guildID, channelID)
+	guildChannel, ok = ss.Backend.GetGuildChannel(guildID, channelID)
 
 	if !ok {
 		return guildChannel, false
@@ -461,10 +495,14 @@ func (ss *SandwichState) GetGuildChannel(guildID discord.GuildID, channelID disc
 //
 // fake-ctx-safe
 func (ss *SandwichState) SetGuildChannel(ctx StateCtx, guildID discord.GuildID, channel discord.Channel) {
+	if !ctx.Caches(guildID, CacheCollectionChannels) {
+		return
+	}
+
 	// Ensure channel has guild id set
 	channel.GuildID = &guildID
 
-	ss.GuildChannels.Store(guildID, channel.ID, channel)
+	ss.Backend.SetGuildChannel(guildID, channel)
 
 	for _, recipient := range channel.Recipients {
 		recipient := recipient
@@ -474,12 +512,12 @@ func (ss *SandwichState) SetGuildChannel(ctx StateCtx, guildID discord.GuildID,
 
 // RemoveGuildChannel removes a channel from the cache.
 func (ss *SandwichState) RemoveGuildChannel(guildID discord.GuildID, channelID discord.ChannelID) {
-	ss.GuildChannels.Delete(guildID, channelID)
+	ss.Backend.RemoveGuildChannel(guildID, channelID)
 }
 
 // Runs a function on a guild channel in the cache, updating the value in cache based on returned value.
 func (ss *SandwichState) UpdateGuildChannel(guildID discord.GuildID, channelID discord.ChannelID, fn func(channel discord.Channel) discord.Channel) (channel discord.Channel, ok bool) {
-	return ss.GuildChannels.Update(guildID, channelID, fn)
+	return ss.Backend.UpdateGuildChannel(guildID, channelID, fn)
 }
 
 // GetChannel returns a channel from its ID searching both DMs and guild channels.
@@ -502,31 +540,17 @@ func (ss *SandwichState) GetChannel(guildIdHint *discord.GuildID, channelID disc
 
 // GetAllGuildChannels returns all guildChannels of a specific guild from the cache.
 func (ss *SandwichState) GetAllGuildChannels(guildID discord.GuildID) (guildChannelsList []discord.Channel, ok bool) {
-	guildChannels, ok := ss.GuildChannels.Inner(guildID)
-
-	if !ok {
-		return
-	}
-
-	// Pre-allocate the list
-	guildChannelsList = make([]discord.Channel, 0, guildChannels.Count())
-
-	guildChannels.Range(func(_ discord.ChannelID, guildChannel discord.Channel) bool {
-		guildChannelsList = append(guildChannelsList, guildChannel)
-		return false
-	})
-
-	return
+	return ss.Backend.GetAllGuildChannels(guildID)
 }
 
 // RemoveAllGuildChannels removes all guildChannels of a specific guild from the cache.
 func (ss *SandwichState) RemoveAllGuildChannels(guildID discord.GuildID) {
-	ss.GuildChannels.ClearKey(guildID)
+	ss.Backend.RemoveAllGuildChannels(guildID)
 }
 
 // GetDMChannel returns the DM channel of a user.
 func (ss *SandwichState) GetDMChannel(channelID discord.ChannelID) (channel discord.Channel, ok bool) {
-	dmChannel, ok := ss.DmChannels.Load(channelID)
+	dmChannel, ok := ss.Backend.GetDMChannel(channelID)
 
 	if !ok || int64(dmChannel.ExpiresAt) < time.Now().Unix() {
 		ok = false
@@ -537,14 +561,20 @@ func (ss *SandwichState) GetDMChannel(channelID discord.ChannelID) (channel disc
 	channel = dmChannel.Channel
 	dmChannel.ExpiresAt = discord.Int64(time.Now().Add(memberDMExpiration).Unix())
 
-	ss.DmChannels.Store(channelID, dmChannel)
+	ss.Backend.SetDMChannel(dmChannel)
 
 	return
 }
 
 // AddDMChannel adds a DM channel to a user.
-func (ss *SandwichState) AddDMChannel(userID discord.UserID, channel discord.Channel) {
-	ss.DmChannels.Store(channel.ID, StateDMChannel{
+//
+// fake-ctx-safe
+func (ss *SandwichState) AddDMChannel(ctx StateCtx, userID discord.UserID, channel discord.Channel) {
+	if !ctx.Caches(0, CacheCollectionDMChannels) {
+		return
+	}
+
+	ss.Backend.SetDMChannel(StateDMChannel{
 		Channel:   channel,
 		UserID:    userID,
 		ExpiresAt: discord.Int64(time.Now().Add(memberDMExpiration).Unix()),
@@ -553,51 +583,40 @@ func (ss *SandwichState) AddDMChannel(userID discord.UserID, channel discord.Cha
 
 // RemoveDMChannel removes a DM channel given channel id.
 func (ss *SandwichState) RemoveDMChannelByChannelID(channelID discord.ChannelID) {
-	ss.DmChannels.Delete(channelID)
+	ss.Backend.RemoveDMChannel(channelID)
 }
 
 // RemoveDMChannel removes a DM channel given user id.
 func (ss *SandwichState) RemoveDMChannelByUserID(userID discord.UserID) {
-	var channelID discord.ChannelID
-
-	ss.DmChannels.Range(func(id discord.ChannelID, dmChannel StateDMChannel) bool {
+	for _, dmChannel := range ss.Backend.GetAllDMChannels() {
 		if dmChannel.UserID == userID {
-			channelID = id
-			return true
-		}
-
-		return false
-	})
+			ss.Backend.RemoveDMChannel(dmChannel.ID)
 
-	if channelID != 0 {
-		ss.DmChannels.Delete(channelID)
+			return
+		}
 	}
 }
 
 // Runs a function on a DM channel in the cache, updating the value in cache based on returned value.
 func (ss *SandwichState) UpdateDMChannelByChannelID(channelID discord.ChannelID, fn func(old StateDMChannel) StateDMChannel) (channel StateDMChannel, ok bool) {
-	return ss.DmChannels.Update(channelID, fn)
-}
-
-// GetUserMutualGuilds returns a list of snowflakes of mutual guilds a member is seen on.
-func (ss *SandwichState) GetUserMutualGuilds(userID discord.UserID) (guildIDs []discord.GuildID, ok bool) {
-	mutualGuilds, ok := ss.Mutuals.Inner(userID)
+	channel, ok = ss.Backend.GetDMChannel(channelID)
 
 	if !ok {
 		return
 	}
 
-	// Pre-allocate the list
-	guildIDs = make([]discord.GuildID, 0, mutualGuilds.Count())
+	channel = fn(channel)
 
-	mutualGuilds.Range(func(guildID discord.GuildID, _ struct{}) bool {
-		guildIDs = append(guildIDs, guildID)
-		return false
-	})
+	ss.Backend.SetDMChannel(channel)
 
 	return
 }
 
+// GetUserMutualGuilds returns a list of snowflakes of mutual guilds a member is seen on.
+func (ss *SandwichState) GetUserMutualGuilds(userID discord.UserID) (guildIDs []discord.GuildID, ok bool) {
+	return ss.Backend.GetUserMutualGuilds(userID)
+}
+
 // AddUserMutualGuild adds a mutual guild to a user.
 //
 // fake-ctx-safe
@@ -606,12 +625,12 @@ func (ss *SandwichState) AddUserMutualGuild(ctx StateCtx, userID discord.UserID,
 		return
 	}
 
-	ss.Mutuals.Store(userID, guildID, struct{}{})
+	ss.Backend.AddUserMutualGuild(userID, guildID)
 }
 
 // RemoveUserMutualGuild removes a mutual guild from a user.
 func (ss *SandwichState) RemoveUserMutualGuild(userID discord.UserID, guildID discord.GuildID) {
-	ss.Mutuals.Delete(userID, guildID)
+	ss.Backend.RemoveUserMutualGuild(userID, guildID)
 }
 
 //
@@ -632,7 +651,7 @@ func (ss *SandwichState) ParseVoiceState(guildID discord.GuildID, userID discord
 }
 
 func (ss *SandwichState) GetVoiceState(guildID discord.GuildID, userID discord.UserID) (voiceState discord.VoiceState, ok bool) {
-	stateVoiceState, ok := ss.GuildVoiceStates.Load(guildID, userID)
+	stateVoiceState, ok := ss.Backend.GetVoiceState(guildID, userID)
 
 	if !ok {
 		return
@@ -647,19 +666,17 @@ func (ss *SandwichState) GetVoiceState(guildID discord.GuildID, userID discord.U
 //
 // fake-ctx-safe
 func (ss *SandwichState) UpdateVoiceState(ctx StateCtx, voiceState discord.VoiceState) {
-	if voiceState.GuildID == nil {
+	if voiceState.GuildID == nil || !ctx.Caches(*voiceState.GuildID, CacheCollectionVoiceStates) {
 		return
 	}
 
-	guildVoiceStates := ss.GuildVoiceStates.LoadOrNew(*voiceState.GuildID)
-
 	beforeVoiceState, _ := ss.GetVoiceState(*voiceState.GuildID, voiceState.UserID)
 
 	if voiceState.ChannelID == 0 {
 		// Remove from voice states if leaving voice channel.
-		guildVoiceStates.Delete(voiceState.UserID)
+		ss.Backend.RemoveVoiceState(*voiceState.GuildID, voiceState.UserID)
 	} else {
-		guildVoiceStates.Store(voiceState.UserID, ss.ParseVoiceState(*voiceState.GuildID, voiceState.UserID, voiceState))
+		ss.Backend.SetVoiceState(*voiceState.GuildID, ss.ParseVoiceState(*voiceState.GuildID, voiceState.UserID, voiceState))
 	}
 
 	if voiceState.Member != nil {
@@ -702,13 +719,13 @@ func (ss *SandwichState) UpdateVoiceState(ctx StateCtx, voiceState discord.Voice
 }
 
 func (ss *SandwichState) RemoveVoiceState(ctx StateCtx, guildID discord.GuildID, userID discord.UserID) {
-	svs, ok := ss.GuildVoiceStates.Load(guildID, userID)
+	svs, ok := ss.Backend.GetVoiceState(guildID, userID)
 
 	if !ok {
 		return
 	}
 
-	ss.GuildVoiceStates.Delete(guildID, userID)
+	ss.Backend.RemoveVoiceState(guildID, userID)
 
 	// Update channel counts.
 
@@ -721,7 +738,7 @@ func (ss *SandwichState) RemoveVoiceState(ctx StateCtx, guildID discord.GuildID,
 }
 
 func (ss *SandwichState) CountMembersForVoiceChannel(guildID discord.GuildID, channelID discord.ChannelID) int32 {
-	guildVoiceStates, ok := ss.GuildVoiceStates.Inner(guildID)
+	guildVoiceStates, ok := ss.Backend.GetAllVoiceStates(guildID)
 
 	if !ok {
 		return 0
@@ -729,12 +746,11 @@ func (ss *SandwichState) CountMembersForVoiceChannel(guildID discord.GuildID, ch
 
 	var count int32
 
-	guildVoiceStates.Range(func(_ discord.UserID, voiceState discord.VoiceState) bool {
+	for _, voiceState := range guildVoiceStates {
 		if voiceState.ChannelID == channelID {
 			count++
 		}
-		return false
-	})
+	}
 
 	return count
 }
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+// StateBackend stores the entities that make up SandwichState. Backends are only
+// responsible for storage, SandwichState handles populating and splitting entities.
+type StateBackend interface {
+	String() string
+
+	// Shared returns if the backend is shared with other processes, which may store guilds this
+	// process does not hold.
+	Shared() bool
+
+	GetGuild(guildID discord.GuildID) (guild discord.Guild, ok bool)
+	SetGuild(guild discord.Guild)
+	// UpdateGuild runs fn on a stored guild and stores the result, without another write to the
+	// guild being lost in between.
+	UpdateGuild(guildID discord.GuildID, fn func(guild discord.Guild) discord.Guild) (guild discord.Guild, ok bool)
+	RemoveGuild(guildID discord.GuildID)
+	GetAllGuildIDs() (guildIDs []discord.GuildID)
+
+	GetGuildMember(guildID discord.GuildID, userID discord.UserID) (guildMember discord.GuildMember, ok bool)
+	SetGuildMember(guildID discord.GuildID, guildMember discord.GuildMember)
+	RemoveGuildMember(guildID discord.GuildID, userID discord.UserID)
+	GetAllGuildMembers(guildID discord.GuildID) (guildMembers []discord.GuildMember, ok bool)
+	RemoveAllGuildMembers(guildID discord.GuildID)
+	CountGuildMembers(guildID discord.GuildID) int
+
+	GetGuildRole(guildID discord.GuildID, roleID discord.RoleID) (role discord.Role, ok bool)
+	SetGuildRole(guildID discord.GuildID, role discord.Role)
+	RemoveGuildRole(guildID discord.GuildID, roleID discord.RoleID)
+	GetAllGuildRoles(guildID discord.GuildID) (roles []discord.Role, ok bool)
+	RemoveAllGuildRoles(guildID discord.GuildID)
+
+	GetGuildEmojis(guildID discord.GuildID) (emojis []discord.Emoji, ok bool)
+	SetGuildEmojis(guildID discord.GuildID, emojis []discord.Emoji)
+	RemoveGuildEmojis(guildID discord.GuildID)
+
+	GetUser(userID discord.UserID) (user StateUser, ok bool)
+	SetUser(user StateUser)
+	RemoveUser(userID discord.UserID)
+
+	GetGuildChannel(guildID discord.GuildID, channelID discord.ChannelID) (channel discord.Channel, ok bool)
+	SetGuildChannel(guildID discord.GuildID, channel discord.Channel)
+	UpdateGuildChannel(guildID discord.GuildID, channelID discord.ChannelID, fn func(channel discord.Channel) discord.Channel) (channel discord.Channel, ok bool)
+	RemoveGuildChannel(guildID discord.GuildID, channelID discord.ChannelID)
+	GetAllGuildChannels(guildID discord.GuildID) (channels []discord.Channel, ok bool)
+	RemoveAllGuildChannels(guildID discord.GuildID)
+
+	GetDMChannel(channelID discord.ChannelID) (channel StateDMChannel, ok bool)
+	SetDMChannel(channel StateDMChannel)
+	RemoveDMChannel(channelID discord.ChannelID)
+	GetAllDMChannels() (channels []StateDMChannel)
+
+	GetUserMutualGuilds(userID discord.UserID) (guildIDs []discord.GuildID, ok bool)
+	AddUserMutualGuild(userID discord.UserID, guildID discord.GuildID)
+	RemoveUserMutualGuild(userID discord.UserID, guildID discord.GuildID)
+
+	GetVoiceState(guildID discord.GuildID, userID discord.UserID) (voiceState discord.VoiceState, ok bool)
+	SetVoiceState(guildID discord.GuildID, voiceState discord.VoiceState)
+	RemoveVoiceState(guildID discord.GuildID, userID discord.UserID)
+	GetAllVoiceStates(guildID discord.GuildID) (voiceStates []discord.VoiceState, ok bool)
+
+	// Counts returns the number of entities in each collection.
+	Counts() StateCounts
+}
+
+// StateCounts represents the number of entities stored by a StateBackend.
+type StateCounts struct {
+	Guilds      int
+	Members     int
+	Roles       int
+	Emojis      int
+	Users       int
+	Channels    int
+	VoiceStates int
+
+	// Sum of the member count of all guilds.
+	TotalMembers int
+}
+
+// Total returns the number of entities across all collections.
+func (sc StateCounts) Total() int {
+	return sc.Guilds + sc.Members + sc.Roles + sc.Emojis + sc.Users + sc.Channels + sc.VoiceStates
+}
+
+// NewStateBackend returns the StateBackend for the state configuration.
+func (sg *Sandwich) NewStateBackend() (StateBackend, error) {
+	sg.configurationMu.RLock()
+	stateConfiguration := sg.Configuration.State
+	sg.configurationMu.RUnlock()
+
+	switch stateConfiguration.Backend {
+	case "", "memory":
+		return NewMemoryStateBackend(), nil
+	case "redis":
+		return NewRedisStateBackend(sg.ctx, sg.Logger, stateConfiguration.Redis)
+	default:
+		return nil, ErrInvalidStateBackend
+	}
+}
+
+// setupStateBackend replaces the default in-memory backend with the configured backend.
+func (sg *Sandwich) setupStateBackend() {
+	backend, err := sg.NewStateBackend()
+	if err != nil {
+		sg.Logger.Error().Err(err).Msg("Failed to create state backend, using memory")
+
+		go sg.PublishSimpleWebhook("Failed to create state backend", "`"+err.Error()+"`", "", EmbedColourDanger)
+
+		return
+	}
+
+	sg.State.Backend = backend
+
+	sg.Logger.Info().Str("backend", backend.String()).Msg("Using state backend")
+}
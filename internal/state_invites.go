@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+const (
+	// Number of invite seeds a manager makes at once. Seeds wait for their ratelimit bucket.
+	inviteTrackerWorkers = 4
+
+	// Number of invite seeds that can be pending before new ones are dropped.
+	inviteTrackerQueueSize = 1000
+
+	// Maximum time GUILD_MEMBER_ADD waits for the invites of a guild before being dispatched
+	// without an invite.
+	inviteTrackTimeout = 5 * time.Second
+)
+
+// InviteUse is the invite a member most likely joined with.
+type InviteUse struct {
+	Inviter *discord.User `json:"inviter"`
+	Code    string        `json:"code"`
+}
+
+// FetchGuildInvites returns the invites of a guild from the REST API.
+func (mg *Manager) FetchGuildInvites(ctx context.Context, guildID discord.GuildID) (invites []discord.Invite, err error) {
+	mg.clientMu.Lock()
+	client := mg.Client
+	mg.clientMu.Unlock()
+
+	status, err := client.FetchJSON(ctx, http.MethodGet, "/guilds/"+strconv.FormatInt(int64(guildID), 10)+"/invites", nil, nil, &invites)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guild invites: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch guild invites: %w: %d", ErrUnexpectedStatus, status)
+	}
+
+	return invites, nil
+}
+
+type inviteGuildLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// InviteTracker seeds guild invites off the dispatch path with a bounded number of workers.
+// Fetches of the same guild are serialized, so uses are diffed against the previous fetch.
+type InviteTracker struct {
+	seeds chan discord.GuildID
+
+	guildLocks   map[discord.GuildID]*inviteGuildLock
+	guildLocksMu sync.Mutex
+
+	startOnce sync.Once
+}
+
+func NewInviteTracker() *InviteTracker {
+	return &InviteTracker{
+		seeds:      make(chan discord.GuildID, inviteTrackerQueueSize),
+		guildLocks: make(map[discord.GuildID]*inviteGuildLock),
+	}
+}
+
+// lockGuild locks the invites of a guild and returns the function to unlock them.
+func (it *InviteTracker) lockGuild(guildID discord.GuildID) (unlock func()) {
+	it.guildLocksMu.Lock()
+
+	lock, ok := it.guildLocks[guildID]
+	if !ok {
+		lock = &inviteGuildLock{}
+		it.guildLocks[guildID] = lock
+	}
+
+	lock.refs++
+
+	it.guildLocksMu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		it.guildLocksMu.Lock()
+
+		lock.refs--
+		if lock.refs == 0 {
+			delete(it.guildLocks, guildID)
+		}
+
+		it.guildLocksMu.Unlock()
+	}
+}
+
+func (mg *Manager) inviteWorker() {
+	for {
+		select {
+		case <-mg.ctx.Done():
+			return
+		case guildID := <-mg.inviteTracker.seeds:
+			mg.SeedGuildInvites(mg.ctx, guildID)
+		}
+	}
+}
+
+// QueueSeedGuildInvites queues replacing the cached invites of a guild with the invites from the REST API,
+// starting the workers of the manager on first use.
+func (mg *Manager) QueueSeedGuildInvites(guildID discord.GuildID) {
+	mg.inviteTracker.startOnce.Do(func() {
+		for i := 0; i < inviteTrackerWorkers; i++ {
+			go mg.inviteWorker()
+		}
+	})
+
+	select {
+	case mg.inviteTracker.seeds <- guildID:
+	default:
+		mg.Logger.Debug().Int64("guild_id", int64(guildID)).Msg("Invite queue is full, not seeding guild invites")
+	}
+}
+
+// SeedGuildInvites replaces the cached invites of a guild with the invites from the REST API.
+func (mg *Manager) SeedGuildInvites(ctx context.Context, guildID discord.GuildID) {
+	defer mg.inviteTracker.lockGuild(guildID)()
+
+	invites, err := mg.FetchGuildInvites(ctx, guildID)
+	if err != nil {
+		mg.Logger.Debug().Err(err).Int64("guild_id", int64(guildID)).Msg("Failed to seed guild invites")
+
+		return
+	}
+
+	mg.Sandwich.State.SetGuildInvites(guildID, invites)
+}
+
+// TrackGuildInviteUse fetches the current invites of a guild and compares the uses against the cached
+// invites to find the invite that was most likely used. The cached invites are replaced by the fetched
+// invites. Returns nil if the guild had not been seeded, as every invite would appear used.
+func (mg *Manager) TrackGuildInviteUse(ctx context.Context, guildID discord.GuildID) (inviteUse *InviteUse, err error) {
+	defer mg.inviteTracker.lockGuild(guildID)()
+
+	ctx, cancel := context.WithTimeout(ctx, inviteTrackTimeout)
+	defer cancel()
+
+	invites, err := mg.FetchGuildInvites(ctx, guildID)
+	if err != nil {
+		// The cached uses no longer match, so the next join only seeds the guild.
+		mg.Sandwich.State.RemoveAllGuildInvites(guildID)
+
+		return nil, err
+	}
+
+	if mg.Sandwich.State.GuildInvitesSeeded.Has(guildID) {
+		inviteUse = mg.Sandwich.State.DiffGuildInvites(guildID, invites)
+	}
+
+	mg.Sandwich.State.SetGuildInvites(guildID, invites)
+
+	return inviteUse, nil
+}
+
+// GetAllGuildInvites returns the cached invites of a guild.
+func (ss *SandwichState) GetAllGuildInvites(guildID discord.GuildID) (invites []discord.Invite, ok bool) {
+	return cacheValues(&ss.GuildInvites, guildID)
+}
+
+// SetGuildInvites replaces the cached invites of a guild and marks the guild as seeded.
+func (ss *SandwichState) SetGuildInvites(guildID discord.GuildID, invites []discord.Invite) {
+	ss.GuildInvites.ClearKey(guildID)
+	ss.GuildInvitesSeeded.Store(guildID, struct{}{})
+
+	for _, invite := range invites {
+		ss.SetGuildInvite(guildID, invite)
+	}
+}
+
+// SetGuildInvite creates or updates an invite in the cache.
+func (ss *SandwichState) SetGuildInvite(guildID discord.GuildID, invite discord.Invite) {
+	// Only the fields needed to track uses are kept.
+	ss.GuildInvites.Store(guildID, invite.Code, discord.Invite{
+		Code:      invite.Code,
+		Inviter:   invite.Inviter,
+		Uses:      invite.Uses,
+		MaxUses:   invite.MaxUses,
+		ExpiresAt: invite.ExpiresAt,
+	})
+}
+
+// RemoveGuildInvite removes an invite from the cache.
+func (ss *SandwichState) RemoveGuildInvite(guildID discord.GuildID, code string) {
+	ss.GuildInvites.Delete(guildID, code)
+}
+
+// RemoveAllGuildInvites removes all invites of a guild from the cache, the guild must be seeded again
+// before invite uses are tracked.
+func (ss *SandwichState) RemoveAllGuildInvites(guildID discord.GuildID) {
+	ss.GuildInvites.ClearKey(guildID)
+	ss.GuildInvitesSeeded.Delete(guildID)
+}
+
+// DiffGuildInvites compares fetched invites against the cached invites of a guild and returns the
+// invite with the largest increase in uses. If no uses increased, a cached invite that is missing
+// from the fetched invites and was one use away from its limit is returned. Returns nil if no
+// invite could be found.
+func (ss *SandwichState) DiffGuildInvites(guildID discord.GuildID, invites []discord.Invite) (inviteUse *InviteUse) {
+	var bestIncrease int32
+
+	for _, invite := range invites {
+		before, _ := ss.GuildInvites.Load(guildID, invite.Code)
+
+		if increase := invite.Uses - before.Uses; increase > bestIncrease {
+			bestIncrease = increase
+			inviteUse = &InviteUse{
+				Code:    invite.Code,
+				Inviter: invite.Inviter,
+			}
+		}
+	}
+
+	if inviteUse != nil {
+		return inviteUse
+	}
+
+	// Invites that reach their max uses are deleted, so they are missing from the fetched invites.
+	fetched := make(map[string]bool, len(invites))
+	for _, invite := range invites {
+		fetched[invite.Code] = true
+	}
+
+	cached, _ := ss.GetAllGuildInvites(guildID)
+
+	sort.Slice(cached, func(i, j int) bool {
+		return cached[i].Code < cached[j].Code
+	})
+
+	for _, invite := range cached {
+		if !fetched[invite.Code] && invite.MaxUses > 0 && invite.Uses+1 >= invite.MaxUses {
+			return &InviteUse{
+				Code:    invite.Code,
+				Inviter: invite.Inviter,
+			}
+		}
+	}
+
+	return nil
+}
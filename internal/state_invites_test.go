@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"go.uber.org/atomic"
+)
+
+func TestDiffGuildInvites(t *testing.T) {
+	state := NewSandwichState()
+
+	state.SetGuildInvites(1, []discord.Invite{
+		{Code: "a", Uses: 1},
+		{Code: "b", Uses: 4},
+		{Code: "c", Uses: 2, MaxUses: 3},
+	})
+
+	inviteUse := state.DiffGuildInvites(1, []discord.Invite{
+		{Code: "a", Uses: 1},
+		{Code: "b", Uses: 5, Inviter: &discord.User{ID: 2}},
+		{Code: "c", Uses: 2, MaxUses: 3},
+	})
+
+	if inviteUse == nil || inviteUse.Code != "b" || inviteUse.Inviter.ID != 2 {
+		t.Errorf("Expected invite b to be used, got %+v", inviteUse)
+	}
+
+	// Invites are deleted once they reach their max uses.
+	inviteUse = state.DiffGuildInvites(1, []discord.Invite{
+		{Code: "a", Uses: 1},
+		{Code: "b", Uses: 4},
+	})
+
+	if inviteUse == nil || inviteUse.Code != "c" {
+		t.Errorf("Expected invite c to be used, got %+v", inviteUse)
+	}
+}
+
+func TestInviteTrackerLockGuild(t *testing.T) {
+	tracker := NewInviteTracker()
+
+	unlock := tracker.lockGuild(1)
+
+	locked := make(chan void)
+
+	go func() {
+		defer tracker.lockGuild(1)()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected guild to stay locked")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Other guilds are not blocked.
+	tracker.lockGuild(2)()
+
+	unlock()
+	<-locked
+
+	tracker.guildLocksMu.Lock()
+	defer tracker.guildLocksMu.Unlock()
+
+	if len(tracker.guildLocks) != 0 {
+		t.Errorf("expected guild locks to be removed, got %d", len(tracker.guildLocks))
+	}
+}
+
+func TestTrackGuildInviteUse(t *testing.T) {
+	var uses atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"code":"a","uses":%d,"inviter":{"id":"2"}}]`, uses.Inc())
+	}))
+	defer server.Close()
+
+	_, mg := newProxyTestSandwich(t, server.URL)
+
+	// Invites are not attributed until the guild is seeded, as every invite with uses would appear used.
+	inviteUse, err := mg.TrackGuildInviteUse(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inviteUse != nil {
+		t.Fatalf("expected no invite before the guild is seeded, got %+v", inviteUse)
+	}
+
+	inviteUse, err = mg.TrackGuildInviteUse(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inviteUse == nil || inviteUse.Code != "a" || inviteUse.Inviter.ID != 2 {
+		t.Fatalf("expected invite a to be used, got %+v", inviteUse)
+	}
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"sort"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+const (
+	// All members are kept in the cache.
+	MemberRetentionAll = "all"
+	// Members are evicted least recently active first once a guild or all guilds are over their limit.
+	MemberRetentionActive = "active"
+)
+
+// MemberRetentionConfiguration configures how many guild members are kept in state.
+type MemberRetentionConfiguration struct {
+	// Either "all" or "active". Defaults to "all".
+	Retention string `json:"retention" yaml:"retention"`
+	// Maximum number of members kept for each guild when using "active". 0 is unlimited.
+	MaxPerGuild int `json:"max_per_guild" yaml:"max_per_guild"`
+	// Maximum number of members kept across all guilds when using "active". 0 is unlimited.
+	MaxTotal int `json:"max_total" yaml:"max_total"`
+}
+
+type guildMemberActivity struct {
+	guildID    discord.GuildID
+	userID     discord.UserID
+	lastActive int64
+}
+
+// TouchGuildMember marks a guildMember as recently active. Does nothing unless member
+// activity is tracked.
+func (ss *SandwichState) TouchGuildMember(guildID discord.GuildID, userID discord.UserID) {
+	if !ss.TrackMemberActivity.Load() || guildID.IsNil() || userID == 0 {
+		return
+	}
+
+	ss.MemberActivity.Store(guildID, userID, time.Now().UnixNano())
+}
+
+// EvictGuildMembers removes the least recently active guildMembers of any guild with more
+// than maxPerGuild members, then across all guilds until there are at most maxTotal members.
+// Members of users in keep are never evicted. Returns the number of members evicted.
+func (ss *SandwichState) EvictGuildMembers(maxPerGuild int, maxTotal int, keep map[discord.UserID]struct{}) (evicted int) {
+	guildIDs := ss.GetAllGuildIDs()
+
+	total := 0
+	counts := make(map[discord.GuildID]int, len(guildIDs))
+
+	for _, guildID := range guildIDs {
+		counts[guildID] = ss.CountGuildMembers(guildID)
+		total += counts[guildID]
+	}
+
+	if maxTotal > 0 && total <= maxTotal {
+		maxTotal = 0
+	}
+
+	candidates := make([]guildMemberActivity, 0)
+
+	for _, guildID := range guildIDs {
+		count := counts[guildID]
+
+		if maxTotal <= 0 && (maxPerGuild <= 0 || count <= maxPerGuild) {
+			continue
+		}
+
+		activity := ss.guildMemberActivity(guildID, keep)
+
+		if maxPerGuild > 0 && count > maxPerGuild {
+			// Kept members still count towards the limit of the guild.
+			allowed := max(maxPerGuild-(count-len(activity)), 0)
+
+			if len(activity) > allowed {
+				for _, member := range activity[:len(activity)-allowed] {
+					ss.RemoveGuildMember(member.guildID, member.userID)
+				}
+
+				evicted += len(activity) - allowed
+				activity = activity[len(activity)-allowed:]
+			}
+		}
+
+		if maxTotal > 0 {
+			candidates = append(candidates, activity...)
+		}
+	}
+
+	if maxTotal > 0 && total-evicted > maxTotal {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].lastActive < candidates[j].lastActive
+		})
+
+		excess := min(total-evicted-maxTotal, len(candidates))
+
+		for _, member := range candidates[:excess] {
+			ss.RemoveGuildMember(member.guildID, member.userID)
+		}
+
+		evicted += excess
+	}
+
+	return evicted
+}
+
+// guildMemberActivity returns the activity of all guildMembers in a guild, least recently active first.
+// Members that have never been active are first.
+func (ss *SandwichState) guildMemberActivity(guildID discord.GuildID, keep map[discord.UserID]struct{}) []guildMemberActivity {
+	guildMembers, _ := ss.GetAllGuildMembers(guildID)
+
+	activity := make([]guildMemberActivity, 0, len(guildMembers))
+
+	for _, guildMember := range guildMembers {
+		if guildMember.User == nil {
+			continue
+		}
+
+		if _, ok := keep[guildMember.User.ID]; ok {
+			continue
+		}
+
+		lastActive, _ := ss.MemberActivity.Load(guildID, guildMember.User.ID)
+
+		activity = append(activity, guildMemberActivity{
+			guildID:    guildID,
+			userID:     guildMember.User.ID,
+			lastActive: lastActive,
+		})
+	}
+
+	sort.Slice(activity, func(i, j int) bool {
+		return activity[i].lastActive < activity[j].lastActive
+	})
+
+	return activity
+}
+
+// memberRetention returns the member retention configuration.
+func (sg *Sandwich) memberRetention() MemberRetentionConfiguration {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	return sg.Configuration.State.Members
+}
+
+// ejectGuildMembers enforces the member retention limits.
+func (sg *Sandwich) ejectGuildMembers() (evicted int) {
+	retention := sg.memberRetention()
+
+	active := retention.Retention == MemberRetentionActive
+	sg.State.TrackMemberActivity.Store(active)
+
+	if !active || (retention.MaxPerGuild <= 0 && retention.MaxTotal <= 0) {
+		return 0
+	}
+
+	// The members of our own bots are never evicted.
+	keep := make(map[discord.UserID]struct{})
+
+	sg.Managers.Range(func(_ string, mg *Manager) bool {
+		mg.userMu.RLock()
+		keep[mg.User.ID] = struct{}{}
+		mg.userMu.RUnlock()
+
+		return false
+	})
+
+	evicted = sg.State.EvictGuildMembers(retention.MaxPerGuild, retention.MaxTotal, keep)
+
+	sandwichStateMemberCacheEvictions.Add(float64(evicted))
+
+	return evicted
+}
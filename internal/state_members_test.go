@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+func TestEvictGuildMembers(t *testing.T) {
+	state := NewSandwichState()
+	state.TrackMemberActivity.Store(true)
+
+	ctx := StateCtx{
+		CacheUsers:   true,
+		CacheMembers: true,
+		Shard: &Shard{
+			Manager: &Manager{User: discord.User{ID: 1}},
+		},
+	}
+
+	for guildID := discord.GuildID(1); guildID <= 2; guildID++ {
+		state.Backend.SetGuild(discord.Guild{ID: guildID})
+
+		for userID := discord.UserID(1); userID <= 5; userID++ {
+			state.SetGuildMember(ctx, guildID, discord.GuildMember{User: &discord.User{ID: userID}})
+		}
+	}
+
+	state.TouchGuildMember(1, 2)
+	state.TouchGuildMember(1, 3)
+	state.TouchGuildMember(2, 4)
+
+	keep := map[discord.UserID]struct{}{1: {}}
+
+	if evicted := state.EvictGuildMembers(3, 0, keep); evicted != 4 {
+		t.Errorf("Expected 4 members to be evicted, got %d", evicted)
+	}
+
+	for _, userID := range []discord.UserID{1, 2, 3} {
+		if _, ok := state.GetGuildMember(1, userID); !ok {
+			t.Errorf("Expected member %d to be kept in guild 1", userID)
+		}
+	}
+
+	if evicted := state.EvictGuildMembers(0, 4, keep); evicted != 2 {
+		t.Errorf("Expected 2 members to be evicted, got %d", evicted)
+	}
+
+	if _, ok := state.GetGuildMember(2, 4); !ok {
+		t.Errorf("Expected most recently active member to be kept")
+	}
+
+	if _, ok := state.GetGuildMember(2, 1); !ok {
+		t.Errorf("Expected kept member to not be evicted")
+	}
+}
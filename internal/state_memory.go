@@ -0,0 +1,330 @@
+package internal
+
+import (
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+// MemoryStateBackend stores state in the memory of the sandwich process.
+type MemoryStateBackend struct {
+	Guilds Cache[discord.GuildID, discord.Guild]
+
+	GuildMembers DoubleCache[discord.GuildID, discord.UserID, discord.GuildMember]
+
+	GuildChannels DoubleCache[discord.GuildID, discord.ChannelID, discord.Channel]
+
+	GuildRoles DoubleCache[discord.GuildID, discord.RoleID, discord.Role]
+
+	GuildEmojis Cache[discord.GuildID, []discord.Emoji]
+
+	Users Cache[discord.UserID, StateUser]
+
+	DmChannels Cache[discord.ChannelID, StateDMChannel]
+
+	Mutuals DoubleCache[discord.UserID, discord.GuildID, struct{}]
+
+	GuildVoiceStates DoubleCache[discord.GuildID, discord.UserID, discord.VoiceState]
+}
+
+func NewMemoryStateBackend() *MemoryStateBackend {
+	return &MemoryStateBackend{
+		Guilds: NewCache[discord.GuildID, discord.Guild](100),
+
+		GuildMembers: NewDoubleCache[discord.GuildID, discord.UserID, discord.GuildMember](0, 50),
+
+		GuildChannels: NewDoubleCache[discord.GuildID, discord.ChannelID, discord.Channel](0, 50),
+
+		GuildRoles: NewDoubleCache[discord.GuildID, discord.RoleID, discord.Role](0, 50),
+
+		GuildEmojis: NewCache[discord.GuildID, []discord.Emoji](50),
+
+		Users: NewCache[discord.UserID, StateUser](100),
+
+		DmChannels: NewCache[discord.ChannelID, StateDMChannel](50),
+
+		Mutuals: NewDoubleCache[discord.UserID, discord.GuildID, struct{}](0, 50),
+
+		GuildVoiceStates: NewDoubleCache[discord.GuildID, discord.UserID, discord.VoiceState](0, 50),
+	}
+}
+
+func (ms *MemoryStateBackend) String() string {
+	return "memory"
+}
+
+func (ms *MemoryStateBackend) Shared() bool {
+	return false
+}
+
+//
+// Guild Operations
+//
+
+func (ms *MemoryStateBackend) GetGuild(guildID discord.GuildID) (guild discord.Guild, ok bool) {
+	return ms.Guilds.Load(guildID)
+}
+
+func (ms *MemoryStateBackend) SetGuild(guild discord.Guild) {
+	ms.Guilds.Store(guild.ID, guild)
+}
+
+func (ms *MemoryStateBackend) UpdateGuild(guildID discord.GuildID, fn func(guild discord.Guild) discord.Guild) (guild discord.Guild, ok bool) {
+	return ms.Guilds.Update(guildID, fn)
+}
+
+func (ms *MemoryStateBackend) RemoveGuild(guildID discord.GuildID) {
+	ms.Guilds.Delete(guildID)
+}
+
+func (ms *MemoryStateBackend) GetAllGuildIDs() (guildIDs []discord.GuildID) {
+	guildIDs = make([]discord.GuildID, 0, ms.Guilds.Count())
+
+	ms.Guilds.Range(func(guildID discord.GuildID, _ discord.Guild) bool {
+		guildIDs = append(guildIDs, guildID)
+		return false
+	})
+
+	return guildIDs
+}
+
+//
+// GuildMember Operations
+//
+
+func (ms *MemoryStateBackend) GetGuildMember(guildID discord.GuildID, userID discord.UserID) (guildMember discord.GuildMember, ok bool) {
+	return ms.GuildMembers.Load(guildID, userID)
+}
+
+func (ms *MemoryStateBackend) SetGuildMember(guildID discord.GuildID, guildMember discord.GuildMember) {
+	ms.GuildMembers.Store(guildID, guildMember.User.ID, guildMember)
+}
+
+func (ms *MemoryStateBackend) RemoveGuildMember(guildID discord.GuildID, userID discord.UserID) {
+	ms.GuildMembers.Delete(guildID, userID)
+}
+
+func (ms *MemoryStateBackend) GetAllGuildMembers(guildID discord.GuildID) (guildMembers []discord.GuildMember, ok bool) {
+	return cacheValues(&ms.GuildMembers, guildID)
+}
+
+func (ms *MemoryStateBackend) RemoveAllGuildMembers(guildID discord.GuildID) {
+	ms.GuildMembers.ClearKey(guildID)
+}
+
+func (ms *MemoryStateBackend) CountGuildMembers(guildID discord.GuildID) int {
+	return ms.GuildMembers.Count(guildID)
+}
+
+//
+// Role Operations
+//
+
+func (ms *MemoryStateBackend) GetGuildRole(guildID discord.GuildID, roleID discord.RoleID) (role discord.Role, ok bool) {
+	return ms.GuildRoles.Load(guildID, roleID)
+}
+
+func (ms *MemoryStateBackend) SetGuildRole(guildID discord.GuildID, role discord.Role) {
+	ms.GuildRoles.Store(guildID, role.ID, role)
+}
+
+func (ms *MemoryStateBackend) RemoveGuildRole(guildID discord.GuildID, roleID discord.RoleID) {
+	ms.GuildRoles.Delete(guildID, roleID)
+}
+
+func (ms *MemoryStateBackend) GetAllGuildRoles(guildID discord.GuildID) (roles []discord.Role, ok bool) {
+	guildRoles, ok := ms.GuildRoles.Inner(guildID)
+
+	if !ok {
+		return
+	}
+
+	// Pre-allocate the list
+	roles = make([]discord.Role, 0, guildRoles.Count())
+
+	guildRoles.Range(func(id discord.RoleID, role discord.Role) bool {
+		if role.ID == 0 {
+			role.ID = id
+		}
+
+		roles = append(roles, role)
+		return false
+	})
+
+	return
+}
+
+func (ms *MemoryStateBackend) RemoveAllGuildRoles(guildID discord.GuildID) {
+	ms.GuildRoles.ClearKey(guildID)
+}
+
+//
+// Emoji Operations
+//
+
+func (ms *MemoryStateBackend) GetGuildEmojis(guildID discord.GuildID) (emojis []discord.Emoji, ok bool) {
+	return ms.GuildEmojis.Load(guildID)
+}
+
+func (ms *MemoryStateBackend) SetGuildEmojis(guildID discord.GuildID, emojis []discord.Emoji) {
+	ms.GuildEmojis.Store(guildID, emojis)
+}
+
+func (ms *MemoryStateBackend) RemoveGuildEmojis(guildID discord.GuildID) {
+	ms.GuildEmojis.Delete(guildID)
+}
+
+//
+// User Operations
+//
+
+func (ms *MemoryStateBackend) GetUser(userID discord.UserID) (user StateUser, ok bool) {
+	return ms.Users.Load(userID)
+}
+
+func (ms *MemoryStateBackend) SetUser(user StateUser) {
+	ms.Users.Store(user.ID, user)
+}
+
+func (ms *MemoryStateBackend) RemoveUser(userID discord.UserID) {
+	ms.Users.Delete(userID)
+}
+
+//
+// Channel Operations
+//
+
+func (ms *MemoryStateBackend) GetGuildChannel(guildID discord.GuildID, channelID discord.ChannelID) (channel discord.Channel, ok bool) {
+	return ms.GuildChannels.Load(guildID, channelID)
+}
+
+func (ms *MemoryStateBackend) SetGuildChannel(guildID discord.GuildID, channel discord.Channel) {
+	ms.GuildChannels.Store(guildID, channel.ID, channel)
+}
+
+func (ms *MemoryStateBackend) UpdateGuildChannel(guildID discord.GuildID, channelID discord.ChannelID, fn func(channel discord.Channel) discord.Channel) (channel discord.Channel, ok bool) {
+	return ms.GuildChannels.Update(guildID, channelID, fn)
+}
+
+func (ms *MemoryStateBackend) RemoveGuildChannel(guildID discord.GuildID, channelID discord.ChannelID) {
+	ms.GuildChannels.Delete(guildID, channelID)
+}
+
+func (ms *MemoryStateBackend) GetAllGuildChannels(guildID discord.GuildID) (channels []discord.Channel, ok bool) {
+	return cacheValues(&ms.GuildChannels, guildID)
+}
+
+func (ms *MemoryStateBackend) RemoveAllGuildChannels(guildID discord.GuildID) {
+	ms.GuildChannels.ClearKey(guildID)
+}
+
+func (ms *MemoryStateBackend) GetDMChannel(channelID discord.ChannelID) (channel StateDMChannel, ok bool) {
+	return ms.DmChannels.Load(channelID)
+}
+
+func (ms *MemoryStateBackend) SetDMChannel(channel StateDMChannel) {
+	ms.DmChannels.Store(channel.ID, channel)
+}
+
+func (ms *MemoryStateBackend) RemoveDMChannel(channelID discord.ChannelID) {
+	ms.DmChannels.Delete(channelID)
+}
+
+func (ms *MemoryStateBackend) GetAllDMChannels() (channels []StateDMChannel) {
+	channels = make([]StateDMChannel, 0, ms.DmChannels.Count())
+
+	ms.DmChannels.Range(func(_ discord.ChannelID, channel StateDMChannel) bool {
+		channels = append(channels, channel)
+		return false
+	})
+
+	return channels
+}
+
+//
+// Mutual Operations
+//
+
+func (ms *MemoryStateBackend) GetUserMutualGuilds(userID discord.UserID) (guildIDs []discord.GuildID, ok bool) {
+	mutualGuilds, ok := ms.Mutuals.Inner(userID)
+
+	if !ok {
+		return
+	}
+
+	// Pre-allocate the list
+	guildIDs = make([]discord.GuildID, 0, mutualGuilds.Count())
+
+	mutualGuilds.Range(func(guildID discord.GuildID, _ struct{}) bool {
+		guildIDs = append(guildIDs, guildID)
+		return false
+	})
+
+	return
+}
+
+func (ms *MemoryStateBackend) AddUserMutualGuild(userID discord.UserID, guildID discord.GuildID) {
+	ms.Mutuals.Store(userID, guildID, struct{}{})
+}
+
+func (ms *MemoryStateBackend) RemoveUserMutualGuild(userID discord.UserID, guildID discord.GuildID) {
+	ms.Mutuals.Delete(userID, guildID)
+}
+
+//
+// VoiceState Operations
+//
+
+func (ms *MemoryStateBackend) GetVoiceState(guildID discord.GuildID, userID discord.UserID) (voiceState discord.VoiceState, ok bool) {
+	return ms.GuildVoiceStates.Load(guildID, userID)
+}
+
+func (ms *MemoryStateBackend) SetVoiceState(guildID discord.GuildID, voiceState discord.VoiceState) {
+	ms.GuildVoiceStates.Store(guildID, voiceState.UserID, voiceState)
+}
+
+func (ms *MemoryStateBackend) RemoveVoiceState(guildID discord.GuildID, userID discord.UserID) {
+	ms.GuildVoiceStates.Delete(guildID, userID)
+}
+
+func (ms *MemoryStateBackend) GetAllVoiceStates(guildID discord.GuildID) (voiceStates []discord.VoiceState, ok bool) {
+	return cacheValues(&ms.GuildVoiceStates, guildID)
+}
+
+func (ms *MemoryStateBackend) Counts() (counts StateCounts) {
+	ms.Guilds.Range(func(_ discord.GuildID, guild discord.Guild) bool {
+		counts.TotalMembers += int(guild.MemberCount)
+		counts.Guilds++
+		return false
+	})
+
+	ms.GuildEmojis.Range(func(_ discord.GuildID, guildEmojis []discord.Emoji) bool {
+		counts.Emojis += len(guildEmojis)
+		return false
+	})
+
+	counts.Members = ms.GuildMembers.TotalCount()
+	counts.Roles = ms.GuildRoles.TotalCount()
+	counts.Channels = ms.GuildChannels.TotalCount()
+	counts.Users = ms.Users.Count()
+	counts.VoiceStates = ms.GuildVoiceStates.TotalCount()
+
+	return counts
+}
+
+// cacheValues returns all values of a specific key in a DoubleCache.
+func cacheValues[KA comparable, KB comparable, V any](c *DoubleCache[KA, KB, V], key KA) (values []V, ok bool) {
+	inner, ok := c.Inner(key)
+
+	if !ok {
+		return
+	}
+
+	// Pre-allocate the list
+	values = make([]V, 0, inner.Count())
+
+	inner.Range(func(_ KB, value V) bool {
+		values = append(values, value)
+		return false
+	})
+
+	return
+}
@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+// MessageCacheConfiguration configures the cache of recent messages in each channel.
+type MessageCacheConfiguration struct {
+	// Number of messages kept for each channel. 0 disables the message cache.
+	Size int32 `json:"size" yaml:"size"`
+	// Number of seconds messages are kept for. 0 keeps messages until they are pushed out.
+	TTL int32 `json:"ttl" yaml:"ttl"`
+}
+
+// StateMessage is a message in the message cache.
+type StateMessage struct {
+	Message  discord.Message
+	CachedAt int64
+}
+
+// MessageRing is a fixed size ring buffer of the most recent messages of a channel.
+type MessageRing struct {
+	messages []StateMessage
+	next     int
+
+	mu sync.Mutex
+}
+
+func NewMessageRing(size int) *MessageRing {
+	return &MessageRing{
+		messages: make([]StateMessage, size),
+	}
+}
+
+// Push adds a message to the ring, replacing the oldest message if full. A message already
+// in the ring is replaced in place.
+func (mr *MessageRing) Push(message discord.Message, now int64) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if i := mr.index(message.ID); i >= 0 {
+		mr.messages[i] = StateMessage{
+			Message:  message,
+			CachedAt: now,
+		}
+
+		return
+	}
+
+	mr.messages[mr.next] = StateMessage{
+		Message:  message,
+		CachedAt: now,
+	}
+
+	mr.next = (mr.next + 1) % len(mr.messages)
+}
+
+// Load returns a message in the ring.
+func (mr *MessageRing) Load(messageID discord.MessageID) (message discord.Message, ok bool) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if i := mr.index(messageID); i >= 0 {
+		return mr.messages[i].Message, true
+	}
+
+	return
+}
+
+// Replace updates a message in the ring, if present.
+func (mr *MessageRing) Replace(message discord.Message) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if i := mr.index(message.ID); i >= 0 {
+		mr.messages[i].Message = message
+	}
+}
+
+// Delete removes a message from the ring and returns it.
+func (mr *MessageRing) Delete(messageID discord.MessageID) (message discord.Message, ok bool) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if i := mr.index(messageID); i >= 0 {
+		message = mr.messages[i].Message
+		mr.messages[i] = StateMessage{}
+
+		return message, true
+	}
+
+	return
+}
+
+// Expire removes messages cached before the cutoff and returns the number of messages remaining.
+func (mr *MessageRing) Expire(cutoff int64) (remaining int) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	for i := range mr.messages {
+		if mr.messages[i].Message.ID == 0 {
+			continue
+		}
+
+		if mr.messages[i].CachedAt < cutoff {
+			mr.messages[i] = StateMessage{}
+		} else {
+			remaining++
+		}
+	}
+
+	return remaining
+}
+
+// Size returns the number of messages the ring can hold.
+func (mr *MessageRing) Size() int {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	return len(mr.messages)
+}
+
+// Resize changes the number of messages the ring can hold, keeping the most recent messages.
+func (mr *MessageRing) Resize(size int) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if size == len(mr.messages) {
+		return
+	}
+
+	// Messages from oldest to newest.
+	kept := make([]StateMessage, 0, len(mr.messages))
+
+	for i := range mr.messages {
+		if message := mr.messages[(mr.next+i)%len(mr.messages)]; message.Message.ID != 0 {
+			kept = append(kept, message)
+		}
+	}
+
+	if len(kept) > size {
+		kept = kept[len(kept)-size:]
+	}
+
+	mr.messages = make([]StateMessage, size)
+	mr.next = copy(mr.messages, kept) % size
+}
+
+func (mr *MessageRing) index(messageID discord.MessageID) int {
+	if messageID == 0 {
+		return -1
+	}
+
+	for i := range mr.messages {
+		if mr.messages[i].Message.ID == messageID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// messageCache returns the message cache configuration.
+func (sg *Sandwich) messageCache() MessageCacheConfiguration {
+	sg.configurationMu.RLock()
+	defer sg.configurationMu.RUnlock()
+
+	return sg.Configuration.State.Messages
+}
+
+// SetMessageCache updates the size and TTL of the message cache. Channels with a different
+// size are resized when they next receive a message.
+func (ss *SandwichState) SetMessageCache(configuration MessageCacheConfiguration) {
+	ss.MessageCacheSize.Store(configuration.Size)
+	ss.MessageCacheTTL.Store(time.Duration(configuration.TTL) * time.Second)
+
+	if configuration.Size <= 0 {
+		ss.Messages.Clear()
+	}
+}
+
+// GetMessage returns a message from the message cache.
+func (ss *SandwichState) GetMessage(channelID discord.ChannelID, messageID discord.MessageID) (message discord.Message, ok bool) {
+	ring, ok := ss.Messages.Load(channelID)
+	if !ok {
+		return
+	}
+
+	return ring.Load(messageID)
+}
+
+// AddMessage adds a message to the message cache of its channel.
+func (ss *SandwichState) AddMessage(message discord.Message) {
+	size := int(ss.MessageCacheSize.Load())
+	if size <= 0 {
+		return
+	}
+
+	ring, ok := ss.Messages.Load(message.ChannelID)
+	if !ok {
+		ss.Messages.SetIfAbsent(message.ChannelID, NewMessageRing(size))
+		ring, _ = ss.Messages.Load(message.ChannelID)
+	}
+
+	ring.Resize(size)
+	ring.Push(message, time.Now().Unix())
+}
+
+// UpdateMessage replaces a message in the message cache and returns the previous version.
+func (ss *SandwichState) UpdateMessage(message discord.Message) (before discord.Message, ok bool) {
+	ring, ok := ss.Messages.Load(message.ChannelID)
+	if !ok {
+		return
+	}
+
+	before, ok = ring.Load(message.ID)
+	if ok {
+		ring.Replace(message)
+	}
+
+	return
+}
+
+// RemoveMessage removes a message from the message cache and returns it.
+func (ss *SandwichState) RemoveMessage(channelID discord.ChannelID, messageID discord.MessageID) (message discord.Message, ok bool) {
+	ring, ok := ss.Messages.Load(channelID)
+	if !ok {
+		return
+	}
+
+	return ring.Delete(messageID)
+}
+
+// RemoveChannelMessages removes all messages of a channel from the message cache.
+func (ss *SandwichState) RemoveChannelMessages(channelID discord.ChannelID) {
+	ss.Messages.Delete(channelID)
+}
+
+// ExpireMessages removes messages older than the message cache TTL and any empty channels.
+// Returns the number of channels removed.
+func (ss *SandwichState) ExpireMessages() (ejected int) {
+	ttl := ss.MessageCacheTTL.Load()
+	if ttl <= 0 {
+		return 0
+	}
+
+	cutoff := time.Now().Add(-ttl).Unix()
+
+	emptyChannels := make([]discord.ChannelID, 0)
+
+	ss.Messages.Range(func(channelID discord.ChannelID, ring *MessageRing) bool {
+		if ring.Expire(cutoff) == 0 {
+			emptyChannels = append(emptyChannels, channelID)
+		}
+
+		return false
+	})
+
+	for _, channelID := range emptyChannels {
+		ss.Messages.Delete(channelID)
+	}
+
+	return len(emptyChannels)
+}
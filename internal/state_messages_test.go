@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+func TestMessageCache(t *testing.T) {
+	state := NewSandwichState()
+	state.SetMessageCache(MessageCacheConfiguration{Size: 2, TTL: 60})
+
+	for messageID := discord.MessageID(1); messageID <= 3; messageID++ {
+		state.AddMessage(discord.Message{ID: messageID, ChannelID: 1, Content: "original"})
+	}
+
+	if _, ok := state.GetMessage(1, 1); ok {
+		t.Errorf("Expected oldest message to be pushed out")
+	}
+
+	before, ok := state.UpdateMessage(discord.Message{ID: 2, ChannelID: 1, Content: "edited"})
+	if !ok || before.Content != "original" {
+		t.Errorf("Expected original message before update, got %+v", before)
+	}
+
+	if deleted, ok := state.RemoveMessage(1, 2); !ok || deleted.Content != "edited" {
+		t.Errorf("Expected edited message to be removed, got %+v", deleted)
+	}
+
+	if _, ok := state.GetMessage(1, 2); ok {
+		t.Errorf("Expected message to be removed")
+	}
+
+	ring, _ := state.Messages.Load(1)
+	if remaining := ring.Expire(time.Now().Add(time.Minute).Unix()); remaining != 0 {
+		t.Errorf("Expected all messages to expire, %d remaining", remaining)
+	}
+}
+
+func TestMessageCacheResize(t *testing.T) {
+	state := NewSandwichState()
+	state.SetMessageCache(MessageCacheConfiguration{Size: 3})
+
+	for messageID := discord.MessageID(1); messageID <= 3; messageID++ {
+		state.AddMessage(discord.Message{ID: messageID, ChannelID: 1})
+	}
+
+	// Pushing a cached message again replaces it instead of pushing out the oldest message.
+	state.AddMessage(discord.Message{ID: 1, ChannelID: 1, Content: "edited"})
+
+	if message, ok := state.GetMessage(1, 1); !ok || message.Content != "edited" {
+		t.Errorf("Expected message to be replaced in place, got %+v", message)
+	}
+
+	state.SetMessageCache(MessageCacheConfiguration{Size: 2})
+	state.AddMessage(discord.Message{ID: 4, ChannelID: 1})
+
+	for messageID, expected := range map[discord.MessageID]bool{1: false, 2: false, 3: true, 4: true} {
+		if _, ok := state.GetMessage(1, messageID); ok != expected {
+			t.Errorf("Expected message %d cached to be %t after shrinking", messageID, expected)
+		}
+	}
+
+	state.SetMessageCache(MessageCacheConfiguration{Size: 4})
+	state.AddMessage(discord.Message{ID: 5, ChannelID: 1})
+	state.AddMessage(discord.Message{ID: 6, ChannelID: 1})
+
+	for messageID := discord.MessageID(3); messageID <= 6; messageID++ {
+		if _, ok := state.GetMessage(1, messageID); !ok {
+			t.Errorf("Expected message %d to be kept after growing", messageID)
+		}
+	}
+}
+
+func TestMessageCacheConcurrentAdd(t *testing.T) {
+	state := NewSandwichState()
+	state.SetMessageCache(MessageCacheConfiguration{Size: 100})
+
+	// Creates the cache of channels before adding concurrently.
+	state.AddMessage(discord.Message{ID: 1, ChannelID: 2})
+
+	var wg sync.WaitGroup
+
+	for messageID := discord.MessageID(1); messageID <= 50; messageID++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			state.AddMessage(discord.Message{ID: messageID, ChannelID: 1})
+		}()
+	}
+
+	wg.Wait()
+
+	for messageID := discord.MessageID(1); messageID <= 50; messageID++ {
+		if _, ok := state.GetMessage(1, messageID); !ok {
+			t.Errorf("Expected message %d to be cached", messageID)
+		}
+	}
+}
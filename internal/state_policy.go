@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+// CachePolicy configures which collections are cached and which fields are removed
+// from entities before they are cached.
+type CachePolicy struct {
+	// Collections that are not cached. Any of members, roles, channels, threads,
+	// archived_threads, emojis, voice_states, dm_channels and presences.
+	Disable []string `json:"disable" yaml:"disable"`
+	// Fields removed before caching. Any of user_avatar, user_banner,
+	// user_avatar_decoration, member_avatar and member_communication_disabled_until.
+	Trim []string `json:"trim" yaml:"trim"`
+}
+
+// CacheCollection represents a collection in state that can be disabled.
+type CacheCollection uint16
+
+const (
+	CacheCollectionMembers CacheCollection = 1 << iota
+	CacheCollectionRoles
+	CacheCollectionChannels
+	CacheCollectionEmojis
+	CacheCollectionVoiceStates
+	CacheCollectionDMChannels
+	CacheCollectionPresences
+	CacheCollectionThreads
+	CacheCollectionArchivedThreads
+)
+
+var cacheCollectionNames = map[string]CacheCollection{
+	"members":      CacheCollectionMembers,
+	"roles":        CacheCollectionRoles,
+	"channels":     CacheCollectionChannels,
+	"emojis":       CacheCollectionEmojis,
+	"voice_states": CacheCollectionVoiceStates,
+	"dm_channels":  CacheCollectionDMChannels,
+	"presences":    CacheCollectionPresences,
+	"threads":      CacheCollectionThreads,
+
+	"archived_threads": CacheCollectionArchivedThreads,
+}
+
+// CacheTrim represents a field that can be removed from entities before caching.
+type CacheTrim uint16
+
+const (
+	CacheTrimUserAvatar CacheTrim = 1 << iota
+	CacheTrimUserBanner
+	CacheTrimUserAvatarDecoration
+	CacheTrimMemberAvatar
+	CacheTrimMemberCommunicationDisabledUntil
+)
+
+var cacheTrimNames = map[string]CacheTrim{
+	"user_avatar":                         CacheTrimUserAvatar,
+	"user_banner":                         CacheTrimUserBanner,
+	"user_avatar_decoration":              CacheTrimUserAvatarDecoration,
+	"member_avatar":                       CacheTrimMemberAvatar,
+	"member_communication_disabled_until": CacheTrimMemberCommunicationDisabledUntil,
+}
+
+// StateCachePolicy is a parsed CachePolicy.
+type StateCachePolicy struct {
+	Disabled CacheCollection
+	Trim     CacheTrim
+}
+
+// StateCachePolicies holds the default cache policy of a manager and any per-guild overrides.
+type StateCachePolicies struct {
+	Guilds  map[discord.GuildID]StateCachePolicy
+	Default StateCachePolicy
+}
+
+// ParseCachePolicy parses the names in a CachePolicy. Unknown names are returned as an error
+// but do not prevent the rest of the policy from being parsed.
+func ParseCachePolicy(policy CachePolicy) (parsed StateCachePolicy, err error) {
+	var unknown []string
+
+	for _, name := range policy.Disable {
+		if collection, ok := cacheCollectionNames[strings.ToLower(name)]; ok {
+			parsed.Disabled |= collection
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+
+	for _, name := range policy.Trim {
+		if trim, ok := cacheTrimNames[strings.ToLower(name)]; ok {
+			parsed.Trim |= trim
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return parsed, fmt.Errorf("%w: %s", ErrUnknownCachePolicy, strings.Join(unknown, ", "))
+	}
+
+	return parsed, nil
+}
+
+// NewStateCachePolicies parses the default cache policy and per-guild overrides.
+func NewStateCachePolicies(policy CachePolicy, guilds map[discord.GuildID]CachePolicy) (policies *StateCachePolicies, err error) {
+	policies = &StateCachePolicies{
+		Guilds: make(map[discord.GuildID]StateCachePolicy, len(guilds)),
+	}
+
+	policies.Default, err = ParseCachePolicy(policy)
+
+	for guildID, guildPolicy := range guilds {
+		parsed, guildErr := ParseCachePolicy(guildPolicy)
+		if guildErr != nil {
+			err = guildErr
+		}
+
+		policies.Guilds[guildID] = parsed
+	}
+
+	return policies, err
+}
+
+// Guild returns the cache policy of a guild.
+func (sp *StateCachePolicies) Guild(guildID discord.GuildID) StateCachePolicy {
+	if policy, ok := sp.Guilds[guildID]; ok {
+		return policy
+	}
+
+	return sp.Default
+}
+
+// Caches returns if a collection should be cached for a guild.
+func (ctx StateCtx) Caches(guildID discord.GuildID, collection CacheCollection) bool {
+	if ctx.CachePolicies == nil {
+		return true
+	}
+
+	return ctx.CachePolicies.Guild(guildID).Disabled&collection == 0
+}
+
+// trimUser removes any fields from a user that should not be cached.
+func (ctx StateCtx) trimUser(user discord.User) discord.User {
+	if ctx.CachePolicies == nil {
+		return user
+	}
+
+	trim := ctx.CachePolicies.Default.Trim
+
+	if trim&CacheTrimUserAvatar != 0 {
+		user.Avatar = nil
+	}
+
+	if trim&CacheTrimUserBanner != 0 {
+		user.Banner = ""
+	}
+
+	if trim&CacheTrimUserAvatarDecoration != 0 {
+		user.AvatarDecoration = nil
+	}
+
+	return user
+}
+
+// trimGuildMember removes any fields from a guild member that should not be cached.
+func (ctx StateCtx) trimGuildMember(guildID discord.GuildID, guildMember discord.GuildMember) discord.GuildMember {
+	if ctx.CachePolicies == nil {
+		return guildMember
+	}
+
+	trim := ctx.CachePolicies.Guild(guildID).Trim
+
+	if trim&CacheTrimMemberAvatar != 0 {
+		guildMember.Avatar = ""
+	}
+
+	if trim&CacheTrimMemberCommunicationDisabledUntil != 0 {
+		guildMember.CommunicationDisabledUntil = nil
+	}
+
+	return guildMember
+}
+
+// SetCachePolicies parses and applies the cache policies of the manager.
+func (mg *Manager) SetCachePolicies(policy CachePolicy, guilds map[discord.GuildID]CachePolicy) {
+	policies, err := NewStateCachePolicies(policy, guilds)
+	if err != nil {
+		mg.Logger.Warn().Err(err).Msg("Cache policy contains unknown entries")
+	}
+
+	mg.cachePoliciesMu.Lock()
+	mg.cachePolicies = policies
+	mg.cachePoliciesMu.Unlock()
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+func TestStateCachePolicies(t *testing.T) {
+	policies, err := NewStateCachePolicies(
+		CachePolicy{Disable: []string{"members", "voice_states"}, Trim: []string{"user_avatar"}},
+		map[discord.GuildID]CachePolicy{1: {Disable: []string{"emojis", "unknown"}}},
+	)
+
+	if !errors.Is(err, ErrUnknownCachePolicy) {
+		t.Errorf("Expected ErrUnknownCachePolicy, got %v", err)
+	}
+
+	ctx := StateCtx{CachePolicies: policies}
+
+	if ctx.Caches(2, CacheCollectionMembers) || ctx.Caches(2, CacheCollectionVoiceStates) || !ctx.Caches(2, CacheCollectionEmojis) {
+		t.Errorf("Expected default policy to apply to guild 2")
+	}
+
+	if !ctx.Caches(1, CacheCollectionMembers) || ctx.Caches(1, CacheCollectionEmojis) {
+		t.Errorf("Expected guild policy to replace default policy for guild 1")
+	}
+
+	avatar := "avatar"
+
+	if user := ctx.trimUser(discord.User{ID: 1, Avatar: &avatar}); user.Avatar != nil {
+		t.Errorf("Expected avatar to be trimmed")
+	}
+
+	if !(StateCtx{}).Caches(1, CacheCollectionMembers) {
+		t.Errorf("Expected everything to be cached without a policy")
+	}
+}
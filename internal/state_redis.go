@@ -0,0 +1,546 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog"
+)
+
+// Prefix of all keys written by the redis state backend if no prefix is configured.
+const DefaultRedisStatePrefix = "sandwich"
+
+// Number of times an update is retried when the value is changed by another process.
+const redisStateUpdateAttempts = 10
+
+// RedisStateConfiguration represents the configuration of the redis state backend.
+type RedisStateConfiguration struct {
+	Address  string `json:"address" yaml:"address"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	// Prefix of all keys. Defaults to "sandwich".
+	Prefix string `json:"prefix" yaml:"prefix"`
+	DB     int    `json:"db" yaml:"db"`
+}
+
+// RedisStateBackend stores state in redis, allowing it to be shared between sandwich
+// processes and read directly by consumers. Entities are stored as JSON in hashes:
+//
+//	{prefix}:guilds                          guild id -> guild
+//	{prefix}:guild_members:{guild id}        user id -> guild member
+//	{prefix}:guild_roles:{guild id}          role id -> role
+//	{prefix}:guild_channels:{guild id}       channel id -> channel
+//	{prefix}:guild_emojis                    guild id -> list of emojis
+//	{prefix}:guild_voice_states:{guild id}   user id -> voice state
+//	{prefix}:users                           user id -> user
+//	{prefix}:dm_channels                     channel id -> dm channel
+//
+// Mutual guilds are stored in a set of guild ids at {prefix}:mutuals:{user id}.
+type RedisStateBackend struct {
+	ctx    context.Context
+	Logger zerolog.Logger
+
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStateBackend connects to redis and returns a RedisStateBackend.
+func NewRedisStateBackend(ctx context.Context, logger zerolog.Logger, configuration RedisStateConfiguration) (*RedisStateBackend, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     configuration.Address,
+		Username: configuration.Username,
+		Password: configuration.Password,
+		DB:       configuration.DB,
+	})
+
+	err := client.Ping(ctx).Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return NewRedisStateBackendFromClient(ctx, logger, client, configuration.Prefix), nil
+}
+
+// NewRedisStateBackendFromClient returns a RedisStateBackend using an existing client.
+func NewRedisStateBackendFromClient(ctx context.Context, logger zerolog.Logger, client *redis.Client, prefix string) *RedisStateBackend {
+	if prefix == "" {
+		prefix = DefaultRedisStatePrefix
+	}
+
+	return &RedisStateBackend{
+		ctx:    ctx,
+		Logger: logger,
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (rs *RedisStateBackend) String() string {
+	return "redis"
+}
+
+func (rs *RedisStateBackend) Shared() bool {
+	return true
+}
+
+func (rs *RedisStateBackend) key(collection string) string {
+	return rs.prefix + ":" + collection
+}
+
+func (rs *RedisStateBackend) guildKey(collection string, guildID discord.GuildID) string {
+	return rs.prefix + ":" + collection + ":" + formatSnowflake(guildID)
+}
+
+func formatSnowflake[T ~int64](id T) string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+func (rs *RedisStateBackend) logError(err error, key string, message string) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		rs.Logger.Error().Err(err).Str("key", key).Msg(message)
+	}
+}
+
+// hget unmarshals a field in a hash into value, returning false if it does not exist.
+func (rs *RedisStateBackend) hget(key string, field string, value any) bool {
+	data, err := rs.client.HGet(rs.ctx, key, field).Bytes()
+	if err != nil {
+		rs.logError(err, key, "Failed to get state")
+
+		return false
+	}
+
+	if err = sandwichjson.Unmarshal(data, value); err != nil {
+		rs.logError(err, key, "Failed to unmarshal state")
+
+		return false
+	}
+
+	return true
+}
+
+func (rs *RedisStateBackend) hset(key string, field string, value any) {
+	data, err := sandwichjson.Marshal(value)
+	if err != nil {
+		rs.logError(err, key, "Failed to marshal state")
+
+		return
+	}
+
+	rs.logError(rs.client.HSet(rs.ctx, key, field, data).Err(), key, "Failed to set state")
+}
+
+func (rs *RedisStateBackend) hdel(key string, field string) {
+	rs.logError(rs.client.HDel(rs.ctx, key, field).Err(), key, "Failed to remove state")
+}
+
+func (rs *RedisStateBackend) del(key string) {
+	rs.logError(rs.client.Del(rs.ctx, key).Err(), key, "Failed to remove state")
+}
+
+// redisHashValues unmarshals all values in a hash. Returns false if the hash is empty.
+func redisHashValues[V any](rs *RedisStateBackend, key string) (values []V, ok bool) {
+	data, err := rs.client.HVals(rs.ctx, key).Result()
+	if err != nil {
+		rs.logError(err, key, "Failed to get state")
+
+		return nil, false
+	}
+
+	if len(data) == 0 {
+		return nil, false
+	}
+
+	values = make([]V, 0, len(data))
+
+	for _, item := range data {
+		var value V
+
+		if err = sandwichjson.Unmarshal([]byte(item), &value); err != nil {
+			rs.logError(err, key, "Failed to unmarshal state")
+
+			continue
+		}
+
+		values = append(values, value)
+	}
+
+	return values, true
+}
+
+// Sets a field in a hash only if it still has the value it was read with.
+// Returns 1 if the field was set, 0 if it has changed.
+//
+// KEYS[1] hash
+// ARGV[1] field, ARGV[2] value read, ARGV[3] value to set
+var redisHashCompareAndSetScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+
+return 1
+`)
+
+// redisHashUpdate runs fn on a field in a hash and stores the result. If another process changes the
+// field before it is stored, the update is retried with the new value. Returns false if the field does
+// not exist.
+func redisHashUpdate[V any](rs *RedisStateBackend, key string, field string, fn func(value V) V) (value V, ok bool) {
+	for attempt := 0; attempt < redisStateUpdateAttempts; attempt++ {
+		before, err := rs.client.HGet(rs.ctx, key, field).Result()
+		if err != nil {
+			rs.logError(err, key, "Failed to get state")
+
+			return value, false
+		}
+
+		var current V
+
+		if err = sandwichjson.Unmarshal([]byte(before), &current); err != nil {
+			rs.logError(err, key, "Failed to unmarshal state")
+
+			return value, false
+		}
+
+		value = fn(current)
+
+		after, err := sandwichjson.Marshal(value)
+		if err != nil {
+			rs.logError(err, key, "Failed to marshal state")
+
+			return value, false
+		}
+
+		set, err := redisHashCompareAndSetScript.Run(rs.ctx, rs.client, []string{key}, field, before, after).Int()
+		if err != nil {
+			rs.logError(err, key, "Failed to update state")
+
+			return value, false
+		}
+
+		if set == 1 {
+			return value, true
+		}
+	}
+
+	rs.logError(ErrStateUpdateConflict, key, "Failed to update state")
+
+	return value, false
+}
+
+//
+// Guild Operations
+//
+
+func (rs *RedisStateBackend) GetGuild(guildID discord.GuildID) (guild discord.Guild, ok bool) {
+	ok = rs.hget(rs.key("guilds"), formatSnowflake(guildID), &guild)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetGuild(guild discord.Guild) {
+	rs.hset(rs.key("guilds"), formatSnowflake(guild.ID), guild)
+}
+
+func (rs *RedisStateBackend) UpdateGuild(guildID discord.GuildID, fn func(guild discord.Guild) discord.Guild) (guild discord.Guild, ok bool) {
+	return redisHashUpdate(rs, rs.key("guilds"), formatSnowflake(guildID), fn)
+}
+
+func (rs *RedisStateBackend) RemoveGuild(guildID discord.GuildID) {
+	rs.hdel(rs.key("guilds"), formatSnowflake(guildID))
+}
+
+func (rs *RedisStateBackend) GetAllGuildIDs() (guildIDs []discord.GuildID) {
+	key := rs.key("guilds")
+
+	fields, err := rs.client.HKeys(rs.ctx, key).Result()
+	if err != nil {
+		rs.logError(err, key, "Failed to get state")
+
+		return
+	}
+
+	guildIDs = make([]discord.GuildID, 0, len(fields))
+
+	for _, field := range fields {
+		guildID, err := strconv.ParseInt(field, 10, 64)
+		if err == nil {
+			guildIDs = append(guildIDs, discord.GuildID(guildID))
+		}
+	}
+
+	return guildIDs
+}
+
+//
+// GuildMember Operations
+//
+
+func (rs *RedisStateBackend) GetGuildMember(guildID discord.GuildID, userID discord.UserID) (guildMember discord.GuildMember, ok bool) {
+	ok = rs.hget(rs.guildKey("guild_members", guildID), formatSnowflake(userID), &guildMember)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetGuildMember(guildID discord.GuildID, guildMember discord.GuildMember) {
+	rs.hset(rs.guildKey("guild_members", guildID), formatSnowflake(guildMember.User.ID), guildMember)
+}
+
+func (rs *RedisStateBackend) RemoveGuildMember(guildID discord.GuildID, userID discord.UserID) {
+	rs.hdel(rs.guildKey("guild_members", guildID), formatSnowflake(userID))
+}
+
+func (rs *RedisStateBackend) GetAllGuildMembers(guildID discord.GuildID) (guildMembers []discord.GuildMember, ok bool) {
+	return redisHashValues[discord.GuildMember](rs, rs.guildKey("guild_members", guildID))
+}
+
+func (rs *RedisStateBackend) RemoveAllGuildMembers(guildID discord.GuildID) {
+	rs.del(rs.guildKey("guild_members", guildID))
+}
+
+func (rs *RedisStateBackend) CountGuildMembers(guildID discord.GuildID) int {
+	key := rs.guildKey("guild_members", guildID)
+
+	count, err := rs.client.HLen(rs.ctx, key).Result()
+	if err != nil {
+		rs.logError(err, key, "Failed to count state")
+	}
+
+	return int(count)
+}
+
+//
+// Role Operations
+//
+
+func (rs *RedisStateBackend) GetGuildRole(guildID discord.GuildID, roleID discord.RoleID) (role discord.Role, ok bool) {
+	ok = rs.hget(rs.guildKey("guild_roles", guildID), formatSnowflake(roleID), &role)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetGuildRole(guildID discord.GuildID, role discord.Role) {
+	rs.hset(rs.guildKey("guild_roles", guildID), formatSnowflake(role.ID), role)
+}
+
+func (rs *RedisStateBackend) RemoveGuildRole(guildID discord.GuildID, roleID discord.RoleID) {
+	rs.hdel(rs.guildKey("guild_roles", guildID), formatSnowflake(roleID))
+}
+
+func (rs *RedisStateBackend) GetAllGuildRoles(guildID discord.GuildID) (roles []discord.Role, ok bool) {
+	return redisHashValues[discord.Role](rs, rs.guildKey("guild_roles", guildID))
+}
+
+func (rs *RedisStateBackend) RemoveAllGuildRoles(guildID discord.GuildID) {
+	rs.del(rs.guildKey("guild_roles", guildID))
+}
+
+//
+// Emoji Operations
+//
+
+func (rs *RedisStateBackend) GetGuildEmojis(guildID discord.GuildID) (emojis []discord.Emoji, ok bool) {
+	ok = rs.hget(rs.key("guild_emojis"), formatSnowflake(guildID), &emojis)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetGuildEmojis(guildID discord.GuildID, emojis []discord.Emoji) {
+	rs.hset(rs.key("guild_emojis"), formatSnowflake(guildID), emojis)
+}
+
+func (rs *RedisStateBackend) RemoveGuildEmojis(guildID discord.GuildID) {
+	rs.hdel(rs.key("guild_emojis"), formatSnowflake(guildID))
+}
+
+//
+// User Operations
+//
+
+func (rs *RedisStateBackend) GetUser(userID discord.UserID) (user StateUser, ok bool) {
+	ok = rs.hget(rs.key("users"), formatSnowflake(userID), &user)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetUser(user StateUser) {
+	rs.hset(rs.key("users"), formatSnowflake(user.ID), user)
+}
+
+func (rs *RedisStateBackend) RemoveUser(userID discord.UserID) {
+	rs.hdel(rs.key("users"), formatSnowflake(userID))
+}
+
+//
+// Channel Operations
+//
+
+func (rs *RedisStateBackend) GetGuildChannel(guildID discord.GuildID, channelID discord.ChannelID) (channel discord.Channel, ok bool) {
+	ok = rs.hget(rs.guildKey("guild_channels", guildID), formatSnowflake(channelID), &channel)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetGuildChannel(guildID discord.GuildID, channel discord.Channel) {
+	rs.hset(rs.guildKey("guild_channels", guildID), formatSnowflake(channel.ID), channel)
+}
+
+func (rs *RedisStateBackend) UpdateGuildChannel(guildID discord.GuildID, channelID discord.ChannelID, fn func(channel discord.Channel) discord.Channel) (channel discord.Channel, ok bool) {
+	return redisHashUpdate(rs, rs.guildKey("guild_channels", guildID), formatSnowflake(channelID), fn)
+}
+
+func (rs *RedisStateBackend) RemoveGuildChannel(guildID discord.GuildID, channelID discord.ChannelID) {
+	rs.hdel(rs.guildKey("guild_channels", guildID), formatSnowflake(channelID))
+}
+
+func (rs *RedisStateBackend) GetAllGuildChannels(guildID discord.GuildID) (channels []discord.Channel, ok bool) {
+	return redisHashValues[discord.Channel](rs, rs.guildKey("guild_channels", guildID))
+}
+
+func (rs *RedisStateBackend) RemoveAllGuildChannels(guildID discord.GuildID) {
+	rs.del(rs.guildKey("guild_channels", guildID))
+}
+
+func (rs *RedisStateBackend) GetDMChannel(channelID discord.ChannelID) (channel StateDMChannel, ok bool) {
+	ok = rs.hget(rs.key("dm_channels"), formatSnowflake(channelID), &channel)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetDMChannel(channel StateDMChannel) {
+	rs.hset(rs.key("dm_channels"), formatSnowflake(channel.ID), channel)
+}
+
+func (rs *RedisStateBackend) RemoveDMChannel(channelID discord.ChannelID) {
+	rs.hdel(rs.key("dm_channels"), formatSnowflake(channelID))
+}
+
+func (rs *RedisStateBackend) GetAllDMChannels() (channels []StateDMChannel) {
+	channels, _ = redisHashValues[StateDMChannel](rs, rs.key("dm_channels"))
+
+	return channels
+}
+
+//
+// Mutual Operations
+//
+
+func (rs *RedisStateBackend) GetUserMutualGuilds(userID discord.UserID) (guildIDs []discord.GuildID, ok bool) {
+	key := rs.key("mutuals:" + formatSnowflake(userID))
+
+	members, err := rs.client.SMembers(rs.ctx, key).Result()
+	if err != nil {
+		rs.logError(err, key, "Failed to get state")
+
+		return nil, false
+	}
+
+	if len(members) == 0 {
+		return nil, false
+	}
+
+	guildIDs = make([]discord.GuildID, 0, len(members))
+
+	for _, member := range members {
+		guildID, err := strconv.ParseInt(member, 10, 64)
+		if err == nil {
+			guildIDs = append(guildIDs, discord.GuildID(guildID))
+		}
+	}
+
+	return guildIDs, true
+}
+
+func (rs *RedisStateBackend) AddUserMutualGuild(userID discord.UserID, guildID discord.GuildID) {
+	key := rs.key("mutuals:" + formatSnowflake(userID))
+
+	rs.logError(rs.client.SAdd(rs.ctx, key, formatSnowflake(guildID)).Err(), key, "Failed to set state")
+}
+
+func (rs *RedisStateBackend) RemoveUserMutualGuild(userID discord.UserID, guildID discord.GuildID) {
+	key := rs.key("mutuals:" + formatSnowflake(userID))
+
+	rs.logError(rs.client.SRem(rs.ctx, key, formatSnowflake(guildID)).Err(), key, "Failed to remove state")
+}
+
+//
+// VoiceState Operations
+//
+
+func (rs *RedisStateBackend) GetVoiceState(guildID discord.GuildID, userID discord.UserID) (voiceState discord.VoiceState, ok bool) {
+	ok = rs.hget(rs.guildKey("guild_voice_states", guildID), formatSnowflake(userID), &voiceState)
+
+	return
+}
+
+func (rs *RedisStateBackend) SetVoiceState(guildID discord.GuildID, voiceState discord.VoiceState) {
+	rs.hset(rs.guildKey("guild_voice_states", guildID), formatSnowflake(voiceState.UserID), voiceState)
+}
+
+func (rs *RedisStateBackend) RemoveVoiceState(guildID discord.GuildID, userID discord.UserID) {
+	rs.hdel(rs.guildKey("guild_voice_states", guildID), formatSnowflake(userID))
+}
+
+func (rs *RedisStateBackend) GetAllVoiceStates(guildID discord.GuildID) (voiceStates []discord.VoiceState, ok bool) {
+	return redisHashValues[discord.VoiceState](rs, rs.guildKey("guild_voice_states", guildID))
+}
+
+func (rs *RedisStateBackend) Counts() (counts StateCounts) {
+	guildCounts, _ := redisHashValues[struct {
+		MemberCount int32 `json:"member_count"`
+	}](rs, rs.key("guilds"))
+
+	for _, guild := range guildCounts {
+		counts.TotalMembers += int(guild.MemberCount)
+	}
+
+	counts.Guilds = len(guildCounts)
+
+	guildEmojis, _ := redisHashValues[[]json.RawMessage](rs, rs.key("guild_emojis"))
+
+	for _, emojis := range guildEmojis {
+		counts.Emojis += len(emojis)
+	}
+
+	pipe := rs.client.Pipeline()
+
+	users := pipe.HLen(rs.ctx, rs.key("users"))
+
+	var members, roles, channels, voiceStates []*redis.IntCmd
+
+	for _, guildID := range rs.GetAllGuildIDs() {
+		members = append(members, pipe.HLen(rs.ctx, rs.guildKey("guild_members", guildID)))
+		roles = append(roles, pipe.HLen(rs.ctx, rs.guildKey("guild_roles", guildID)))
+		channels = append(channels, pipe.HLen(rs.ctx, rs.guildKey("guild_channels", guildID)))
+		voiceStates = append(voiceStates, pipe.HLen(rs.ctx, rs.guildKey("guild_voice_states", guildID)))
+	}
+
+	if _, err := pipe.Exec(rs.ctx); err != nil {
+		rs.logError(err, rs.prefix, "Failed to count state")
+
+		return counts
+	}
+
+	counts.Users = int(users.Val())
+	counts.Members = sumIntCmds(members)
+	counts.Roles = sumIntCmds(roles)
+	counts.Channels = sumIntCmds(channels)
+	counts.VoiceStates = sumIntCmds(voiceStates)
+
+	return counts
+}
+
+func sumIntCmds(cmds []*redis.IntCmd) (total int) {
+	for _, cmd := range cmds {
+		total += int(cmd.Val())
+	}
+
+	return total
+}
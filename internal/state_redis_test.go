@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog"
+)
+
+func newTestRedisStateBackend(t *testing.T) *RedisStateBackend {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisStateBackendFromClient(context.Background(), zerolog.Nop(), client, "")
+}
+
+func TestRedisStateBackendGuild(t *testing.T) {
+	state := &SandwichState{
+		Backend: newTestRedisStateBackend(t),
+	}
+
+	guildID := discord.GuildID(1)
+	userID := discord.UserID(2)
+
+	ctx := StateCtx{
+		CacheUsers:   true,
+		CacheMembers: true,
+		Shard: &Shard{
+			Manager:    &Manager{User: discord.User{ID: userID}},
+			ShardGroup: &ShardGroup{Guilds: NewCache[discord.GuildID, struct{}](0)},
+			Guilds:     NewCache[discord.GuildID, struct{}](0),
+		},
+	}
+
+	state.SetGuild(ctx, discord.Guild{
+		ID:          guildID,
+		Name:        "Guild",
+		MemberCount: 1,
+		Roles:       []discord.Role{{ID: 3, Name: "Role"}},
+		Channels:    []discord.Channel{{ID: 4, Name: "channel"}},
+		Members:     []discord.GuildMember{{User: &discord.User{ID: userID, Username: "user"}}},
+		Emojis:      []discord.Emoji{},
+	})
+
+	guild, ok := state.GetGuild(guildID)
+	if !ok {
+		t.Fatalf("Expected guild to be found")
+	}
+
+	if guild.Name != "Guild" || len(guild.Roles) != 1 || len(guild.Channels) != 1 || len(guild.Members) != 1 {
+		t.Errorf("Expected guild to be populated, got %+v", guild)
+	}
+
+	if member, ok := state.GetGuildMember(guildID, userID); !ok || member.User.Username != "user" {
+		t.Errorf("Expected member to be found, got %+v", member)
+	}
+
+	if ids := state.GetAllGuildIDs(); len(ids) != 1 || ids[0] != guildID {
+		t.Errorf("Expected guild IDs [%d], got %v", guildID, ids)
+	}
+
+	counts := state.Backend.Counts()
+	if counts.Guilds != 1 || counts.Members != 1 || counts.Roles != 1 || counts.Channels != 1 || counts.Users != 1 || counts.TotalMembers != 1 {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+
+	state.RemoveGuild(StateCtx{Stateless: true}, guildID)
+
+	if _, ok := state.GetGuild(guildID); ok {
+		t.Errorf("Expected guild to be removed")
+	}
+
+	if _, ok := state.GetAllGuildMembers(guildID); ok {
+		t.Errorf("Expected members to be removed")
+	}
+}
+
+func TestRedisStateBackendMutuals(t *testing.T) {
+	backend := newTestRedisStateBackend(t)
+
+	backend.AddUserMutualGuild(1, 2)
+	backend.AddUserMutualGuild(1, 3)
+	backend.RemoveUserMutualGuild(1, 2)
+
+	guildIDs, ok := backend.GetUserMutualGuilds(1)
+	if !ok || len(guildIDs) != 1 || guildIDs[0] != 3 {
+		t.Errorf("Expected mutual guilds [3], got %v", guildIDs)
+	}
+}
+
+func TestRedisStateBackendUpdateGuildConflict(t *testing.T) {
+	backend := newTestRedisStateBackend(t)
+
+	backend.SetGuild(discord.Guild{ID: 1, Name: "before"})
+
+	attempts := 0
+
+	guild, ok := backend.UpdateGuild(1, func(guild discord.Guild) discord.Guild {
+		attempts++
+
+		// Another process writes to the guild while the first update is in progress.
+		if attempts == 1 {
+			backend.SetGuild(discord.Guild{ID: 1, Name: "concurrent"})
+		}
+
+		guild.Description = guild.Name + " updated"
+
+		return guild
+	})
+
+	if !ok || attempts != 2 {
+		t.Fatalf("expected update to be retried once, got ok %v after %d attempts", ok, attempts)
+	}
+
+	stored, _ := backend.GetGuild(1)
+
+	if guild.Description != "concurrent updated" || stored.Description != guild.Description || stored.Name != "concurrent" {
+		t.Errorf("expected update to apply to the concurrent write, got %+v", stored)
+	}
+
+	if _, ok = backend.UpdateGuild(2, func(guild discord.Guild) discord.Guild { return guild }); ok {
+		t.Error("expected update of a missing guild to fail")
+	}
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+// IsThread returns if a channel is a thread.
+func IsThread(channel discord.Channel) bool {
+	return channel.Type == discord.ChannelTypeGuildNewsThread ||
+		channel.Type == discord.ChannelTypeGuildPublicThread ||
+		channel.Type == discord.ChannelTypeGuildPrivateThread
+}
+
+// IsThreadArchived returns if a thread has been archived.
+func IsThreadArchived(channel discord.Channel) bool {
+	return channel.ThreadMetadata != nil && channel.ThreadMetadata.Archived
+}
+
+// SetGuildThread creates or updates a thread in the guild channels of a guild.
+// Archived threads are removed instead if the cache policy does not cache them.
+//
+// fake-ctx-safe
+func (ss *SandwichState) SetGuildThread(ctx StateCtx, guildID discord.GuildID, thread discord.Channel) {
+	if !ctx.Caches(guildID, CacheCollectionThreads) {
+		return
+	}
+
+	if IsThreadArchived(thread) && !ctx.Caches(guildID, CacheCollectionArchivedThreads) {
+		ss.RemoveGuildThread(guildID, thread.ID)
+
+		return
+	}
+
+	// Ensure thread has guild id set
+	thread.GuildID = &guildID
+
+	// Keep the thread member of the bot if the update does not include it.
+	if thread.ThreadMember == nil {
+		if beforeThread, ok := ss.Backend.GetGuildChannel(guildID, thread.ID); ok {
+			thread.ThreadMember = beforeThread.ThreadMember
+		}
+	}
+
+	ss.Backend.SetGuildChannel(guildID, thread)
+}
+
+// RemoveGuildThread removes a thread and its members from the cache.
+func (ss *SandwichState) RemoveGuildThread(guildID discord.GuildID, threadID discord.ChannelID) {
+	ss.Backend.RemoveGuildChannel(guildID, threadID)
+	ss.ThreadMembers.ClearKey(threadID)
+}
+
+// GetAllGuildThreads returns all threads of a specific guild from the cache.
+func (ss *SandwichState) GetAllGuildThreads(guildID discord.GuildID) (threads []discord.Channel) {
+	channels, _ := ss.Backend.GetAllGuildChannels(guildID)
+
+	threads = make([]discord.Channel, 0)
+
+	for _, channel := range channels {
+		if IsThread(channel) {
+			threads = append(threads, channel)
+		}
+	}
+
+	return threads
+}
+
+// GetThreadMember returns the member of a thread from the cache.
+func (ss *SandwichState) GetThreadMember(threadID discord.ChannelID, userID discord.UserID) (threadMember discord.ThreadMember, ok bool) {
+	return ss.ThreadMembers.Load(threadID, userID)
+}
+
+// GetAllThreadMembers returns all known members of a thread from the cache.
+func (ss *SandwichState) GetAllThreadMembers(threadID discord.ChannelID) (threadMembers []discord.ThreadMember, ok bool) {
+	return cacheValues(&ss.ThreadMembers, threadID)
+}
+
+// SetThreadMember creates or updates a thread member in the cache.
+//
+// fake-ctx-safe
+func (ss *SandwichState) SetThreadMember(ctx StateCtx, guildID discord.GuildID, threadMember discord.ThreadMember) {
+	if threadMember.ID == nil || threadMember.UserID == nil || !ctx.Caches(guildID, CacheCollectionThreads) {
+		return
+	}
+
+	ss.ThreadMembers.Store(*threadMember.ID, *threadMember.UserID, threadMember)
+}
+
+// RemoveThreadMember removes a thread member from the cache.
+func (ss *SandwichState) RemoveThreadMember(threadID discord.ChannelID, userID discord.UserID) {
+	ss.ThreadMembers.Delete(threadID, userID)
+}
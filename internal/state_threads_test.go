@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
+)
+
+func TestSetGuildThreadArchived(t *testing.T) {
+	state := NewSandwichState()
+
+	policies, err := NewStateCachePolicies(CachePolicy{Disable: []string{"archived_threads"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := StateCtx{CachePolicies: policies}
+
+	thread := discord.Channel{ID: 2, Type: discord.ChannelTypeGuildPublicThread, ThreadMetadata: &discord.ThreadMetadata{}}
+
+	state.SetGuildThread(ctx, 1, thread)
+
+	if threads := state.GetAllGuildThreads(1); len(threads) != 1 {
+		t.Fatalf("Expected thread to be cached, got %v", threads)
+	}
+
+	userID := discord.UserID(3)
+	state.SetThreadMember(ctx, 1, discord.ThreadMember{ID: &thread.ID, UserID: &userID})
+
+	thread.ThreadMetadata = &discord.ThreadMetadata{Archived: true}
+	state.SetGuildThread(ctx, 1, thread)
+
+	if threads := state.GetAllGuildThreads(1); len(threads) != 0 {
+		t.Errorf("Expected archived thread to be removed, got %v", threads)
+	}
+
+	if _, ok := state.GetThreadMember(thread.ID, userID); ok {
+		t.Errorf("Expected thread members to be removed")
+	}
+}
@@ -1,7 +1,28 @@
 package structs
 
+import "encoding/json"
+
 const (
 	SandwichEventConfigurationReload    = "SW_CONFIGURATION_RELOAD"
 	SandwichEventShardStatusUpdate      = "SW_SHARD_STATUS_UPDATE"
 	SandwichEventShardGroupStatusUpdate = "SW_SHARD_GROUP_STATUS_UPDATE"
+	SandwichEventJoinSpike              = "SANDWICH_JOIN_SPIKE"
+	SandwichEventAuditCorrelated        = "SANDWICH_AUDIT_CORRELATED"
 )
+
+// JoinSpike is sent when the joins of a guild within a window cross the configured threshold.
+type JoinSpike struct {
+	JoinRate  map[string]int32 `json:"join_rate"`
+	GuildID   int64            `json:"guild_id,string"`
+	Joins     int32            `json:"joins"`
+	Threshold int32            `json:"threshold"`
+	Window    int32            `json:"window"`
+}
+
+// AuditCorrelated is sent when an audit log entry is received after the event it is responsible for.
+type AuditCorrelated struct {
+	EventType  string          `json:"event_type"`
+	AuditEntry json.RawMessage `json:"audit_entry"`
+	GuildID    int64           `json:"guild_id,string"`
+	TargetID   int64           `json:"target_id,string"`
+}
@@ -7,6 +7,18 @@ type IdentifyPayload struct {
 	ShardID        int32  `json:"shard_id"`
 	ShardCount     int32  `json:"shard_count"`
 	MaxConcurrency int32  `json:"max_concurrency"`
+
+	// Session start limit of the token as last retrieved from /gateway/bot.
+	// Used by identify coordinators to track the daily session start limit.
+	SessionStartLimit *IdentifySessionStartLimit `json:"session_start_limit,omitempty"`
+}
+
+// IdentifySessionStartLimit represents the session start limit of a token.
+type IdentifySessionStartLimit struct {
+	Total     int32 `json:"total"`
+	Remaining int32 `json:"remaining"`
+	// Milliseconds until the limit resets.
+	ResetAfter int32 `json:"reset_after"`
 }
 
 // IdentifyResponse represents the response to external identifying.
@@ -20,6 +20,35 @@ type StatusEndpointManager struct {
 	ShardGroups []StatusEndpointShardGroup `json:"shard_groups"`
 	UserCount   int                        `json:"user_count"`
 	MemberCount int                        `json:"member_count"`
+	// Number of payloads spooled while the producer is unavailable, and the age of the oldest in seconds.
+	SpoolDepth int `json:"spool_depth"`
+	SpoolAge   int `json:"spool_age"`
+
+	Backpressure *StatusEndpointBackpressure `json:"backpressure,omitempty"`
+	Rescale      *StatusEndpointRescale      `json:"rescale,omitempty"`
+}
+
+// StatusEndpointRescale represents the progress of the last rescale of a manager.
+type StatusEndpointRescale struct {
+	Phase                 string  `json:"phase"`
+	ShardGroupID          int32   `json:"shard_group_id"`
+	PreviousShardGroupIDs []int32 `json:"previous_shard_group_ids"`
+	ShardCount            int32   `json:"shard_count"`
+	Shards                int     `json:"shards"`
+	ShardsReady           int     `json:"shards_ready"`
+	// Guilds of the new shard group that have not yet received GUILD_CREATE.
+	GuildsPending int `json:"guilds_pending"`
+	// Seconds since the rescale started.
+	Duration int    `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+type StatusEndpointBackpressure struct {
+	Policy        string `json:"policy"`
+	Active        bool   `json:"active"`
+	QueueLength   int    `json:"queue_length"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Dropped       int64  `json:"dropped"`
 }
 
 type StatusEndpointShardGroup struct {
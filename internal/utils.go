@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/WelcomerTeam/Sandwich-Daemon/discord"
 	"github.com/WelcomerTeam/Sandwich-Daemon/sandwichjson"
 )
 
@@ -88,6 +89,17 @@ func makeExtra(extra map[string]interface{}) (out map[string]json.RawMessage, er
 	return
 }
 
+// makeAuditExtra returns the extras for an event with a matching audit log entry.
+func makeAuditExtra(auditEntry *discord.AuditLogEntry) (out map[string]json.RawMessage, err error) {
+	if auditEntry == nil {
+		return nil, nil
+	}
+
+	return makeExtra(map[string]interface{}{
+		"audit_entry": auditEntry,
+	})
+}
+
 // getShardId returns the shard ID from a guild id
 // Given a guild ID, return its shard ID
 func getShardIDFromGuildID(guildID string, shardCount int) (uint64, error) {
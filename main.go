@@ -36,6 +36,10 @@ func main() {
 	httpHost := flag.String("httpHost", os.Getenv("HTTP_HOST"), "Host to use for internal dashboard.")
 	httpEnabled := flag.Bool("httpEnabled", MustParseBool(os.Getenv("HTTP_ENABLED")), "Enables the internal dashboard.")
 
+	grpcNetwork := flag.String("grpcNetwork", os.Getenv("GRPC_NETWORK"), "Network to use for gRPC (default: tcp)")
+	grpcHost := flag.String("grpcHost", os.Getenv("GRPC_HOST"), "Host to use for gRPC. If empty, gRPC is disabled.")
+	grpcToken := flag.String("grpcToken", os.Getenv("GRPC_TOKEN"), "Token gRPC clients must send as the authorization metadata. If empty, gRPC is unauthenticated.")
+
 	loggingLevel := flag.String("level", os.Getenv("LOGGING_LEVEL"), "Logging level")
 
 	loggingFileLoggingEnabled := flag.Bool("fileLoggingEnabled", MustParseBool(os.Getenv("LOGGING_FILE_LOGGING_ENABLED")), "When enabled, will save logs to files")
@@ -102,6 +106,9 @@ func main() {
 		PrometheusAddress:     *prometheusAddress,
 		HTTPHost:              *httpHost,
 		HTTPEnabled:           *httpEnabled,
+		GRPCNetwork:           *grpcNetwork,
+		GRPCHost:              *grpcHost,
+		GRPCToken:             *grpcToken,
 	}
 
 	if confGatewayURL, err := url.Parse(*gatewayURL); err == nil {
@@ -0,0 +1,1628 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: sandwich.proto
+
+package protobuf
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// BaseResponse represents data included in all responses.
+type BaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Ok            bool                   `protobuf:"varint,3,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BaseResponse) Reset() {
+	*x = BaseResponse{}
+	mi := &file_sandwich_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BaseResponse) ProtoMessage() {}
+
+func (x *BaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BaseResponse.ProtoReflect.Descriptor instead.
+func (*BaseResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BaseResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *BaseResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BaseResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type FetchConsumerConfigurationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Identifier    string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchConsumerConfigurationRequest) Reset() {
+	*x = FetchConsumerConfigurationRequest{}
+	mi := &file_sandwich_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchConsumerConfigurationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchConsumerConfigurationRequest) ProtoMessage() {}
+
+func (x *FetchConsumerConfigurationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchConsumerConfigurationRequest.ProtoReflect.Descriptor instead.
+func (*FetchConsumerConfigurationRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FetchConsumerConfigurationRequest) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+type FetchGuildRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	GuildIds      []int64                `protobuf:"varint,2,rep,packed,name=guild_ids,json=guildIds,proto3" json:"guild_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchGuildRequest) Reset() {
+	*x = FetchGuildRequest{}
+	mi := &file_sandwich_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchGuildRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchGuildRequest) ProtoMessage() {}
+
+func (x *FetchGuildRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchGuildRequest.ProtoReflect.Descriptor instead.
+func (*FetchGuildRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FetchGuildRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *FetchGuildRequest) GetGuildIds() []int64 {
+	if x != nil {
+		return x.GuildIds
+	}
+	return nil
+}
+
+type FetchGuildChannelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	ChannelIds    []int64                `protobuf:"varint,2,rep,packed,name=channel_ids,json=channelIds,proto3" json:"channel_ids,omitempty"`
+	GuildId       int64                  `protobuf:"varint,3,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchGuildChannelsRequest) Reset() {
+	*x = FetchGuildChannelsRequest{}
+	mi := &file_sandwich_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchGuildChannelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchGuildChannelsRequest) ProtoMessage() {}
+
+func (x *FetchGuildChannelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchGuildChannelsRequest.ProtoReflect.Descriptor instead.
+func (*FetchGuildChannelsRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FetchGuildChannelsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *FetchGuildChannelsRequest) GetChannelIds() []int64 {
+	if x != nil {
+		return x.ChannelIds
+	}
+	return nil
+}
+
+func (x *FetchGuildChannelsRequest) GetGuildId() int64 {
+	if x != nil {
+		return x.GuildId
+	}
+	return 0
+}
+
+type FetchGuildEmojisRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	EmojiIds      []int64                `protobuf:"varint,2,rep,packed,name=emoji_ids,json=emojiIds,proto3" json:"emoji_ids,omitempty"`
+	GuildId       int64                  `protobuf:"varint,3,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchGuildEmojisRequest) Reset() {
+	*x = FetchGuildEmojisRequest{}
+	mi := &file_sandwich_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchGuildEmojisRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchGuildEmojisRequest) ProtoMessage() {}
+
+func (x *FetchGuildEmojisRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchGuildEmojisRequest.ProtoReflect.Descriptor instead.
+func (*FetchGuildEmojisRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FetchGuildEmojisRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *FetchGuildEmojisRequest) GetEmojiIds() []int64 {
+	if x != nil {
+		return x.EmojiIds
+	}
+	return nil
+}
+
+func (x *FetchGuildEmojisRequest) GetGuildId() int64 {
+	if x != nil {
+		return x.GuildId
+	}
+	return 0
+}
+
+type FetchGuildMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	UserIds       []int64                `protobuf:"varint,2,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	GuildId       int64                  `protobuf:"varint,3,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchGuildMembersRequest) Reset() {
+	*x = FetchGuildMembersRequest{}
+	mi := &file_sandwich_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchGuildMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchGuildMembersRequest) ProtoMessage() {}
+
+func (x *FetchGuildMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchGuildMembersRequest.ProtoReflect.Descriptor instead.
+func (*FetchGuildMembersRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FetchGuildMembersRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *FetchGuildMembersRequest) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+func (x *FetchGuildMembersRequest) GetGuildId() int64 {
+	if x != nil {
+		return x.GuildId
+	}
+	return 0
+}
+
+type FetchGuildRolesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	RoleIds       []int64                `protobuf:"varint,2,rep,packed,name=role_ids,json=roleIds,proto3" json:"role_ids,omitempty"`
+	GuildId       int64                  `protobuf:"varint,3,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchGuildRolesRequest) Reset() {
+	*x = FetchGuildRolesRequest{}
+	mi := &file_sandwich_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchGuildRolesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchGuildRolesRequest) ProtoMessage() {}
+
+func (x *FetchGuildRolesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchGuildRolesRequest.ProtoReflect.Descriptor instead.
+func (*FetchGuildRolesRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FetchGuildRolesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *FetchGuildRolesRequest) GetRoleIds() []int64 {
+	if x != nil {
+		return x.RoleIds
+	}
+	return nil
+}
+
+func (x *FetchGuildRolesRequest) GetGuildId() int64 {
+	if x != nil {
+		return x.GuildId
+	}
+	return 0
+}
+
+type FetchMutualGuildsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Includes the guilds, not just their IDs.
+	Expand        bool `protobuf:"varint,2,opt,name=expand,proto3" json:"expand,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchMutualGuildsRequest) Reset() {
+	*x = FetchMutualGuildsRequest{}
+	mi := &file_sandwich_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchMutualGuildsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchMutualGuildsRequest) ProtoMessage() {}
+
+func (x *FetchMutualGuildsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchMutualGuildsRequest.ProtoReflect.Descriptor instead.
+func (*FetchMutualGuildsRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FetchMutualGuildsRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *FetchMutualGuildsRequest) GetExpand() bool {
+	if x != nil {
+		return x.Expand
+	}
+	return false
+}
+
+type RequestGuildChunkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       int64                  `protobuf:"varint,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestGuildChunkRequest) Reset() {
+	*x = RequestGuildChunkRequest{}
+	mi := &file_sandwich_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestGuildChunkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestGuildChunkRequest) ProtoMessage() {}
+
+func (x *RequestGuildChunkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestGuildChunkRequest.ProtoReflect.Descriptor instead.
+func (*RequestGuildChunkRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RequestGuildChunkRequest) GetGuildId() int64 {
+	if x != nil {
+		return x.GuildId
+	}
+	return 0
+}
+
+type SendWebsocketMessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Manager       string                 `protobuf:"bytes,1,opt,name=manager,proto3" json:"manager,omitempty"`
+	ShardGroup    int32                  `protobuf:"varint,2,opt,name=shard_group,json=shardGroup,proto3" json:"shard_group,omitempty"`
+	Shard         int32                  `protobuf:"varint,3,opt,name=shard,proto3" json:"shard,omitempty"`
+	GatewayOpCode int64                  `protobuf:"varint,4,opt,name=gateway_op_code,json=gatewayOpCode,proto3" json:"gateway_op_code,omitempty"`
+	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendWebsocketMessageRequest) Reset() {
+	*x = SendWebsocketMessageRequest{}
+	mi := &file_sandwich_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendWebsocketMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendWebsocketMessageRequest) ProtoMessage() {}
+
+func (x *SendWebsocketMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendWebsocketMessageRequest.ProtoReflect.Descriptor instead.
+func (*SendWebsocketMessageRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SendWebsocketMessageRequest) GetManager() string {
+	if x != nil {
+		return x.Manager
+	}
+	return ""
+}
+
+func (x *SendWebsocketMessageRequest) GetShardGroup() int32 {
+	if x != nil {
+		return x.ShardGroup
+	}
+	return 0
+}
+
+func (x *SendWebsocketMessageRequest) GetShard() int32 {
+	if x != nil {
+		return x.Shard
+	}
+	return 0
+}
+
+func (x *SendWebsocketMessageRequest) GetGatewayOpCode() int64 {
+	if x != nil {
+		return x.GatewayOpCode
+	}
+	return 0
+}
+
+func (x *SendWebsocketMessageRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type WhereIsGuildRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       int64                  `protobuf:"varint,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhereIsGuildRequest) Reset() {
+	*x = WhereIsGuildRequest{}
+	mi := &file_sandwich_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhereIsGuildRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhereIsGuildRequest) ProtoMessage() {}
+
+func (x *WhereIsGuildRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhereIsGuildRequest.ProtoReflect.Descriptor instead.
+func (*WhereIsGuildRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WhereIsGuildRequest) GetGuildId() int64 {
+	if x != nil {
+		return x.GuildId
+	}
+	return 0
+}
+
+type FetchConsumerConfigurationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          []byte                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchConsumerConfigurationResponse) Reset() {
+	*x = FetchConsumerConfigurationResponse{}
+	mi := &file_sandwich_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchConsumerConfigurationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchConsumerConfigurationResponse) ProtoMessage() {}
+
+func (x *FetchConsumerConfigurationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchConsumerConfigurationResponse.ProtoReflect.Descriptor instead.
+func (*FetchConsumerConfigurationResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *FetchConsumerConfigurationResponse) GetFile() []byte {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+type GuildsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BaseResponse  *BaseResponse          `protobuf:"bytes,1,opt,name=base_response,json=baseResponse,proto3" json:"base_response,omitempty"`
+	Guilds        map[int64][]byte       `protobuf:"bytes,2,rep,name=guilds,proto3" json:"guilds,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	GuildIds      []int64                `protobuf:"varint,3,rep,packed,name=guild_ids,json=guildIds,proto3" json:"guild_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GuildsResponse) Reset() {
+	*x = GuildsResponse{}
+	mi := &file_sandwich_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GuildsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildsResponse) ProtoMessage() {}
+
+func (x *GuildsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildsResponse.ProtoReflect.Descriptor instead.
+func (*GuildsResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GuildsResponse) GetBaseResponse() *BaseResponse {
+	if x != nil {
+		return x.BaseResponse
+	}
+	return nil
+}
+
+func (x *GuildsResponse) GetGuilds() map[int64][]byte {
+	if x != nil {
+		return x.Guilds
+	}
+	return nil
+}
+
+func (x *GuildsResponse) GetGuildIds() []int64 {
+	if x != nil {
+		return x.GuildIds
+	}
+	return nil
+}
+
+type ChannelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BaseResponse  *BaseResponse          `protobuf:"bytes,1,opt,name=base_response,json=baseResponse,proto3" json:"base_response,omitempty"`
+	GuildChannels map[int64][]byte       `protobuf:"bytes,2,rep,name=guild_channels,json=guildChannels,proto3" json:"guild_channels,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelsResponse) Reset() {
+	*x = ChannelsResponse{}
+	mi := &file_sandwich_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelsResponse) ProtoMessage() {}
+
+func (x *ChannelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelsResponse.ProtoReflect.Descriptor instead.
+func (*ChannelsResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ChannelsResponse) GetBaseResponse() *BaseResponse {
+	if x != nil {
+		return x.BaseResponse
+	}
+	return nil
+}
+
+func (x *ChannelsResponse) GetGuildChannels() map[int64][]byte {
+	if x != nil {
+		return x.GuildChannels
+	}
+	return nil
+}
+
+type EmojisResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BaseResponse  *BaseResponse          `protobuf:"bytes,1,opt,name=base_response,json=baseResponse,proto3" json:"base_response,omitempty"`
+	GuildEmojis   map[int64][]byte       `protobuf:"bytes,2,rep,name=guild_emojis,json=guildEmojis,proto3" json:"guild_emojis,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmojisResponse) Reset() {
+	*x = EmojisResponse{}
+	mi := &file_sandwich_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmojisResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmojisResponse) ProtoMessage() {}
+
+func (x *EmojisResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmojisResponse.ProtoReflect.Descriptor instead.
+func (*EmojisResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *EmojisResponse) GetBaseResponse() *BaseResponse {
+	if x != nil {
+		return x.BaseResponse
+	}
+	return nil
+}
+
+func (x *EmojisResponse) GetGuildEmojis() map[int64][]byte {
+	if x != nil {
+		return x.GuildEmojis
+	}
+	return nil
+}
+
+type GuildMembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BaseResponse  *BaseResponse          `protobuf:"bytes,1,opt,name=base_response,json=baseResponse,proto3" json:"base_response,omitempty"`
+	GuildMembers  map[int64][]byte       `protobuf:"bytes,2,rep,name=guild_members,json=guildMembers,proto3" json:"guild_members,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GuildMembersResponse) Reset() {
+	*x = GuildMembersResponse{}
+	mi := &file_sandwich_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GuildMembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildMembersResponse) ProtoMessage() {}
+
+func (x *GuildMembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildMembersResponse.ProtoReflect.Descriptor instead.
+func (*GuildMembersResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GuildMembersResponse) GetBaseResponse() *BaseResponse {
+	if x != nil {
+		return x.BaseResponse
+	}
+	return nil
+}
+
+func (x *GuildMembersResponse) GetGuildMembers() map[int64][]byte {
+	if x != nil {
+		return x.GuildMembers
+	}
+	return nil
+}
+
+type GuildRolesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BaseResponse  *BaseResponse          `protobuf:"bytes,1,opt,name=base_response,json=baseResponse,proto3" json:"base_response,omitempty"`
+	GuildRoles    map[int64][]byte       `protobuf:"bytes,2,rep,name=guild_roles,json=guildRoles,proto3" json:"guild_roles,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GuildRolesResponse) Reset() {
+	*x = GuildRolesResponse{}
+	mi := &file_sandwich_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GuildRolesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildRolesResponse) ProtoMessage() {}
+
+func (x *GuildRolesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildRolesResponse.ProtoReflect.Descriptor instead.
+func (*GuildRolesResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GuildRolesResponse) GetBaseResponse() *BaseResponse {
+	if x != nil {
+		return x.BaseResponse
+	}
+	return nil
+}
+
+func (x *GuildRolesResponse) GetGuildRoles() map[int64][]byte {
+	if x != nil {
+		return x.GuildRoles
+	}
+	return nil
+}
+
+type WhereIsGuildResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	BaseResponse  *BaseResponse           `protobuf:"bytes,1,opt,name=base_response,json=baseResponse,proto3" json:"base_response,omitempty"`
+	Locations     []*WhereIsGuildLocation `protobuf:"bytes,2,rep,name=locations,proto3" json:"locations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhereIsGuildResponse) Reset() {
+	*x = WhereIsGuildResponse{}
+	mi := &file_sandwich_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhereIsGuildResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhereIsGuildResponse) ProtoMessage() {}
+
+func (x *WhereIsGuildResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhereIsGuildResponse.ProtoReflect.Descriptor instead.
+func (*WhereIsGuildResponse) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WhereIsGuildResponse) GetBaseResponse() *BaseResponse {
+	if x != nil {
+		return x.BaseResponse
+	}
+	return nil
+}
+
+func (x *WhereIsGuildResponse) GetLocations() []*WhereIsGuildLocation {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+type WhereIsGuildLocation struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Manager    string                 `protobuf:"bytes,1,opt,name=manager,proto3" json:"manager,omitempty"`
+	ShardGroup int32                  `protobuf:"varint,2,opt,name=shard_group,json=shardGroup,proto3" json:"shard_group,omitempty"`
+	ShardId    int32                  `protobuf:"varint,3,opt,name=shard_id,json=shardId,proto3" json:"shard_id,omitempty"`
+	// The member of the manager's user in the guild, if cached.
+	GuildMember   []byte `protobuf:"bytes,4,opt,name=guild_member,json=guildMember,proto3" json:"guild_member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhereIsGuildLocation) Reset() {
+	*x = WhereIsGuildLocation{}
+	mi := &file_sandwich_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhereIsGuildLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhereIsGuildLocation) ProtoMessage() {}
+
+func (x *WhereIsGuildLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhereIsGuildLocation.ProtoReflect.Descriptor instead.
+func (*WhereIsGuildLocation) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WhereIsGuildLocation) GetManager() string {
+	if x != nil {
+		return x.Manager
+	}
+	return ""
+}
+
+func (x *WhereIsGuildLocation) GetShardGroup() int32 {
+	if x != nil {
+		return x.ShardGroup
+	}
+	return 0
+}
+
+func (x *WhereIsGuildLocation) GetShardId() int32 {
+	if x != nil {
+		return x.ShardId
+	}
+	return 0
+}
+
+func (x *WhereIsGuildLocation) GetGuildMember() []byte {
+	if x != nil {
+		return x.GuildMember
+	}
+	return nil
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Shards to receive events of. Events of all shards are received if unset.
+	ShardRange *ShardRange `protobuf:"bytes,1,opt,name=shard_range,json=shardRange,proto3" json:"shard_range,omitempty"`
+	// Event types to receive. All event types are received if empty.
+	EventTypes    []string `protobuf:"bytes,2,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_sandwich_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SubscribeRequest) GetShardRange() *ShardRange {
+	if x != nil {
+		return x.ShardRange
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetEventTypes() []string {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+// ShardRange is an inclusive range of shard IDs.
+type ShardRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardRange) Reset() {
+	*x = ShardRange{}
+	mi := &file_sandwich_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardRange) ProtoMessage() {}
+
+func (x *ShardRange) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardRange.ProtoReflect.Descriptor instead.
+func (*ShardRange) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ShardRange) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *ShardRange) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type SandwichMetadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Identifier    string                 `protobuf:"bytes,2,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Application   string                 `protobuf:"bytes,3,opt,name=application,proto3" json:"application,omitempty"`
+	ApplicationId int64                  `protobuf:"varint,4,opt,name=application_id,json=applicationId,proto3" json:"application_id,omitempty"`
+	ShardGroupId  int32                  `protobuf:"varint,5,opt,name=shard_group_id,json=shardGroupId,proto3" json:"shard_group_id,omitempty"`
+	ShardId       int32                  `protobuf:"varint,6,opt,name=shard_id,json=shardId,proto3" json:"shard_id,omitempty"`
+	ShardCount    int32                  `protobuf:"varint,7,opt,name=shard_count,json=shardCount,proto3" json:"shard_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SandwichMetadata) Reset() {
+	*x = SandwichMetadata{}
+	mi := &file_sandwich_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SandwichMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SandwichMetadata) ProtoMessage() {}
+
+func (x *SandwichMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SandwichMetadata.ProtoReflect.Descriptor instead.
+func (*SandwichMetadata) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SandwichMetadata) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *SandwichMetadata) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *SandwichMetadata) GetApplication() string {
+	if x != nil {
+		return x.Application
+	}
+	return ""
+}
+
+func (x *SandwichMetadata) GetApplicationId() int64 {
+	if x != nil {
+		return x.ApplicationId
+	}
+	return 0
+}
+
+func (x *SandwichMetadata) GetShardGroupId() int32 {
+	if x != nil {
+		return x.ShardGroupId
+	}
+	return 0
+}
+
+func (x *SandwichMetadata) GetShardId() int32 {
+	if x != nil {
+		return x.ShardId
+	}
+	return 0
+}
+
+func (x *SandwichMetadata) GetShardCount() int32 {
+	if x != nil {
+		return x.ShardCount
+	}
+	return 0
+}
+
+type SandwichPayload struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Metadata *SandwichMetadata      `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// Unix time each stage of processing the event finished.
+	Trace map[string]int64 `protobuf:"bytes,2,rep,name=trace,proto3" json:"trace,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Type  string           `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	// Event data encoded as JSON.
+	Data     []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Sequence int32  `protobuf:"varint,5,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Op       int32  `protobuf:"varint,6,opt,name=op,proto3" json:"op,omitempty"`
+	// Extra data attached to the event, each encoded as JSON.
+	Extra          map[string][]byte `protobuf:"bytes,7,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	GloballyRouted bool              `protobuf:"varint,8,opt,name=globally_routed,json=globallyRouted,proto3" json:"globally_routed,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SandwichPayload) Reset() {
+	*x = SandwichPayload{}
+	mi := &file_sandwich_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SandwichPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SandwichPayload) ProtoMessage() {}
+
+func (x *SandwichPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_sandwich_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SandwichPayload.ProtoReflect.Descriptor instead.
+func (*SandwichPayload) Descriptor() ([]byte, []int) {
+	return file_sandwich_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SandwichPayload) GetMetadata() *SandwichMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *SandwichPayload) GetTrace() map[string]int64 {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *SandwichPayload) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SandwichPayload) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *SandwichPayload) GetSequence() int32 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *SandwichPayload) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *SandwichPayload) GetExtra() map[string][]byte {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+func (x *SandwichPayload) GetGloballyRouted() bool {
+	if x != nil {
+		return x.GloballyRouted
+	}
+	return false
+}
+
+var File_sandwich_proto protoreflect.FileDescriptor
+
+const file_sandwich_proto_rawDesc = "" +
+	"\n" +
+	"\x0esandwich.proto\x12\bsandwich\"N\n" +
+	"\fBaseResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x0e\n" +
+	"\x02ok\x18\x03 \x01(\bR\x02ok\"C\n" +
+	"!FetchConsumerConfigurationRequest\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\x01 \x01(\tR\n" +
+	"identifier\"F\n" +
+	"\x11FetchGuildRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1b\n" +
+	"\tguild_ids\x18\x02 \x03(\x03R\bguildIds\"m\n" +
+	"\x19FetchGuildChannelsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1f\n" +
+	"\vchannel_ids\x18\x02 \x03(\x03R\n" +
+	"channelIds\x12\x19\n" +
+	"\bguild_id\x18\x03 \x01(\x03R\aguildId\"g\n" +
+	"\x17FetchGuildEmojisRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1b\n" +
+	"\temoji_ids\x18\x02 \x03(\x03R\bemojiIds\x12\x19\n" +
+	"\bguild_id\x18\x03 \x01(\x03R\aguildId\"f\n" +
+	"\x18FetchGuildMembersRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x19\n" +
+	"\buser_ids\x18\x02 \x03(\x03R\auserIds\x12\x19\n" +
+	"\bguild_id\x18\x03 \x01(\x03R\aguildId\"d\n" +
+	"\x16FetchGuildRolesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x19\n" +
+	"\brole_ids\x18\x02 \x03(\x03R\aroleIds\x12\x19\n" +
+	"\bguild_id\x18\x03 \x01(\x03R\aguildId\"K\n" +
+	"\x18FetchMutualGuildsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x16\n" +
+	"\x06expand\x18\x02 \x01(\bR\x06expand\"5\n" +
+	"\x18RequestGuildChunkRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\x03R\aguildId\"\xaa\x01\n" +
+	"\x1bSendWebsocketMessageRequest\x12\x18\n" +
+	"\amanager\x18\x01 \x01(\tR\amanager\x12\x1f\n" +
+	"\vshard_group\x18\x02 \x01(\x05R\n" +
+	"shardGroup\x12\x14\n" +
+	"\x05shard\x18\x03 \x01(\x05R\x05shard\x12&\n" +
+	"\x0fgateway_op_code\x18\x04 \x01(\x03R\rgatewayOpCode\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\"0\n" +
+	"\x13WhereIsGuildRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\x03R\aguildId\"8\n" +
+	"\"FetchConsumerConfigurationResponse\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\fR\x04file\"\xe3\x01\n" +
+	"\x0eGuildsResponse\x12;\n" +
+	"\rbase_response\x18\x01 \x01(\v2\x16.sandwich.BaseResponseR\fbaseResponse\x12<\n" +
+	"\x06guilds\x18\x02 \x03(\v2$.sandwich.GuildsResponse.GuildsEntryR\x06guilds\x12\x1b\n" +
+	"\tguild_ids\x18\x03 \x03(\x03R\bguildIds\x1a9\n" +
+	"\vGuildsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\xe7\x01\n" +
+	"\x10ChannelsResponse\x12;\n" +
+	"\rbase_response\x18\x01 \x01(\v2\x16.sandwich.BaseResponseR\fbaseResponse\x12T\n" +
+	"\x0eguild_channels\x18\x02 \x03(\v2-.sandwich.ChannelsResponse.GuildChannelsEntryR\rguildChannels\x1a@\n" +
+	"\x12GuildChannelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\xdb\x01\n" +
+	"\x0eEmojisResponse\x12;\n" +
+	"\rbase_response\x18\x01 \x01(\v2\x16.sandwich.BaseResponseR\fbaseResponse\x12L\n" +
+	"\fguild_emojis\x18\x02 \x03(\v2).sandwich.EmojisResponse.GuildEmojisEntryR\vguildEmojis\x1a>\n" +
+	"\x10GuildEmojisEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\xeb\x01\n" +
+	"\x14GuildMembersResponse\x12;\n" +
+	"\rbase_response\x18\x01 \x01(\v2\x16.sandwich.BaseResponseR\fbaseResponse\x12U\n" +
+	"\rguild_members\x18\x02 \x03(\v20.sandwich.GuildMembersResponse.GuildMembersEntryR\fguildMembers\x1a?\n" +
+	"\x11GuildMembersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\xdf\x01\n" +
+	"\x12GuildRolesResponse\x12;\n" +
+	"\rbase_response\x18\x01 \x01(\v2\x16.sandwich.BaseResponseR\fbaseResponse\x12M\n" +
+	"\vguild_roles\x18\x02 \x03(\v2,.sandwich.GuildRolesResponse.GuildRolesEntryR\n" +
+	"guildRoles\x1a=\n" +
+	"\x0fGuildRolesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\x91\x01\n" +
+	"\x14WhereIsGuildResponse\x12;\n" +
+	"\rbase_response\x18\x01 \x01(\v2\x16.sandwich.BaseResponseR\fbaseResponse\x12<\n" +
+	"\tlocations\x18\x02 \x03(\v2\x1e.sandwich.WhereIsGuildLocationR\tlocations\"\x8f\x01\n" +
+	"\x14WhereIsGuildLocation\x12\x18\n" +
+	"\amanager\x18\x01 \x01(\tR\amanager\x12\x1f\n" +
+	"\vshard_group\x18\x02 \x01(\x05R\n" +
+	"shardGroup\x12\x19\n" +
+	"\bshard_id\x18\x03 \x01(\x05R\ashardId\x12!\n" +
+	"\fguild_member\x18\x04 \x01(\fR\vguildMember\"j\n" +
+	"\x10SubscribeRequest\x125\n" +
+	"\vshard_range\x18\x01 \x01(\v2\x14.sandwich.ShardRangeR\n" +
+	"shardRange\x12\x1f\n" +
+	"\vevent_types\x18\x02 \x03(\tR\n" +
+	"eventTypes\"4\n" +
+	"\n" +
+	"ShardRange\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\"\xf7\x01\n" +
+	"\x10SandwichMetadata\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\x02 \x01(\tR\n" +
+	"identifier\x12 \n" +
+	"\vapplication\x18\x03 \x01(\tR\vapplication\x12%\n" +
+	"\x0eapplication_id\x18\x04 \x01(\x03R\rapplicationId\x12$\n" +
+	"\x0eshard_group_id\x18\x05 \x01(\x05R\fshardGroupId\x12\x19\n" +
+	"\bshard_id\x18\x06 \x01(\x05R\ashardId\x12\x1f\n" +
+	"\vshard_count\x18\a \x01(\x05R\n" +
+	"shardCount\"\xb2\x03\n" +
+	"\x0fSandwichPayload\x126\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x1a.sandwich.SandwichMetadataR\bmetadata\x12:\n" +
+	"\x05trace\x18\x02 \x03(\v2$.sandwich.SandwichPayload.TraceEntryR\x05trace\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x1a\n" +
+	"\bsequence\x18\x05 \x01(\x05R\bsequence\x12\x0e\n" +
+	"\x02op\x18\x06 \x01(\x05R\x02op\x12:\n" +
+	"\x05extra\x18\a \x03(\v2$.sandwich.SandwichPayload.ExtraEntryR\x05extra\x12'\n" +
+	"\x0fglobally_routed\x18\b \x01(\bR\x0egloballyRouted\x1a8\n" +
+	"\n" +
+	"TraceEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x012\xe6\x06\n" +
+	"\bSandwich\x12w\n" +
+	"\x1aFetchConsumerConfiguration\x12+.sandwich.FetchConsumerConfigurationRequest\x1a,.sandwich.FetchConsumerConfigurationResponse\x12C\n" +
+	"\n" +
+	"FetchGuild\x12\x1b.sandwich.FetchGuildRequest\x1a\x18.sandwich.GuildsResponse\x12U\n" +
+	"\x12FetchGuildChannels\x12#.sandwich.FetchGuildChannelsRequest\x1a\x1a.sandwich.ChannelsResponse\x12O\n" +
+	"\x10FetchGuildEmojis\x12!.sandwich.FetchGuildEmojisRequest\x1a\x18.sandwich.EmojisResponse\x12W\n" +
+	"\x11FetchGuildMembers\x12\".sandwich.FetchGuildMembersRequest\x1a\x1e.sandwich.GuildMembersResponse\x12Q\n" +
+	"\x0fFetchGuildRoles\x12 .sandwich.FetchGuildRolesRequest\x1a\x1c.sandwich.GuildRolesResponse\x12Q\n" +
+	"\x11FetchMutualGuilds\x12\".sandwich.FetchMutualGuildsRequest\x1a\x18.sandwich.GuildsResponse\x12O\n" +
+	"\x11RequestGuildChunk\x12\".sandwich.RequestGuildChunkRequest\x1a\x16.sandwich.BaseResponse\x12U\n" +
+	"\x14SendWebsocketMessage\x12%.sandwich.SendWebsocketMessageRequest\x1a\x16.sandwich.BaseResponse\x12M\n" +
+	"\fWhereIsGuild\x12\x1d.sandwich.WhereIsGuildRequest\x1a\x1e.sandwich.WhereIsGuildResponse2P\n" +
+	"\bProducer\x12D\n" +
+	"\tSubscribe\x12\x1a.sandwich.SubscribeRequest\x1a\x19.sandwich.SandwichPayload0\x01B2Z0github.com/WelcomerTeam/Sandwich-Daemon/protobufb\x06proto3"
+
+var (
+	file_sandwich_proto_rawDescOnce sync.Once
+	file_sandwich_proto_rawDescData []byte
+)
+
+func file_sandwich_proto_rawDescGZIP() []byte {
+	file_sandwich_proto_rawDescOnce.Do(func() {
+		file_sandwich_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sandwich_proto_rawDesc), len(file_sandwich_proto_rawDesc)))
+	})
+	return file_sandwich_proto_rawDescData
+}
+
+var file_sandwich_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
+var file_sandwich_proto_goTypes = []any{
+	(*BaseResponse)(nil),                       // 0: sandwich.BaseResponse
+	(*FetchConsumerConfigurationRequest)(nil),  // 1: sandwich.FetchConsumerConfigurationRequest
+	(*FetchGuildRequest)(nil),                  // 2: sandwich.FetchGuildRequest
+	(*FetchGuildChannelsRequest)(nil),          // 3: sandwich.FetchGuildChannelsRequest
+	(*FetchGuildEmojisRequest)(nil),            // 4: sandwich.FetchGuildEmojisRequest
+	(*FetchGuildMembersRequest)(nil),           // 5: sandwich.FetchGuildMembersRequest
+	(*FetchGuildRolesRequest)(nil),             // 6: sandwich.FetchGuildRolesRequest
+	(*FetchMutualGuildsRequest)(nil),           // 7: sandwich.FetchMutualGuildsRequest
+	(*RequestGuildChunkRequest)(nil),           // 8: sandwich.RequestGuildChunkRequest
+	(*SendWebsocketMessageRequest)(nil),        // 9: sandwich.SendWebsocketMessageRequest
+	(*WhereIsGuildRequest)(nil),                // 10: sandwich.WhereIsGuildRequest
+	(*FetchConsumerConfigurationResponse)(nil), // 11: sandwich.FetchConsumerConfigurationResponse
+	(*GuildsResponse)(nil),                     // 12: sandwich.GuildsResponse
+	(*ChannelsResponse)(nil),                   // 13: sandwich.ChannelsResponse
+	(*EmojisResponse)(nil),                     // 14: sandwich.EmojisResponse
+	(*GuildMembersResponse)(nil),               // 15: sandwich.GuildMembersResponse
+	(*GuildRolesResponse)(nil),                 // 16: sandwich.GuildRolesResponse
+	(*WhereIsGuildResponse)(nil),               // 17: sandwich.WhereIsGuildResponse
+	(*WhereIsGuildLocation)(nil),               // 18: sandwich.WhereIsGuildLocation
+	(*SubscribeRequest)(nil),                   // 19: sandwich.SubscribeRequest
+	(*ShardRange)(nil),                         // 20: sandwich.ShardRange
+	(*SandwichMetadata)(nil),                   // 21: sandwich.SandwichMetadata
+	(*SandwichPayload)(nil),                    // 22: sandwich.SandwichPayload
+	nil,                                        // 23: sandwich.GuildsResponse.GuildsEntry
+	nil,                                        // 24: sandwich.ChannelsResponse.GuildChannelsEntry
+	nil,                                        // 25: sandwich.EmojisResponse.GuildEmojisEntry
+	nil,                                        // 26: sandwich.GuildMembersResponse.GuildMembersEntry
+	nil,                                        // 27: sandwich.GuildRolesResponse.GuildRolesEntry
+	nil,                                        // 28: sandwich.SandwichPayload.TraceEntry
+	nil,                                        // 29: sandwich.SandwichPayload.ExtraEntry
+}
+var file_sandwich_proto_depIdxs = []int32{
+	0,  // 0: sandwich.GuildsResponse.base_response:type_name -> sandwich.BaseResponse
+	23, // 1: sandwich.GuildsResponse.guilds:type_name -> sandwich.GuildsResponse.GuildsEntry
+	0,  // 2: sandwich.ChannelsResponse.base_response:type_name -> sandwich.BaseResponse
+	24, // 3: sandwich.ChannelsResponse.guild_channels:type_name -> sandwich.ChannelsResponse.GuildChannelsEntry
+	0,  // 4: sandwich.EmojisResponse.base_response:type_name -> sandwich.BaseResponse
+	25, // 5: sandwich.EmojisResponse.guild_emojis:type_name -> sandwich.EmojisResponse.GuildEmojisEntry
+	0,  // 6: sandwich.GuildMembersResponse.base_response:type_name -> sandwich.BaseResponse
+	26, // 7: sandwich.GuildMembersResponse.guild_members:type_name -> sandwich.GuildMembersResponse.GuildMembersEntry
+	0,  // 8: sandwich.GuildRolesResponse.base_response:type_name -> sandwich.BaseResponse
+	27, // 9: sandwich.GuildRolesResponse.guild_roles:type_name -> sandwich.GuildRolesResponse.GuildRolesEntry
+	0,  // 10: sandwich.WhereIsGuildResponse.base_response:type_name -> sandwich.BaseResponse
+	18, // 11: sandwich.WhereIsGuildResponse.locations:type_name -> sandwich.WhereIsGuildLocation
+	20, // 12: sandwich.SubscribeRequest.shard_range:type_name -> sandwich.ShardRange
+	21, // 13: sandwich.SandwichPayload.metadata:type_name -> sandwich.SandwichMetadata
+	28, // 14: sandwich.SandwichPayload.trace:type_name -> sandwich.SandwichPayload.TraceEntry
+	29, // 15: sandwich.SandwichPayload.extra:type_name -> sandwich.SandwichPayload.ExtraEntry
+	1,  // 16: sandwich.Sandwich.FetchConsumerConfiguration:input_type -> sandwich.FetchConsumerConfigurationRequest
+	2,  // 17: sandwich.Sandwich.FetchGuild:input_type -> sandwich.FetchGuildRequest
+	3,  // 18: sandwich.Sandwich.FetchGuildChannels:input_type -> sandwich.FetchGuildChannelsRequest
+	4,  // 19: sandwich.Sandwich.FetchGuildEmojis:input_type -> sandwich.FetchGuildEmojisRequest
+	5,  // 20: sandwich.Sandwich.FetchGuildMembers:input_type -> sandwich.FetchGuildMembersRequest
+	6,  // 21: sandwich.Sandwich.FetchGuildRoles:input_type -> sandwich.FetchGuildRolesRequest
+	7,  // 22: sandwich.Sandwich.FetchMutualGuilds:input_type -> sandwich.FetchMutualGuildsRequest
+	8,  // 23: sandwich.Sandwich.RequestGuildChunk:input_type -> sandwich.RequestGuildChunkRequest
+	9,  // 24: sandwich.Sandwich.SendWebsocketMessage:input_type -> sandwich.SendWebsocketMessageRequest
+	10, // 25: sandwich.Sandwich.WhereIsGuild:input_type -> sandwich.WhereIsGuildRequest
+	19, // 26: sandwich.Producer.Subscribe:input_type -> sandwich.SubscribeRequest
+	11, // 27: sandwich.Sandwich.FetchConsumerConfiguration:output_type -> sandwich.FetchConsumerConfigurationResponse
+	12, // 28: sandwich.Sandwich.FetchGuild:output_type -> sandwich.GuildsResponse
+	13, // 29: sandwich.Sandwich.FetchGuildChannels:output_type -> sandwich.ChannelsResponse
+	14, // 30: sandwich.Sandwich.FetchGuildEmojis:output_type -> sandwich.EmojisResponse
+	15, // 31: sandwich.Sandwich.FetchGuildMembers:output_type -> sandwich.GuildMembersResponse
+	16, // 32: sandwich.Sandwich.FetchGuildRoles:output_type -> sandwich.GuildRolesResponse
+	12, // 33: sandwich.Sandwich.FetchMutualGuilds:output_type -> sandwich.GuildsResponse
+	0,  // 34: sandwich.Sandwich.RequestGuildChunk:output_type -> sandwich.BaseResponse
+	0,  // 35: sandwich.Sandwich.SendWebsocketMessage:output_type -> sandwich.BaseResponse
+	17, // 36: sandwich.Sandwich.WhereIsGuild:output_type -> sandwich.WhereIsGuildResponse
+	22, // 37: sandwich.Producer.Subscribe:output_type -> sandwich.SandwichPayload
+	27, // [27:38] is the sub-list for method output_type
+	16, // [16:27] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_sandwich_proto_init() }
+func file_sandwich_proto_init() {
+	if File_sandwich_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sandwich_proto_rawDesc), len(file_sandwich_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   30,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_sandwich_proto_goTypes,
+		DependencyIndexes: file_sandwich_proto_depIdxs,
+		MessageInfos:      file_sandwich_proto_msgTypes,
+	}.Build()
+	File_sandwich_proto = out.File
+	file_sandwich_proto_goTypes = nil
+	file_sandwich_proto_depIdxs = nil
+}
@@ -0,0 +1,586 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: sandwich.proto
+
+package protobuf
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Sandwich_FetchConsumerConfiguration_FullMethodName = "/sandwich.Sandwich/FetchConsumerConfiguration"
+	Sandwich_FetchGuild_FullMethodName                 = "/sandwich.Sandwich/FetchGuild"
+	Sandwich_FetchGuildChannels_FullMethodName         = "/sandwich.Sandwich/FetchGuildChannels"
+	Sandwich_FetchGuildEmojis_FullMethodName           = "/sandwich.Sandwich/FetchGuildEmojis"
+	Sandwich_FetchGuildMembers_FullMethodName          = "/sandwich.Sandwich/FetchGuildMembers"
+	Sandwich_FetchGuildRoles_FullMethodName            = "/sandwich.Sandwich/FetchGuildRoles"
+	Sandwich_FetchMutualGuilds_FullMethodName          = "/sandwich.Sandwich/FetchMutualGuilds"
+	Sandwich_RequestGuildChunk_FullMethodName          = "/sandwich.Sandwich/RequestGuildChunk"
+	Sandwich_SendWebsocketMessage_FullMethodName       = "/sandwich.Sandwich/SendWebsocketMessage"
+	Sandwich_WhereIsGuild_FullMethodName               = "/sandwich.Sandwich/WhereIsGuild"
+)
+
+// SandwichClient is the client API for Sandwich service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Sandwich provides access to the state and shards of Sandwich.
+//
+// Discord objects are sent as their JSON encoding, the same as the events sent to consumers.
+type SandwichClient interface {
+	FetchConsumerConfiguration(ctx context.Context, in *FetchConsumerConfigurationRequest, opts ...grpc.CallOption) (*FetchConsumerConfigurationResponse, error)
+	FetchGuild(ctx context.Context, in *FetchGuildRequest, opts ...grpc.CallOption) (*GuildsResponse, error)
+	FetchGuildChannels(ctx context.Context, in *FetchGuildChannelsRequest, opts ...grpc.CallOption) (*ChannelsResponse, error)
+	FetchGuildEmojis(ctx context.Context, in *FetchGuildEmojisRequest, opts ...grpc.CallOption) (*EmojisResponse, error)
+	FetchGuildMembers(ctx context.Context, in *FetchGuildMembersRequest, opts ...grpc.CallOption) (*GuildMembersResponse, error)
+	FetchGuildRoles(ctx context.Context, in *FetchGuildRolesRequest, opts ...grpc.CallOption) (*GuildRolesResponse, error)
+	FetchMutualGuilds(ctx context.Context, in *FetchMutualGuildsRequest, opts ...grpc.CallOption) (*GuildsResponse, error)
+	RequestGuildChunk(ctx context.Context, in *RequestGuildChunkRequest, opts ...grpc.CallOption) (*BaseResponse, error)
+	SendWebsocketMessage(ctx context.Context, in *SendWebsocketMessageRequest, opts ...grpc.CallOption) (*BaseResponse, error)
+	WhereIsGuild(ctx context.Context, in *WhereIsGuildRequest, opts ...grpc.CallOption) (*WhereIsGuildResponse, error)
+}
+
+type sandwichClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSandwichClient(cc grpc.ClientConnInterface) SandwichClient {
+	return &sandwichClient{cc}
+}
+
+func (c *sandwichClient) FetchConsumerConfiguration(ctx context.Context, in *FetchConsumerConfigurationRequest, opts ...grpc.CallOption) (*FetchConsumerConfigurationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchConsumerConfigurationResponse)
+	err := c.cc.Invoke(ctx, Sandwich_FetchConsumerConfiguration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) FetchGuild(ctx context.Context, in *FetchGuildRequest, opts ...grpc.CallOption) (*GuildsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GuildsResponse)
+	err := c.cc.Invoke(ctx, Sandwich_FetchGuild_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) FetchGuildChannels(ctx context.Context, in *FetchGuildChannelsRequest, opts ...grpc.CallOption) (*ChannelsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChannelsResponse)
+	err := c.cc.Invoke(ctx, Sandwich_FetchGuildChannels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) FetchGuildEmojis(ctx context.Context, in *FetchGuildEmojisRequest, opts ...grpc.CallOption) (*EmojisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmojisResponse)
+	err := c.cc.Invoke(ctx, Sandwich_FetchGuildEmojis_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) FetchGuildMembers(ctx context.Context, in *FetchGuildMembersRequest, opts ...grpc.CallOption) (*GuildMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GuildMembersResponse)
+	err := c.cc.Invoke(ctx, Sandwich_FetchGuildMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) FetchGuildRoles(ctx context.Context, in *FetchGuildRolesRequest, opts ...grpc.CallOption) (*GuildRolesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GuildRolesResponse)
+	err := c.cc.Invoke(ctx, Sandwich_FetchGuildRoles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) FetchMutualGuilds(ctx context.Context, in *FetchMutualGuildsRequest, opts ...grpc.CallOption) (*GuildsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GuildsResponse)
+	err := c.cc.Invoke(ctx, Sandwich_FetchMutualGuilds_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) RequestGuildChunk(ctx context.Context, in *RequestGuildChunkRequest, opts ...grpc.CallOption) (*BaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BaseResponse)
+	err := c.cc.Invoke(ctx, Sandwich_RequestGuildChunk_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) SendWebsocketMessage(ctx context.Context, in *SendWebsocketMessageRequest, opts ...grpc.CallOption) (*BaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BaseResponse)
+	err := c.cc.Invoke(ctx, Sandwich_SendWebsocketMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sandwichClient) WhereIsGuild(ctx context.Context, in *WhereIsGuildRequest, opts ...grpc.CallOption) (*WhereIsGuildResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WhereIsGuildResponse)
+	err := c.cc.Invoke(ctx, Sandwich_WhereIsGuild_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SandwichServer is the server API for Sandwich service.
+// All implementations must embed UnimplementedSandwichServer
+// for forward compatibility
+//
+// Sandwich provides access to the state and shards of Sandwich.
+//
+// Discord objects are sent as their JSON encoding, the same as the events sent to consumers.
+type SandwichServer interface {
+	FetchConsumerConfiguration(context.Context, *FetchConsumerConfigurationRequest) (*FetchConsumerConfigurationResponse, error)
+	FetchGuild(context.Context, *FetchGuildRequest) (*GuildsResponse, error)
+	FetchGuildChannels(context.Context, *FetchGuildChannelsRequest) (*ChannelsResponse, error)
+	FetchGuildEmojis(context.Context, *FetchGuildEmojisRequest) (*EmojisResponse, error)
+	FetchGuildMembers(context.Context, *FetchGuildMembersRequest) (*GuildMembersResponse, error)
+	FetchGuildRoles(context.Context, *FetchGuildRolesRequest) (*GuildRolesResponse, error)
+	FetchMutualGuilds(context.Context, *FetchMutualGuildsRequest) (*GuildsResponse, error)
+	RequestGuildChunk(context.Context, *RequestGuildChunkRequest) (*BaseResponse, error)
+	SendWebsocketMessage(context.Context, *SendWebsocketMessageRequest) (*BaseResponse, error)
+	WhereIsGuild(context.Context, *WhereIsGuildRequest) (*WhereIsGuildResponse, error)
+	mustEmbedUnimplementedSandwichServer()
+}
+
+// UnimplementedSandwichServer must be embedded to have forward compatible implementations.
+type UnimplementedSandwichServer struct {
+}
+
+func (UnimplementedSandwichServer) FetchConsumerConfiguration(context.Context, *FetchConsumerConfigurationRequest) (*FetchConsumerConfigurationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchConsumerConfiguration not implemented")
+}
+func (UnimplementedSandwichServer) FetchGuild(context.Context, *FetchGuildRequest) (*GuildsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchGuild not implemented")
+}
+func (UnimplementedSandwichServer) FetchGuildChannels(context.Context, *FetchGuildChannelsRequest) (*ChannelsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchGuildChannels not implemented")
+}
+func (UnimplementedSandwichServer) FetchGuildEmojis(context.Context, *FetchGuildEmojisRequest) (*EmojisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchGuildEmojis not implemented")
+}
+func (UnimplementedSandwichServer) FetchGuildMembers(context.Context, *FetchGuildMembersRequest) (*GuildMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchGuildMembers not implemented")
+}
+func (UnimplementedSandwichServer) FetchGuildRoles(context.Context, *FetchGuildRolesRequest) (*GuildRolesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchGuildRoles not implemented")
+}
+func (UnimplementedSandwichServer) FetchMutualGuilds(context.Context, *FetchMutualGuildsRequest) (*GuildsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchMutualGuilds not implemented")
+}
+func (UnimplementedSandwichServer) RequestGuildChunk(context.Context, *RequestGuildChunkRequest) (*BaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestGuildChunk not implemented")
+}
+func (UnimplementedSandwichServer) SendWebsocketMessage(context.Context, *SendWebsocketMessageRequest) (*BaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendWebsocketMessage not implemented")
+}
+func (UnimplementedSandwichServer) WhereIsGuild(context.Context, *WhereIsGuildRequest) (*WhereIsGuildResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WhereIsGuild not implemented")
+}
+func (UnimplementedSandwichServer) mustEmbedUnimplementedSandwichServer() {}
+
+// UnsafeSandwichServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SandwichServer will
+// result in compilation errors.
+type UnsafeSandwichServer interface {
+	mustEmbedUnimplementedSandwichServer()
+}
+
+func RegisterSandwichServer(s grpc.ServiceRegistrar, srv SandwichServer) {
+	s.RegisterService(&Sandwich_ServiceDesc, srv)
+}
+
+func _Sandwich_FetchConsumerConfiguration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchConsumerConfigurationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).FetchConsumerConfiguration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_FetchConsumerConfiguration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).FetchConsumerConfiguration(ctx, req.(*FetchConsumerConfigurationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_FetchGuild_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchGuildRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).FetchGuild(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_FetchGuild_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).FetchGuild(ctx, req.(*FetchGuildRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_FetchGuildChannels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchGuildChannelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).FetchGuildChannels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_FetchGuildChannels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).FetchGuildChannels(ctx, req.(*FetchGuildChannelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_FetchGuildEmojis_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchGuildEmojisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).FetchGuildEmojis(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_FetchGuildEmojis_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).FetchGuildEmojis(ctx, req.(*FetchGuildEmojisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_FetchGuildMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchGuildMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).FetchGuildMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_FetchGuildMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).FetchGuildMembers(ctx, req.(*FetchGuildMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_FetchGuildRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchGuildRolesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).FetchGuildRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_FetchGuildRoles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).FetchGuildRoles(ctx, req.(*FetchGuildRolesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_FetchMutualGuilds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchMutualGuildsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).FetchMutualGuilds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_FetchMutualGuilds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).FetchMutualGuilds(ctx, req.(*FetchMutualGuildsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_RequestGuildChunk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestGuildChunkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).RequestGuildChunk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_RequestGuildChunk_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).RequestGuildChunk(ctx, req.(*RequestGuildChunkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_SendWebsocketMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendWebsocketMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).SendWebsocketMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_SendWebsocketMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).SendWebsocketMessage(ctx, req.(*SendWebsocketMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sandwich_WhereIsGuild_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WhereIsGuildRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SandwichServer).WhereIsGuild(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sandwich_WhereIsGuild_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SandwichServer).WhereIsGuild(ctx, req.(*WhereIsGuildRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Sandwich_ServiceDesc is the grpc.ServiceDesc for Sandwich service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sandwich_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sandwich.Sandwich",
+	HandlerType: (*SandwichServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FetchConsumerConfiguration",
+			Handler:    _Sandwich_FetchConsumerConfiguration_Handler,
+		},
+		{
+			MethodName: "FetchGuild",
+			Handler:    _Sandwich_FetchGuild_Handler,
+		},
+		{
+			MethodName: "FetchGuildChannels",
+			Handler:    _Sandwich_FetchGuildChannels_Handler,
+		},
+		{
+			MethodName: "FetchGuildEmojis",
+			Handler:    _Sandwich_FetchGuildEmojis_Handler,
+		},
+		{
+			MethodName: "FetchGuildMembers",
+			Handler:    _Sandwich_FetchGuildMembers_Handler,
+		},
+		{
+			MethodName: "FetchGuildRoles",
+			Handler:    _Sandwich_FetchGuildRoles_Handler,
+		},
+		{
+			MethodName: "FetchMutualGuilds",
+			Handler:    _Sandwich_FetchMutualGuilds_Handler,
+		},
+		{
+			MethodName: "RequestGuildChunk",
+			Handler:    _Sandwich_RequestGuildChunk_Handler,
+		},
+		{
+			MethodName: "SendWebsocketMessage",
+			Handler:    _Sandwich_SendWebsocketMessage_Handler,
+		},
+		{
+			MethodName: "WhereIsGuild",
+			Handler:    _Sandwich_WhereIsGuild_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sandwich.proto",
+}
+
+const (
+	Producer_Subscribe_FullMethodName = "/sandwich.Producer/Subscribe"
+)
+
+// ProducerClient is the client API for Producer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Producer streams events to consumers, as an alternative to a message queue.
+//
+// If the producer has an expected token, it must be sent as the authorization metadata.
+type ProducerClient interface {
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Producer_SubscribeClient, error)
+}
+
+type producerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProducerClient(cc grpc.ClientConnInterface) ProducerClient {
+	return &producerClient{cc}
+}
+
+func (c *producerClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Producer_SubscribeClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Producer_ServiceDesc.Streams[0], Producer_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &producerSubscribeClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Producer_SubscribeClient interface {
+	Recv() (*SandwichPayload, error)
+	grpc.ClientStream
+}
+
+type producerSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *producerSubscribeClient) Recv() (*SandwichPayload, error) {
+	m := new(SandwichPayload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ProducerServer is the server API for Producer service.
+// All implementations must embed UnimplementedProducerServer
+// for forward compatibility
+//
+// Producer streams events to consumers, as an alternative to a message queue.
+//
+// If the producer has an expected token, it must be sent as the authorization metadata.
+type ProducerServer interface {
+	Subscribe(*SubscribeRequest, Producer_SubscribeServer) error
+	mustEmbedUnimplementedProducerServer()
+}
+
+// UnimplementedProducerServer must be embedded to have forward compatible implementations.
+type UnimplementedProducerServer struct {
+}
+
+func (UnimplementedProducerServer) Subscribe(*SubscribeRequest, Producer_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedProducerServer) mustEmbedUnimplementedProducerServer() {}
+
+// UnsafeProducerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProducerServer will
+// result in compilation errors.
+type UnsafeProducerServer interface {
+	mustEmbedUnimplementedProducerServer()
+}
+
+func RegisterProducerServer(s grpc.ServiceRegistrar, srv ProducerServer) {
+	s.RegisterService(&Producer_ServiceDesc, srv)
+}
+
+func _Producer_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProducerServer).Subscribe(m, &producerSubscribeServer{ServerStream: stream})
+}
+
+type Producer_SubscribeServer interface {
+	Send(*SandwichPayload) error
+	grpc.ServerStream
+}
+
+type producerSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *producerSubscribeServer) Send(m *SandwichPayload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Producer_ServiceDesc is the grpc.ServiceDesc for Producer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Producer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sandwich.Producer",
+	HandlerType: (*ProducerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Producer_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sandwich.proto",
+}